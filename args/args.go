@@ -1,6 +1,22 @@
 package args
 
-import "flag"
+import (
+	"flag"
+	"picochat/batch"
+	"strings"
+)
+
+// stringList collects the values of a repeatable flag.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
 
 var (
 	ConfigPath  = flag.String("config", "", "Loads a configuration file")
@@ -8,11 +24,23 @@ var (
 	Quiet       = flag.Bool("quiet", false, "Suppresses all app messages")
 	Model       = flag.String("model", "", "Overrides configured model")
 	ShowVersion = flag.Bool("version", false, "Shows the version and exits")
-	Image       = flag.String("image", "", "Sets a path to an image file")
 	Output      = flag.String("output", "", "Sets the response output format (plain, json, json-pretty, yaml)")
 	Schema      = flag.String("schema", "", "Sets the path to a JSON schema file")
+	Template    = flag.String("template", "", "Applies a prompt template to each prompt (key [name=value ...])")
+	Script      = flag.String("script", "", "Runs the prompts and commands of a script file and exits")
+	KeepGoing   = flag.Bool("keep-going", false, "Continues a script after a failed step")
+	Batch       = flag.String("batch", "", "Processes the prompts of a JSONL file and exits")
+	Out         = flag.String("out", "", "Sets the JSONL result file of -batch (resumes existing files)")
+	Workers     = flag.Int("workers", batch.DefaultWorkers, "Sets the number of parallel -batch requests")
+	Images      stringList
+	Files       stringList
 )
 
+func init() {
+	flag.Var(&Images, "image", "Attaches an image file or URL to the first prompt (repeatable)")
+	flag.Var(&Files, "file", "Attaches a text file, directory or glob to the first prompt (repeatable)")
+}
+
 func Parse() {
 	flag.Parse()
 }
@@ -25,6 +25,13 @@ type ChatChunk struct {
 type ChatFinal struct {
 	Reasoning string
 	Content   string
+	Usage     Usage
+}
+
+// Usage holds the token counts reported by the server (zero if unknown).
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens" yaml:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens" yaml:"completion_tokens"`
 }
 
 type Client interface {
@@ -33,6 +40,20 @@ type Client interface {
 	GetServerVersion() (string, error)
 }
 
+// SupportsFileInput checks if a backend accepts PDF documents as native
+// file input instead of extracted text.
+//
+// Parameters:
+//
+//	name (string) - the configured backend name
+//
+// Returns:
+//
+//	bool - true for the Responses API backend
+func SupportsFileInput(name string) bool {
+	return strings.ToLower(strings.TrimSpace(name)) == "responses"
+}
+
 func New(cfg *config.Config) Client {
 	baseURL := strings.TrimRight(cfg.URL, "/")
 	switch strings.ToLower(strings.TrimSpace(cfg.Backend)) {
@@ -1,9 +1,11 @@
 package backend
 
 import (
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"picochat/messages"
 	"strings"
 	"testing"
 )
@@ -148,3 +150,37 @@ func TestOllamaInvalidBaseURLBranches(t *testing.T) {
 		t.Fatal("expected error for invalid version base url, got nil")
 	}
 }
+
+func TestFetchRemoteImages(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cat.png":
+			_, _ = w.Write(png)
+		case "/page.html":
+			_, _ = w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	in := []messages.Message{{Role: messages.RoleUser, Images: []string{"sha256:x.png", srv.URL + "/cat.png"}}}
+	out, err := fetchRemoteImages(in)
+	if err != nil {
+		t.Fatalf("fetchRemoteImages failed: %v", err)
+	}
+	if out[0].Images[0] != "sha256:x.png" || out[0].Images[1] != base64.StdEncoding.EncodeToString(png) {
+		t.Fatalf("unexpected images: %v", out[0].Images)
+	}
+	if in[0].Images[1] != srv.URL+"/cat.png" {
+		t.Fatal("input messages were modified")
+	}
+
+	for _, path := range []string{"/page.html", "/missing.png"} {
+		in := []messages.Message{{Role: messages.RoleUser, Images: []string{srv.URL + path}}}
+		if _, err := fetchRemoteImages(in); err == nil {
+			t.Fatalf("expected error for %s", path)
+		}
+	}
+}
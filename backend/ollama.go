@@ -2,15 +2,20 @@ package backend
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"picochat/messages"
 	"picochat/utils"
+	"slices"
 	"strings"
+	"time"
 )
 
+const maxRemoteImageSize = 20 << 20 // limit for downloaded image URLs
+
 type ollamaClient struct {
 	baseURL string
 }
@@ -39,7 +44,9 @@ type ollamaStreamResponse struct {
 		Thinking string `json:"thinking,omitempty"`
 		Content  string `json:"content"`
 	} `json:"message"`
-	Done bool `json:"done"`
+	Done            bool `json:"done"`
+	PromptEvalCount int  `json:"prompt_eval_count"`
+	EvalCount       int  `json:"eval_count"`
 }
 
 type ollamaModelTag struct {
@@ -71,7 +78,21 @@ func (c *ollamaClient) ChatStream(input ChatInput, onChunk func(ChatChunk) error
 		reasoning = &ollamaReasoning{Effort: input.Effort}
 	}
 
-	ollamaMessages := normalizeOllamaImages(input.Messages)
+	inlined, err := messages.InlineDocuments(input.Messages)
+	if err != nil {
+		return ChatFinal{}, fmt.Errorf("load documents failed: %w", err)
+	}
+	resolved, err := messages.ResolveImages(inlined)
+	if err != nil {
+		return ChatFinal{}, fmt.Errorf("load images failed: %w", err)
+	}
+
+	resolved, err = fetchRemoteImages(resolved)
+	if err != nil {
+		return ChatFinal{}, fmt.Errorf("load images failed: %w", err)
+	}
+
+	ollamaMessages := normalizeOllamaImages(resolved)
 	var options *ollamaOptions
 	if input.Temperature != nil || input.TopP != nil {
 		options = &ollamaOptions{
@@ -114,6 +135,7 @@ func (c *ollamaClient) ChatStream(input ChatInput, onChunk func(ChatChunk) error
 	decoder := json.NewDecoder(response.Body)
 	var fullThinking strings.Builder
 	var fullContent strings.Builder
+	var usage Usage
 
 	for {
 		var res ollamaStreamResponse
@@ -130,6 +152,9 @@ func (c *ollamaClient) ChatStream(input ChatInput, onChunk func(ChatChunk) error
 		if res.Message.Content != "" {
 			fullContent.WriteString(res.Message.Content)
 		}
+		if res.Done {
+			usage = Usage{PromptTokens: res.PromptEvalCount, CompletionTokens: res.EvalCount}
+		}
 
 		if onChunk != nil {
 			if err := onChunk(ChatChunk{
@@ -145,6 +170,7 @@ func (c *ollamaClient) ChatStream(input ChatInput, onChunk func(ChatChunk) error
 	return ChatFinal{
 		Reasoning: fullThinking.String(),
 		Content:   fullContent.String(),
+		Usage:     usage,
 	}, nil
 }
 
@@ -226,6 +252,78 @@ func (c *ollamaClient) GetServerVersion() (string, error) {
 	return v.Version, nil
 }
 
+// fetchRemoteImages downloads image URLs and replaces them with base64
+// data, because Ollama accepts inline images only.
+//
+// Parameters:
+//
+//	in ([]messages.Message) - input messages
+//
+// Returns:
+//
+//	[]messages.Message - copied slice without image URLs
+//	error              - error if a download fails
+func fetchRemoteImages(in []messages.Message) ([]messages.Message, error) {
+	out := make([]messages.Message, len(in))
+	copy(out, in)
+
+	client := http.Client{Timeout: 30 * time.Second}
+	for i := range out {
+		if !slices.ContainsFunc(out[i].Images, messages.IsImageURL) {
+			continue
+		}
+
+		imgs := slices.Clone(out[i].Images)
+		for j, img := range imgs {
+			if !messages.IsImageURL(img) {
+				continue
+			}
+			data, err := downloadImage(&client, img)
+			if err != nil {
+				return nil, err
+			}
+			imgs[j] = base64.StdEncoding.EncodeToString(data)
+		}
+		out[i].Images = imgs
+	}
+
+	return out, nil
+}
+
+// downloadImage fetches an image URL up to maxRemoteImageSize bytes.
+//
+// Parameters:
+//
+//	client (*http.Client) - the HTTP client
+//	imageURL (string)     - the image URL
+//
+// Returns:
+//
+//	[]byte - the image data
+//	error  - error if the request fails or the data is not an image
+func downloadImage(client *http.Client, imageURL string) ([]byte, error) {
+	resp, err := client.Get(imageURL)
+	if err != nil {
+		return nil, fmt.Errorf("download image failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download image %q failed: %s", imageURL, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("download image failed: %w", err)
+	}
+	if len(data) > maxRemoteImageSize {
+		return nil, fmt.Errorf("image %q larger than %d MiB", imageURL, maxRemoteImageSize>>20)
+	}
+	if mt := http.DetectContentType(data); !strings.HasPrefix(mt, "image/") {
+		return nil, fmt.Errorf("URL %q is not an image (%s)", imageURL, mt)
+	}
+	return data, nil
+}
+
 // normalizeOllamaImages normalizes image payloads for Ollama requests.
 // If an image is a data URL, the prefix is removed and only plain base64
 // data is kept.
@@ -13,11 +13,16 @@ type openAIClient struct {
 }
 
 type openAIChatCompletionsRequest struct {
-	Model       string              `json:"model"`
-	Messages    []openAIChatMessage `json:"messages"`
-	Stream      bool                `json:"stream"`
-	Temperature *float64            `json:"temperature,omitempty"`
-	TopP        *float64            `json:"top_p,omitempty"`
+	Model         string               `json:"model"`
+	Messages      []openAIChatMessage  `json:"messages"`
+	Stream        bool                 `json:"stream"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+	Temperature   *float64             `json:"temperature,omitempty"`
+	TopP          *float64             `json:"top_p,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type openAIChatMessage struct {
@@ -48,12 +53,22 @@ type openAIStreamEvent struct {
 //	ChatFinal - accumulated reasoning and content
 //	error     - error if request/stream handling fails
 func (c *openAIClient) ChatStream(input ChatInput, onChunk func(ChatChunk) error) (ChatFinal, error) {
+	inlined, err := messages.InlineDocuments(input.Messages)
+	if err != nil {
+		return ChatFinal{}, fmt.Errorf("load documents failed: %w", err)
+	}
+	resolved, err := messages.ResolveImages(inlined)
+	if err != nil {
+		return ChatFinal{}, fmt.Errorf("load images failed: %w", err)
+	}
+
 	payload := openAIChatCompletionsRequest{
-		Model:       input.Model,
-		Messages:    mapMessagesToOpenAIChatMessages(input.Messages),
-		Stream:      true,
-		Temperature: input.Temperature,
-		TopP:        input.TopP,
+		Model:         input.Model,
+		Messages:      mapMessagesToOpenAIChatMessages(resolved),
+		Stream:        true,
+		StreamOptions: &openAIStreamOptions{IncludeUsage: true}, // usage in the last event
+		Temperature:   input.Temperature,
+		TopP:          input.TopP,
 	}
 
 	return postStreamingJSON(
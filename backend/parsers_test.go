@@ -219,6 +219,28 @@ func TestMapMessagesToResponsesInput(t *testing.T) {
 	}
 }
 
+func TestMapMessagesToResponsesInput_Documents(t *testing.T) {
+	in := []messages.Message{
+		{
+			Role:      messages.RoleUser,
+			Content:   "summarize",
+			Documents: []messages.Document{{Name: "report.pdf", Ref: "data:application/pdf;base64,JVBERi0="}},
+		},
+	}
+
+	out := mapMessagesToResponsesInput(in)
+	if len(out) != 1 || len(out[0].Content) != 2 {
+		t.Fatalf("unexpected output shape: %+v", out)
+	}
+	file := out[0].Content[0]
+	if file.Type != "input_file" || file.Filename != "report.pdf" || !strings.HasPrefix(file.FileData, "data:application/pdf;base64,") {
+		t.Fatalf("unexpected file part: %+v", file)
+	}
+	if out[0].Content[1].Type != "input_text" {
+		t.Fatalf("unexpected text part: %+v", out[0].Content[1])
+	}
+}
+
 func TestNormalizeOllamaImages(t *testing.T) {
 	in := []messages.Message{
 		{
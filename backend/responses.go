@@ -43,6 +43,8 @@ type responsesInputPart struct {
 	Text     string `json:"text,omitempty"`
 	ImageURL string `json:"image_url,omitempty"`
 	Detail   string `json:"detail,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	FileData string `json:"file_data,omitempty"`
 }
 
 // ChatStream sends a streaming request to the OpenAI Responses endpoint.
@@ -57,9 +59,18 @@ type responsesInputPart struct {
 //	ChatFinal - accumulated reasoning and content
 //	error     - error if request/stream handling fails
 func (c *openAIResponsesClient) ChatStream(input ChatInput, onChunk func(ChatChunk) error) (ChatFinal, error) {
+	resolved, err := messages.ResolveImages(input.Messages)
+	if err != nil {
+		return ChatFinal{}, fmt.Errorf("load images failed: %w", err)
+	}
+	resolved, err = messages.ResolveDocuments(resolved)
+	if err != nil {
+		return ChatFinal{}, fmt.Errorf("load documents failed: %w", err)
+	}
+
 	reqPayload := responsesRequest{
 		Model:       input.Model,
-		Input:       mapMessagesToResponsesInput(input.Messages),
+		Input:       mapMessagesToResponsesInput(resolved),
 		Stream:      true,
 		Temperature: input.Temperature,
 		TopP:        input.TopP,
@@ -146,7 +157,15 @@ func mapMessagesToResponsesInput(in []messages.Message) []responsesInputItem {
 	out := make([]responsesInputItem, 0, len(in))
 
 	for _, msg := range in {
-		parts := make([]responsesInputPart, 0, 1+len(msg.Images))
+		parts := make([]responsesInputPart, 0, 1+len(msg.Images)+len(msg.Documents))
+
+		for _, doc := range msg.Documents {
+			parts = append(parts, responsesInputPart{
+				Type:     "input_file",
+				Filename: doc.Name,
+				FileData: doc.Ref,
+			})
+		}
 
 		if strings.TrimSpace(msg.Content) != "" {
 			parts = append(parts, responsesInputPart{
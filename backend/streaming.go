@@ -2,6 +2,7 @@ package backend
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
@@ -12,6 +13,23 @@ type parseEventFn func(data string) (thinking, content string, done bool, err er
 type streamAccum struct {
 	Reasoning strings.Builder
 	Content   strings.Builder
+	Usage     Usage
+}
+
+// streamUsage matches the usage objects of Chat Completions events and of
+// the Responses API "response.completed" event.
+type streamUsage struct {
+	Usage    *streamUsageCounts `json:"usage"`
+	Response struct {
+		Usage *streamUsageCounts `json:"usage"`
+	} `json:"response"`
+}
+
+type streamUsageCounts struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	InputTokens      int `json:"input_tokens"`
+	OutputTokens     int `json:"output_tokens"`
 }
 
 // consumeSSEStream consumes an SSE stream body, parses each data event
@@ -73,6 +91,9 @@ func consumeSSEStream(
 		if content != "" {
 			acc.Content.WriteString(content)
 		}
+		if usage, ok := parseStreamUsage(data); ok {
+			acc.Usage = usage
+		}
 
 		if onChunk != nil {
 			if err := onChunk(ChatChunk{
@@ -92,5 +113,37 @@ func consumeSSEStream(
 	return ChatFinal{
 		Reasoning: acc.Reasoning.String(),
 		Content:   acc.Content.String(),
+		Usage:     acc.Usage,
 	}, nil
 }
+
+// parseStreamUsage extracts the token usage of an SSE event payload.
+//
+// Parameters:
+//
+//	data (string) - SSE event payload (JSON)
+//
+// Returns:
+//
+//	Usage - the reported token counts
+//	bool  - true if the event contains usage data
+func parseStreamUsage(data string) (Usage, bool) {
+	if !strings.Contains(data, `"usage"`) {
+		return Usage{}, false
+	}
+	var evt streamUsage
+	if err := json.Unmarshal([]byte(data), &evt); err != nil {
+		return Usage{}, false
+	}
+	counts := evt.Usage
+	if counts == nil {
+		counts = evt.Response.Usage
+	}
+	if counts == nil {
+		return Usage{}, false
+	}
+	return Usage{
+		PromptTokens:     counts.PromptTokens + counts.InputTokens,
+		CompletionTokens: counts.CompletionTokens + counts.OutputTokens,
+	}, true
+}
@@ -113,3 +113,26 @@ func TestConsumeSSEStream_ProcessesFinalLineWithoutTrailingNewline(t *testing.T)
 		t.Fatalf("final content = %q, want %q", final.Content, "c")
 	}
 }
+
+func TestParseStreamUsage(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Usage
+		ok   bool
+	}{
+		{"chat completions", `{"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":34}}`, Usage{12, 34}, true},
+		{"responses", `{"type":"response.completed","response":{"usage":{"input_tokens":5,"output_tokens":7}}}`, Usage{5, 7}, true},
+		{"null usage", `{"choices":[{"delta":{"content":"x"}}],"usage":null}`, Usage{}, false},
+		{"no usage", `{"choices":[{"delta":{"content":"x"}}]}`, Usage{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseStreamUsage(tt.data)
+			if got != tt.want || ok != tt.ok {
+				t.Fatalf("parseStreamUsage() = (%+v, %v), want (%+v, %v)", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"picochat/backend"
+	"picochat/chat"
+	"picochat/command"
+	"picochat/config"
+	"picochat/messages"
+	"picochat/paths"
+	"picochat/utils"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultWorkers is the number of parallel requests if not set otherwise.
+	DefaultWorkers = 4
+	itemContext    = 4 // system, user and assistant message of a single turn
+)
+
+// Item is one input line of a batch file.
+type Item struct {
+	ID       string          `json:"id"`
+	Prompt   string          `json:"prompt"`
+	System   string          `json:"system,omitempty"`
+	Model    string          `json:"model,omitempty"`
+	Schema   json.RawMessage `json:"schema,omitempty"` // file path or inline JSON schema
+	Image    string          `json:"image,omitempty"`
+	Images   []string        `json:"images,omitempty"`
+	Template string          `json:"template,omitempty"` // key [name=value ...]
+}
+
+// Result is one output line of a batch run.
+type Result struct {
+	ID         string         `json:"id"`
+	Model      string         `json:"model,omitempty"`
+	Output     string         `json:"output,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Usage      *backend.Usage `json:"usage,omitempty"`
+	DurationMS int64          `json:"duration_ms"`
+}
+
+// Summary counts the items of a batch run.
+type Summary struct {
+	Total   int
+	Done    int
+	Failed  int
+	Skipped int
+	Elapsed time.Duration
+}
+
+// String formats the summary for the final info line.
+func (s Summary) String() string {
+	return fmt.Sprintf("Batch finished in %s: %d done, %d failed, %d skipped of %d.",
+		s.Elapsed.Round(time.Millisecond), s.Done, s.Failed, s.Skipped, s.Total)
+}
+
+// ReadItems parses a JSONL batch file. Empty lines are ignored; items
+// without id get their line number as id.
+//
+// Parameters:
+//
+//	r (io.Reader) - the JSONL input
+//
+// Returns:
+//
+//	[]Item - the items in input order
+//	error  - error if a line is invalid or an id is used twice
+func ReadItems(r io.Reader) ([]Item, error) {
+	var items []Item
+	seen := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var item Item
+		if err := json.Unmarshal(line, &item); err != nil {
+			return nil, fmt.Errorf("line %d: invalid JSON: %w", n, err)
+		}
+		if strings.TrimSpace(item.Prompt) == "" && item.Template == "" {
+			return nil, fmt.Errorf("line %d: prompt is missing", n)
+		}
+		if item.ID == "" {
+			item.ID = strconv.Itoa(n)
+		}
+		if prev, ok := seen[item.ID]; ok {
+			return nil, fmt.Errorf("line %d: id %q already used in line %d", n, item.ID, prev)
+		}
+		seen[item.ID] = n
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read batch failed: %w", err)
+	}
+	return items, nil
+}
+
+// LoadItems reads and parses a JSONL batch file.
+//
+// Parameters:
+//
+//	path (string) - path of the batch file ("~" is expanded)
+//
+// Returns:
+//
+//	[]Item - the items in input order
+//	error  - error if the file cannot be read or parsed
+func LoadItems(path string) ([]Item, error) {
+	fullPath, err := paths.ExpandHomeDir(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("open batch failed: %w", err)
+	}
+	defer f.Close()
+	return ReadItems(f)
+}
+
+// DoneIDs returns the ids of the successful results of an existing output
+// file, so a batch run can be resumed. Failed items are run again. A
+// missing file has no ids; unreadable lines (e.g. a line cut off by an
+// aborted run) are ignored.
+//
+// Parameters:
+//
+//	path (string) - path of the output file
+//
+// Returns:
+//
+//	map[string]bool - the ids of the successful results
+//	error           - error if the file exists but cannot be read
+func DoneIDs(path string) (map[string]bool, error) {
+	done := make(map[string]bool)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open output failed: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64<<20)
+	for scanner.Scan() {
+		var res Result
+		if json.Unmarshal(scanner.Bytes(), &res) == nil && res.ID != "" && res.Error == "" {
+			done[res.ID] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read output failed: %w", err)
+	}
+	return done, nil
+}
+
+// OpenOutput opens an output file for appending. If the file ends with an
+// incomplete line, a line break is added first.
+//
+// Parameters:
+//
+//	path (string) - path of the output file
+//
+// Returns:
+//
+//	*os.File - the file opened for appending
+//	error    - error if the file cannot be opened
+func OpenOutput(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open output failed: %w", err)
+	}
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			if _, err := f.Write([]byte("\n")); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("write output failed: %w", err)
+			}
+		}
+	}
+	return f, nil
+}
+
+// Run processes the items with a pool of workers and writes one result
+// line per item in input order. Items with an id in done are skipped.
+//
+// Parameters:
+//
+//	cfg (*config.Config)    - base configuration of all requests
+//	items ([]Item)          - the batch items
+//	done (map[string]bool)  - ids to skip (already in the output)
+//	workers (int)           - number of parallel requests
+//	w (io.Writer)           - output for the JSONL results
+//	progress (func(Result)) - optional callback per finished item
+//
+// Returns:
+//
+//	Summary - counts of the run
+//	error   - error if a result cannot be written
+func Run(cfg *config.Config, items []Item, done map[string]bool, workers int, w io.Writer, progress func(Result)) (Summary, error) {
+	start := time.Now()
+	summary := Summary{Total: len(items)}
+
+	var todo []Item
+	for _, item := range items {
+		if done[item.ID] {
+			summary.Skipped++
+			continue
+		}
+		todo = append(todo, item)
+	}
+	workers = max(1, min(workers, len(todo)))
+
+	type indexed struct {
+		index  int
+		result Result
+	}
+	jobs := make(chan int)
+	results := make(chan indexed)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results <- indexed{i, runItem(cfg, todo[i])}
+			}
+		}()
+	}
+	go func() {
+		for i := range todo {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	// results arrive in any order and are written in input order
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	pending := make(map[int]Result)
+	next := 0
+	var writeErr error
+	for r := range results {
+		if progress != nil {
+			progress(r.result)
+		}
+		if r.result.Error != "" {
+			summary.Failed++
+		} else {
+			summary.Done++
+		}
+
+		pending[r.index] = r.result
+		for writeErr == nil {
+			res, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if err := enc.Encode(res); err != nil {
+				writeErr = fmt.Errorf("write result failed: %w", err)
+			}
+		}
+	}
+
+	summary.Elapsed = time.Since(start)
+	return summary, writeErr
+}
+
+// runItem sends the request of one item.
+//
+// Parameters:
+//
+//	cfg (*config.Config) - base configuration
+//	item (Item)          - the batch item
+//
+// Returns:
+//
+//	Result - the answer or the error of the item
+func runItem(cfg *config.Config, item Item) Result {
+	start := time.Now()
+	res := Result{ID: item.ID, Model: cfg.Model}
+
+	reqCfg, history, err := prepareItem(cfg, item)
+	if err == nil {
+		res.Model = reqCfg.Model
+		var result *chat.ChatResult
+		result, err = chat.HandleChat(reqCfg, history, make(chan struct{}))
+		if err == nil {
+			res.Output = result.Output
+			if result.Usage != (backend.Usage{}) {
+				res.Usage = &result.Usage
+			}
+		}
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+
+	res.DurationMS = time.Since(start).Milliseconds()
+	return res
+}
+
+// prepareItem builds the request config and the history of one item. The
+// item settings override the template settings, which override the base
+// configuration.
+//
+// Parameters:
+//
+//	cfg (*config.Config) - base configuration
+//	item (Item)          - the batch item
+//
+// Returns:
+//
+//	*config.Config         - the request configuration
+//	*messages.ChatHistory  - system prompt and user prompt of the item
+//	error                  - error if the template, schema or image fails
+func prepareItem(cfg *config.Config, item Item) (*config.Config, *messages.ChatHistory, error) {
+	reqCfg := *cfg
+	prompt := item.Prompt
+
+	if item.Template != "" {
+		key, args, _ := command.ParseTemplateArgs(strings.Fields(item.Template))
+		tpl, err := config.LookupTemplate(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		prompt, err = tpl.Render(config.NewTemplateVars(prompt, args, noClipboard))
+		if err != nil {
+			return nil, nil, fmt.Errorf("template %q: %w", key, err)
+		}
+		next, _, err := tpl.ApplyTo(&reqCfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("template %q: %w", key, err)
+		}
+		reqCfg = *next
+	}
+
+	if item.Model != "" {
+		reqCfg.Model = item.Model
+	}
+	if len(item.Schema) > 0 {
+		schema, err := loadSchema(item.Schema)
+		if err != nil {
+			return nil, nil, err
+		}
+		reqCfg.SchemaFmt = schema
+	}
+
+	system := reqCfg.Prompt
+	if item.System != "" {
+		system = item.System
+		reqCfg.PromptOverride = ""
+	}
+
+	// answers are collected, not streamed to the terminal
+	reqCfg.Quiet = true
+	reqCfg.OutputFmt = "json"
+
+	images := item.Images
+	if item.Image != "" {
+		images = append([]string{item.Image}, images...)
+	}
+	history := messages.NewHistory(system, itemContext)
+	if err := history.AddUserAttachments(prompt, images, nil); err != nil {
+		return nil, nil, err
+	}
+	return &reqCfg, history, nil
+}
+
+// loadSchema reads the schema of an item, given as file path or as JSON
+// object.
+//
+// Parameters:
+//
+//	raw (json.RawMessage) - the schema value of the item
+//
+// Returns:
+//
+//	map[string]any - the JSON schema
+//	error          - error if the schema cannot be loaded
+func loadSchema(raw json.RawMessage) (map[string]any, error) {
+	var path string
+	if json.Unmarshal(raw, &path) == nil {
+		schema, err := utils.LoadSchemaFromFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("load json schema file failed: %w", err)
+		}
+		return schema, nil
+	}
+
+	var schema map[string]any
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, fmt.Errorf("schema must be a file path or a JSON object")
+	}
+	return schema, nil
+}
+
+// noClipboard is the clipboard reader of batch templates.
+func noClipboard() (string, error) {
+	return "", fmt.Errorf("clipboard not available in batch mode")
+}
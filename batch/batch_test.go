@@ -0,0 +1,213 @@
+package batch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"picochat/config"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// echoServer answers with the model name and the last user prompt. Prompts
+// starting with "slow" take longer, "fail" returns an HTTP error.
+func echoServer(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var req struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		prompt := req.Messages[len(req.Messages)-1].Content
+		if strings.HasPrefix(prompt, "fail") {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		if strings.HasPrefix(prompt, "slow") {
+			time.Sleep(50 * time.Millisecond)
+		}
+		answer, _ := json.Marshal(fmt.Sprintf("%s|%s|%s", req.Model, req.Messages[0].Content, prompt))
+		fmt.Fprintf(w, `{"message":{"content":%s},"done":true,"prompt_eval_count":3,"eval_count":4}`+"\n", answer)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestReadItems(t *testing.T) {
+	input := `{"id":"a","prompt":"hello"}
+
+{"prompt":"no id","model":"m2","schema":{"type":"object"}}
+`
+	items, err := ReadItems(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadItems() error: %v", err)
+	}
+	if len(items) != 2 || items[0].ID != "a" || items[1].ID != "3" || items[1].Model != "m2" {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+
+	for _, bad := range []string{
+		`{"prompt":`,
+		`{"id":"x"}`,
+		`{"id":"x","prompt":"a"}` + "\n" + `{"id":"x","prompt":"b"}`,
+	} {
+		if _, err := ReadItems(strings.NewReader(bad)); err == nil {
+			t.Errorf("ReadItems(%q) expected error", bad)
+		}
+	}
+}
+
+func TestRun_OrderAndResume(t *testing.T) {
+	var calls atomic.Int32
+	server := echoServer(t, &calls)
+	cfg := &config.Config{URL: server.URL, Model: "base", Prompt: "sys"}
+
+	items := []Item{
+		{ID: "1", Prompt: "slow first"},
+		{ID: "2", Prompt: "second", Model: "other", System: "custom"},
+		{ID: "3", Prompt: "fail third"},
+		{ID: "4", Prompt: "done before"},
+	}
+
+	var out bytes.Buffer
+	var progress atomic.Int32
+	summary, err := Run(cfg, items, map[string]bool{"4": true}, 3, &out, func(Result) { progress.Add(1) })
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if summary.Done != 2 || summary.Failed != 1 || summary.Skipped != 1 || summary.Total != 4 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if calls.Load() != 3 || progress.Load() != 3 {
+		t.Fatalf("calls = %d, progress = %d, want 3", calls.Load(), progress.Load())
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 result lines, got %q", out.String())
+	}
+	var results []Result
+	for _, line := range lines {
+		var r Result
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("invalid result line %q: %v", line, err)
+		}
+		results = append(results, r)
+	}
+	if results[0].ID != "1" || results[0].Output != "base|sys|slow first" {
+		t.Errorf("unexpected first result: %+v", results[0])
+	}
+	if results[0].Usage == nil || results[0].Usage.PromptTokens != 3 || results[0].Usage.CompletionTokens != 4 {
+		t.Errorf("unexpected usage: %+v", results[0].Usage)
+	}
+	if results[1].ID != "2" || results[1].Output != "other|custom|second" || results[1].Model != "other" {
+		t.Errorf("unexpected second result: %+v", results[1])
+	}
+	if results[2].ID != "3" || !strings.Contains(results[2].Error, "boom") || results[2].Output != "" {
+		t.Errorf("unexpected third result: %+v", results[2])
+	}
+}
+
+func TestResumeFromOutputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+	// the last line was cut off by an aborted run
+	if err := os.WriteFile(path, []byte(`{"id":"1","output":"x"}`+"\n"+`{"id":"2","out`), 0644); err != nil {
+		t.Fatalf("write output failed: %v", err)
+	}
+
+	done, err := DoneIDs(path)
+	if err != nil {
+		t.Fatalf("DoneIDs() error: %v", err)
+	}
+	if !done["1"] || done["2"] || len(done) != 1 {
+		t.Fatalf("unexpected ids: %v", done)
+	}
+
+	f, err := OpenOutput(path)
+	if err != nil {
+		t.Fatalf("OpenOutput() error: %v", err)
+	}
+	fmt.Fprintln(f, `{"id":"2","output":"y"}`)
+	f.Close()
+
+	done, err = DoneIDs(path)
+	if err != nil || !done["1"] || !done["2"] {
+		t.Fatalf("DoneIDs() after append = (%v, %v)", done, err)
+	}
+	if done, err := DoneIDs(filepath.Join(t.TempDir(), "missing.jsonl")); err != nil || len(done) != 0 {
+		t.Fatalf("DoneIDs(missing) = (%v, %v), want empty", done, err)
+	}
+}
+
+func TestResume_RetriesFailedItems(t *testing.T) {
+	var calls atomic.Int32
+	server := echoServer(t, &calls)
+	cfg := &config.Config{URL: server.URL, Model: "base", Prompt: "sys"}
+
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+	previous := `{"id":"1","output":"x"}` + "\n" + `{"id":"2","error":"connection refused"}` + "\n"
+	if err := os.WriteFile(path, []byte(previous), 0644); err != nil {
+		t.Fatalf("write output failed: %v", err)
+	}
+
+	done, err := DoneIDs(path)
+	if err != nil || !done["1"] || done["2"] {
+		t.Fatalf("DoneIDs() = (%v, %v), want only id 1", done, err)
+	}
+
+	f, err := OpenOutput(path)
+	if err != nil {
+		t.Fatalf("OpenOutput() error: %v", err)
+	}
+	items := []Item{{ID: "1", Prompt: "first"}, {ID: "2", Prompt: "second"}}
+	summary, err := Run(cfg, items, done, 2, f, nil)
+	f.Close()
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if summary.Done != 1 || summary.Skipped != 1 || calls.Load() != 1 {
+		t.Fatalf("summary = %+v, calls = %d, want id 2 run again", summary, calls.Load())
+	}
+
+	if done, err = DoneIDs(path); err != nil || !done["1"] || !done["2"] {
+		t.Fatalf("DoneIDs() after resume = (%v, %v), want ids 1 and 2", done, err)
+	}
+}
+
+func TestPrepareItem_Schema(t *testing.T) {
+	cfg := &config.Config{Model: "base", Prompt: "sys", OutputFmt: "plain"}
+
+	reqCfg, history, err := prepareItem(cfg, Item{Prompt: "hi", Schema: json.RawMessage(`{"type":"object"}`)})
+	if err != nil {
+		t.Fatalf("prepareItem() error: %v", err)
+	}
+	if reqCfg.SchemaFmt["type"] != "object" || reqCfg.OutputFmt != "json" || !reqCfg.Quiet {
+		t.Fatalf("unexpected request config: %+v", reqCfg)
+	}
+	if cfg.OutputFmt != "plain" || cfg.SchemaFmt != nil {
+		t.Fatal("base config was modified")
+	}
+	if history.Len() != 2 || history.GetLast().Content != "hi" {
+		t.Fatalf("unexpected history: %+v", history.Get())
+	}
+
+	if _, _, err := prepareItem(cfg, Item{Prompt: "hi", Schema: json.RawMessage(`"missing.json"`)}); err == nil {
+		t.Fatal("expected error for missing schema file")
+	}
+	if _, _, err := prepareItem(cfg, Item{Prompt: "hi", Schema: json.RawMessage(`[1]`)}); err == nil {
+		t.Fatal("expected error for invalid schema")
+	}
+}
@@ -2,22 +2,31 @@ package chat
 
 import (
 	"fmt"
+	"os"
 	"picochat/backend"
 	"picochat/config"
 	"picochat/console"
+	"picochat/highlight"
 	"picochat/jsonutils"
+	"picochat/markdown"
 	"picochat/messages"
+	"slices"
 	"strings"
 	"time"
 )
 
 type ChatResult struct {
-	Output     string  `json:"output" yaml:"output"`
-	Elapsed    string  `json:"elapsed" yaml:"elapsed"`
-	TokensPS   float64 `json:"tokens_per_sec" yaml:"tokens_per_sec"`
-	Structured bool    `json:"-" yaml:"-"`
+	Output     string        `json:"output" yaml:"output"`
+	Elapsed    string        `json:"elapsed" yaml:"elapsed"`
+	TokensPS   float64       `json:"tokens_per_sec" yaml:"tokens_per_sec"`
+	Usage      backend.Usage `json:"-" yaml:"-"`
+	Structured bool          `json:"-" yaml:"-"`
 }
 
+// stdoutColors reports whether the answer is printed with colors,
+// can be replaced in tests.
+var stdoutColors = console.StdoutColors
+
 // HandleChat sends a chat request to the configured model, streams the response,
 // updates the chat history, and returns a summary message with elapsed time
 // and token speed.
@@ -49,10 +58,23 @@ func HandleChat(cfg *config.Config, history *messages.ChatHistory, stop chan str
 	streamPlain := cfg.OutputFmt == "plain"
 	structured := cfg.Backend == "ollama" && cfg.HasSchema()
 
+	// Markdown is rendered for the terminal only, piped output stays raw
+	var render *markdown.Renderer
+	if streamPlain && !structured && cfg.Markdown && stdoutColors() {
+		render = markdown.NewRenderer(os.Stdout, highlight.ThemeFor(cfg.Highlight))
+	}
+
+	msgs := history.Messages
+	if cfg.PromptOverride != "" && len(msgs) > 0 {
+		// system prompt of a template applies to this request only
+		msgs = slices.Clone(msgs)
+		msgs[0].Content = cfg.PromptOverride
+	}
+
 	client := backend.New(cfg)
-	_, err = client.ChatStream(backend.ChatInput{
+	final, err := client.ChatStream(backend.ChatInput{
 		Model:       cfg.Model,
-		Messages:    history.Messages,
+		Messages:    msgs,
 		Temperature: cfg.Temperature,
 		TopP:        cfg.Top_p,
 		Reasoning:   cfg.Reasoning,
@@ -68,7 +90,7 @@ func HandleChat(cfg *config.Config, history *messages.ChatHistory, stop chan str
 		if chunk.Thinking != "" {
 			fullThinking.WriteString(chunk.Thinking)
 			if streamPlain && cfg.Reasoning {
-				console.ColorPrint(console.Gray256, chunk.Thinking)
+				console.ColorPrint(console.ActiveTheme().Reasoning, chunk.Thinking)
 			}
 		}
 
@@ -82,7 +104,11 @@ func HandleChat(cfg *config.Config, history *messages.ChatHistory, stop chan str
 					}
 					firstContent = false
 				}
-				fmt.Print(chunk.Content)
+				if render != nil {
+					render.Write([]byte(chunk.Content))
+				} else {
+					fmt.Print(chunk.Content)
+				}
 			}
 		}
 
@@ -94,6 +120,9 @@ func HandleChat(cfg *config.Config, history *messages.ChatHistory, stop chan str
 		}
 		return nil
 	})
+	if render != nil {
+		render.Flush()
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -128,7 +157,7 @@ func HandleChat(cfg *config.Config, history *messages.ChatHistory, stop chan str
 	}
 	speed := tokenSpeed(seconds, cleanThinking+cleanContent)
 
-	return &ChatResult{Output: cleanContent, Elapsed: elapsed, TokensPS: speed, Structured: structured}, nil
+	return &ChatResult{Output: cleanContent, Elapsed: elapsed, TokensPS: speed, Usage: final.Usage, Structured: structured}, nil
 }
 
 // postProcessingChat separates reasoning part from content and cleans the text
@@ -47,10 +47,13 @@ func TestHandleChat(t *testing.T) {
 	history.AddUser("Say Hello", "")
 
 	// Simulate HandleChat
-	_, err := dummyHandleChat(cfg, history)
+	result, err := dummyHandleChat(cfg, history)
 	if err != nil {
 		t.Fatalf("HandleChat returned error: %v", err)
 	}
+	if result.Usage.PromptTokens != 5 || result.Usage.CompletionTokens != 10 {
+		t.Errorf("unexpected usage: %+v", result.Usage)
+	}
 
 	// Check if bot reply was stored
 	messages := history.Get()
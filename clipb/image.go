@@ -0,0 +1,143 @@
+package clipb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardImageCmd is a platform tool that writes the clipboard image to
+// stdout, with a decoder for its output format.
+type clipboardImageCmd struct {
+	args   []string
+	decode func([]byte) ([]byte, error)
+}
+
+const windowsClipboardImageScript = `Add-Type -AssemblyName System.Windows.Forms, System.Drawing;` +
+	`$img = [System.Windows.Forms.Clipboard]::GetImage();` +
+	`if ($img) { $ms = New-Object System.IO.MemoryStream;` +
+	`$img.Save($ms, [System.Drawing.Imaging.ImageFormat]::Png);` +
+	`[Convert]::ToBase64String($ms.ToArray()) }`
+
+// variable added for unit test accessibility
+var runImageCmd = func(args []string) ([]byte, error) {
+	return exec.Command(args[0], args[1:]...).Output()
+}
+
+// ReadClipboardImage reads an image from the system clipboard. It uses
+// osascript on macOS, PowerShell on Windows and wl-paste or xclip on
+// Linux/BSD.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	[]byte - the image data (PNG for most platforms)
+//	error  - error if no image is on the clipboard or no tool is available
+func ReadClipboardImage() ([]byte, error) {
+	var lastErr error
+	for _, c := range clipboardImageCmds() {
+		out, err := runImageCmd(c.args)
+		if err != nil {
+			lastErr = fmt.Errorf("%s failed: %w", c.args[0], err)
+			continue
+		}
+		data, err := c.decode(out)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(data) == 0 {
+			return nil, fmt.Errorf("clipboard contains no image")
+		}
+		return data, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no clipboard image tool for %s", runtime.GOOS)
+	}
+	return nil, fmt.Errorf("clipboard image read failed: %w", lastErr)
+}
+
+// clipboardImageCmds returns the image tools to try for this platform.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	[]clipboardImageCmd - tools in order of preference
+func clipboardImageCmds() []clipboardImageCmd {
+	raw := func(b []byte) ([]byte, error) { return b, nil }
+
+	switch runtime.GOOS {
+	case "darwin":
+		return []clipboardImageCmd{
+			{args: []string{"osascript", "-e", "the clipboard as «class PNGf»"}, decode: parseAppleScriptData},
+		}
+	case "windows":
+		return []clipboardImageCmd{
+			{args: []string{"powershell", "-NoProfile", "-Command", windowsClipboardImageScript}, decode: decodeBase64Output},
+		}
+	default:
+		cmds := []clipboardImageCmd{
+			{args: []string{"xclip", "-selection", "clipboard", "-t", "image/png", "-o"}, decode: raw},
+		}
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			cmds = append([]clipboardImageCmd{
+				{args: []string{"wl-paste", "--no-newline", "--type", "image/png"}, decode: raw},
+			}, cmds...)
+		}
+		return cmds
+	}
+}
+
+// parseAppleScriptData decodes osascript output in the form
+// «data PNGf89504E47...».
+//
+// Parameters:
+//
+//	out ([]byte) - osascript output
+//
+// Returns:
+//
+//	[]byte - the image data
+//	error  - error if the output has an unexpected format
+func parseAppleScriptData(out []byte) ([]byte, error) {
+	s := strings.TrimSpace(string(out))
+	s = strings.TrimPrefix(s, "«data ")
+	s = strings.TrimSuffix(s, "»")
+	if len(s) < 4 || s == strings.TrimSpace(string(out)) {
+		return nil, fmt.Errorf("unexpected osascript output")
+	}
+	data, err := hex.DecodeString(s[4:]) // skip the 4-char type code
+	if err != nil {
+		return nil, fmt.Errorf("decode osascript output failed: %w", err)
+	}
+	return data, nil
+}
+
+// decodeBase64Output decodes base64 text written by PowerShell.
+//
+// Parameters:
+//
+//	out ([]byte) - command output
+//
+// Returns:
+//
+//	[]byte - the image data
+//	error  - error if the output is not base64
+func decodeBase64Output(out []byte) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(out)))
+	if err != nil {
+		return nil, fmt.Errorf("decode clipboard image failed: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,67 @@
+package clipb
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseAppleScriptData(t *testing.T) {
+	tests := []struct {
+		name    string
+		out     string
+		want    []byte
+		wantErr bool
+	}{
+		{"png data", "«data PNGf89504E47»\n", []byte{0x89, 0x50, 0x4E, 0x47}, false},
+		{"no image", "execution error: Can’t make some data", nil, true},
+		{"invalid hex", "«data PNGfZZ»", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAppleScriptData([]byte(tt.out))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("data = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeBase64Output(t *testing.T) {
+	got, err := decodeBase64Output([]byte("iVBORw==\r\n"))
+	if err != nil {
+		t.Fatalf("decodeBase64Output failed: %v", err)
+	}
+	if !bytes.Equal(got, []byte{0x89, 'P', 'N', 'G'}) {
+		t.Fatalf("data = %x", got)
+	}
+}
+
+func TestReadClipboardImage(t *testing.T) {
+	prev := runImageCmd
+	t.Cleanup(func() { runImageCmd = prev })
+
+	t.Run("tool fails", func(t *testing.T) {
+		runImageCmd = func(args []string) ([]byte, error) {
+			return nil, errors.New("not found")
+		}
+		_, err := ReadClipboardImage()
+		if err == nil || !strings.Contains(err.Error(), "clipboard image read failed") {
+			t.Fatalf("error = %v", err)
+		}
+	})
+
+	t.Run("empty clipboard", func(t *testing.T) {
+		runImageCmd = func(args []string) ([]byte, error) {
+			return []byte{}, nil
+		}
+		if _, err := ReadClipboardImage(); err == nil {
+			t.Fatal("expected error for empty clipboard")
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"picochat/config"
+	"picochat/messages"
+	"regexp"
+	"strings"
+)
+
+// MaxExpansionDepth limits nested alias and macro expansions.
+const MaxExpansionDepth = 10
+
+var argPlaceholderRe = regexp.MustCompile(`\$(\*|[1-9])`)
+
+// expandUserCommand runs an alias or macro from the [Aliases] and [Macros]
+// config tables. Built-in commands always take precedence.
+//
+// Parameters:
+//
+//	cmd (string)                    - the command name
+//	args ([]string)                 - the command arguments
+//	history (*messages.ChatHistory) - the chat history
+//	input (io.Reader)               - input for interactive questions
+//	depth (int)                     - number of alias expansions so far
+//
+// Returns:
+//
+//	CommandResult - result of the expanded command, a prompt or macro steps
+func expandUserCommand(cmd string, args []string, history *messages.ChatHistory, input io.Reader, depth int) CommandResult {
+	if expansion, ok := config.LookupAlias(cmd); ok {
+		if depth >= MaxExpansionDepth {
+			return CommandResult{Error: fmt.Errorf("alias /%s nested deeper than %d, recursion?", cmd, MaxExpansionDepth)}
+		}
+		line := substituteArgs(expansion, args, true)
+		if IsCommandLine(line) {
+			return handleCommand(line, history, input, depth+1)
+		}
+		return CommandResult{Pasted: line}
+	}
+
+	if macro, ok := config.LookupMacro(cmd); ok {
+		if len(macro.Steps) == 0 {
+			return CommandResult{Warn: fmt.Sprintf("macro /%s has no steps", cmd)}
+		}
+		steps := make([]string, len(macro.Steps))
+		for i, step := range macro.Steps {
+			steps[i] = substituteArgs(step, args, false)
+		}
+		return CommandResult{Steps: steps}
+	}
+
+	return CommandResult{Error: fmt.Errorf("unknown command")}
+}
+
+// substituteArgs replaces $1..$9 with the positional arguments and $* with
+// all arguments. If appendArgs is set and the text has no placeholder, the
+// arguments are appended.
+//
+// Parameters:
+//
+//	text (string)     - alias expansion or macro step
+//	args ([]string)   - the command arguments
+//	appendArgs (bool) - append arguments if no placeholder is used
+//
+// Returns:
+//
+//	string - the expanded text
+func substituteArgs(text string, args []string, appendArgs bool) string {
+	if len(args) == 1 && args[0] == "" {
+		args = nil
+	}
+
+	used := false
+	out := argPlaceholderRe.ReplaceAllStringFunc(text, func(m string) string {
+		used = true
+		if m == "$*" {
+			return strings.Join(args, " ")
+		}
+		n := int(m[1] - '0')
+		if n <= len(args) {
+			return args[n-1]
+		}
+		return ""
+	})
+
+	if appendArgs && !used && len(args) > 0 {
+		out += " " + strings.Join(args, " ")
+	}
+	return strings.TrimSpace(out)
+}
+
+// IsCommandLine checks if an input line is a command ("/cmd" or "!shell").
+//
+// Parameters:
+//
+//	line (string) - the input line
+//
+// Returns:
+//
+//	bool - true for commands
+func IsCommandLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "/") || strings.HasPrefix(line, "!")
+}
+
+// userCommandHelp returns the help text for a topic and includes aliases
+// and macros: the command overview lists them, and an alias or macro name
+// shows its expansion.
+//
+// Parameters:
+//
+//	topic (string) - the help topic, empty for the overview
+//
+// Returns:
+//
+//	string - the help text
+func userCommandHelp(topic string) string {
+	if topic == "" {
+		text := HelpText("")
+		if config.HasAliases() {
+			text += "\n\nAliases and macros:\n" + config.ListAliases()
+		}
+		return text
+	}
+	if expansion, ok := config.LookupAlias(topic); ok {
+		return fmt.Sprintf("Alias /%s expands to: %s", strings.TrimPrefix(topic, "/"), expansion)
+	}
+	if macro, ok := config.LookupMacro(topic); ok {
+		lines := []string{fmt.Sprintf("Macro /%s: %s", strings.TrimPrefix(topic, "/"), macro.Description)}
+		for i, step := range macro.Steps {
+			lines = append(lines, fmt.Sprintf("  %d. %s", i+1, step))
+		}
+		return strings.Join(lines, "\n")
+	}
+	return HelpText(topic)
+}
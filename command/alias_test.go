@@ -0,0 +1,82 @@
+package command
+
+import (
+	"picochat/config"
+	"picochat/messages"
+	"strings"
+	"testing"
+)
+
+func TestSubstituteArgs(t *testing.T) {
+	tests := []struct {
+		text       string
+		args       []string
+		appendArgs bool
+		want       string
+	}{
+		{"/paste eng", []string{""}, true, "/paste eng"},
+		{"/paste eng", []string{"a", "b"}, true, "/paste eng a b"},
+		{"/tpl translate lang=$1 $2", []string{"German", "text"}, true, "/tpl translate lang=German text"},
+		{"Explain $* briefly", []string{"go", "channels"}, true, "Explain go channels briefly"},
+		{"Review $1", []string{""}, false, "Review"},
+		{"/run git log -n 5", []string{"x"}, false, "/run git log -n 5"},
+	}
+
+	for _, tt := range tests {
+		if got := substituteArgs(tt.text, tt.args, tt.appendArgs); got != tt.want {
+			t.Errorf("substituteArgs(%q, %q) = %q, want %q", tt.text, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestHandleAliasesAndMacros(t *testing.T) {
+	if _, _, err := config.Get(); err != nil {
+		t.Fatalf("load config failed: %v", err)
+	}
+	t.Cleanup(config.OverrideAliases(
+		map[string]string{
+			"sys":   "/system",
+			"Ask":   "Answer in one sentence: $*",
+			"loop":  "/loop2",
+			"loop2": "/loop",
+			"help":  "/bye", // built-in commands take precedence
+		},
+		map[string]config.Macro{
+			"review": {Description: "Review changes", Steps: []string{"/run -a git diff $1", "Review the diff."}},
+		},
+	))
+	h := messages.NewHistory("prompt", 50)
+
+	result := HandleCommand("/sys You are terse.", h, strings.NewReader(""))
+	if result.Error != nil || h.Get()[0].Content != "You are terse." {
+		t.Fatalf("alias to command failed: %+v, system=%q", result, h.Get()[0].Content)
+	}
+
+	result = HandleCommand("/ask what is Go?", h, strings.NewReader(""))
+	if result.Pasted != "Answer in one sentence: what is Go?" {
+		t.Fatalf("alias to prompt failed: %+v", result)
+	}
+
+	result = HandleCommand("/loop", h, strings.NewReader(""))
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "recursion") {
+		t.Fatalf("expected recursion error, got %+v", result)
+	}
+
+	result = HandleCommand("/help", h, strings.NewReader(""))
+	if result.Quit || !strings.Contains(result.Output, "Aliases and macros:") || !strings.Contains(result.Output, "/review") {
+		t.Fatalf("expected help with aliases, got %+v", result)
+	}
+
+	result = HandleCommand("/review main.go", h, strings.NewReader(""))
+	want := []string{"/run -a git diff main.go", "Review the diff."}
+	if strings.Join(result.Steps, "|") != strings.Join(want, "|") {
+		t.Fatalf("macro steps = %q, want %q", result.Steps, want)
+	}
+
+	if result := HandleCommand("/? review", h, strings.NewReader("")); !strings.Contains(result.Output, "2. Review the diff.") {
+		t.Fatalf("expected macro help, got %q", result.Output)
+	}
+	if result := HandleCommand("/nope", h, strings.NewReader("")); result.Error == nil {
+		t.Fatal("expected unknown command error")
+	}
+}
@@ -0,0 +1,313 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"picochat/console"
+	"picochat/messages"
+	"picochat/utils"
+	"strings"
+)
+
+// patchResult is the dry-run outcome of all patches of one file.
+type patchResult struct {
+	patches []utils.FilePatch // patches of the file in order of the answer
+	path    string            // cleaned target path
+	content string            // patched content
+	errs    []string
+}
+
+// handleApply executes /apply [#n]: it finds unified diff blocks in an
+// assistant message, dry-runs them against the working tree, shows a
+// summary and writes the files after confirmation. Files with a failing
+// hunk are never written.
+//
+// Parameters:
+//
+//	sel (string)                    - "" for the last answer or "#<index>"
+//	history (*messages.ChatHistory) - the chat history
+//	input (io.Reader)               - input stream for the confirmation
+//
+// Returns:
+//
+//	CommandResult - summary, info and warnings
+func handleApply(sel string, history *messages.ChatHistory, input io.Reader) CommandResult {
+	blocks, err := codeBlocksOf(sel, history)
+	if err != nil {
+		return CommandResult{Error: fmt.Errorf("apply failed: %w", err)}
+	}
+
+	var patches []utils.FilePatch
+	for _, b := range blocks {
+		if !isDiffBlock(b) {
+			continue
+		}
+		p, err := utils.ParsePatch(b.Code)
+		if err != nil {
+			return CommandResult{Error: fmt.Errorf("parse diff failed: %w", err)}
+		}
+		patches = append(patches, p...)
+	}
+	if len(patches) == 0 {
+		return CommandResult{Info: "No diff blocks found."}
+	}
+
+	results := dryRunPatches(patches)
+	valid := 0
+	for _, r := range results {
+		if len(r.errs) == 0 {
+			valid++
+		}
+	}
+
+	fmt.Println(formatPatchSummary(results))
+	rejected := len(results) - valid
+	if valid == 0 {
+		return CommandResult{Error: fmt.Errorf("no file can be patched, %d rejected", rejected)}
+	}
+
+	question := fmt.Sprintf("Apply changes to %d file(s)?", valid)
+	if rejected > 0 {
+		question = fmt.Sprintf("Apply changes to %d file(s) and skip %d rejected?", valid, rejected)
+	}
+	ok, err := askConfirmation(question, input)
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+	if !ok {
+		return CommandResult{Info: "Nothing applied."}
+	}
+
+	var applied []string
+	for _, r := range results {
+		if len(r.errs) > 0 {
+			continue
+		}
+		if err := writePatchResult(r); err != nil {
+			return CommandResult{Info: strings.Join(applied, "\n"), Error: err}
+		}
+		applied = append(applied, fmt.Sprintf("%s patched.", r.path))
+	}
+
+	res := CommandResult{Info: strings.Join(applied, "\n")}
+	if rejected > 0 {
+		res.Warn = fmt.Sprintf("%d file(s) rejected, see summary above", rejected)
+	}
+	return res
+}
+
+// isDiffBlock checks if a code block holds a unified diff.
+//
+// Parameters:
+//
+//	b (messages.CodeBlock) - the code block
+//
+// Returns:
+//
+//	bool - true for diff/patch blocks or blocks with ---/+++ and @@ lines
+func isDiffBlock(b messages.CodeBlock) bool {
+	switch strings.ToLower(b.Lang) {
+	case "diff", "patch", "udiff":
+		return true
+	}
+	return strings.Contains(b.Code, "\n+++ ") && strings.Contains(b.Code, "\n@@")
+}
+
+// dryRunPatches applies the file patches in memory. Patches of the same
+// file are applied one after another, so each file is written once with
+// all changes.
+//
+// Parameters:
+//
+//	patches ([]utils.FilePatch) - the file patches in order of the answer
+//
+// Returns:
+//
+//	[]patchResult - one result per file in order of first appearance
+func dryRunPatches(patches []utils.FilePatch) []patchResult {
+	var results []patchResult
+	index := make(map[string]int)
+	for _, p := range patches {
+		path, err := safeRelPath(p.Path())
+		if err != nil {
+			results = append(results, patchResult{patches: []utils.FilePatch{p}, path: p.Path(), errs: []string{err.Error()}})
+			continue
+		}
+		if i, ok := index[path]; ok {
+			results[i].patches = append(results[i].patches, p)
+			continue
+		}
+		index[path] = len(results)
+		results = append(results, patchResult{patches: []utils.FilePatch{p}, path: path})
+	}
+
+	for i := range results {
+		if len(results[i].errs) == 0 {
+			dryRunFile(&results[i])
+		}
+	}
+	return results
+}
+
+// dryRunFile applies the patches of one file to its content in memory.
+//
+// Parameters:
+//
+//	r (*patchResult) - the file with its patches, receives the content or
+//	                   the reasons for rejection
+//
+// Returns:
+//
+//	none
+func dryRunFile(r *patchResult) {
+	data, readErr := os.ReadFile(r.path)
+	content, exists := string(data), readErr == nil
+
+	for i, p := range r.patches {
+		prefix := ""
+		if len(r.patches) > 1 {
+			prefix = fmt.Sprintf("diff %d: ", i+1)
+		}
+		switch {
+		case p.IsNew() && exists:
+			r.errs = append(r.errs, prefix+"file already exists")
+			return
+		case p.IsNew():
+		case !exists && i == 0:
+			r.errs = append(r.errs, fmt.Sprintf("%sread failed: %v", prefix, readErr))
+			return
+		case !exists:
+			r.errs = append(r.errs, prefix+"file deleted by an earlier diff")
+			return
+		}
+
+		var hunkErrs []utils.HunkError
+		content, hunkErrs = utils.ApplyHunks(content, p.Hunks)
+		for _, e := range hunkErrs {
+			r.errs = append(r.errs, prefix+e.Error())
+		}
+		if len(hunkErrs) > 0 {
+			return
+		}
+		if p.IsDelete() && content != "" {
+			r.errs = append(r.errs, prefix+"file not empty after removing the lines")
+			return
+		}
+		exists = !p.IsDelete()
+	}
+	r.content = content
+}
+
+// isNew reports whether the patches create the file.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	bool - true if the first patch creates the file
+func (r patchResult) isNew() bool {
+	return r.patches[0].IsNew()
+}
+
+// isDelete reports whether the patches delete the file.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	bool - true if the last patch deletes the file
+func (r patchResult) isDelete() bool {
+	return r.patches[len(r.patches)-1].IsDelete()
+}
+
+// stats returns the number of hunks and of added and removed lines of all
+// patches of the file.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	int - the number of hunks
+//	int - the number of added lines
+//	int - the number of removed lines
+func (r patchResult) stats() (int, int, int) {
+	var hunks, added, removed int
+	for _, p := range r.patches {
+		a, d := p.Stats()
+		hunks, added, removed = hunks+len(p.Hunks), added+a, removed+d
+	}
+	return hunks, added, removed
+}
+
+// formatPatchSummary renders a colored overview of all file patches.
+//
+// Parameters:
+//
+//	results ([]patchResult) - the dry-run results
+//
+// Returns:
+//
+//	string - one line per file plus the reasons for rejected files
+func formatPatchSummary(results []patchResult) string {
+	styles := console.ActiveTheme()
+	var sb strings.Builder
+	sb.WriteString("Diff summary:\n")
+	for _, r := range results {
+		hunks, added, removed := r.stats()
+		mode := "M"
+		switch {
+		case r.isNew():
+			mode = "A"
+		case r.isDelete():
+			mode = "D"
+		}
+		stats := fmt.Sprintf("%s %s", console.Colorize(styles.Added, fmt.Sprintf("+%d", added)), console.Colorize(styles.Removed, fmt.Sprintf("-%d", removed)))
+
+		if len(r.errs) == 0 {
+			fmt.Fprintf(&sb, " %s %s %s %s (%d hunks)\n", console.Colorize(styles.Added, "✓"), mode, r.path, stats, hunks)
+			continue
+		}
+		fmt.Fprintf(&sb, " %s %s %s rejected\n", console.Colorize(styles.Error, "✗"), mode, r.path)
+		for _, e := range r.errs {
+			sb.WriteString("     " + console.Colorize(styles.Error, e) + "\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// writePatchResult writes, creates or deletes the file of a patch.
+//
+// Parameters:
+//
+//	r (patchResult) - a successful dry-run result
+//
+// Returns:
+//
+//	error - error if the file cannot be written
+func writePatchResult(r patchResult) error {
+	if r.isDelete() {
+		if err := os.Remove(r.path); err != nil {
+			return fmt.Errorf("delete %s failed: %w", r.path, err)
+		}
+		return nil
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(r.path); err == nil {
+		mode = info.Mode().Perm()
+	} else if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("create directory failed: %w", err)
+	}
+	if err := os.WriteFile(r.path, []byte(r.content), mode); err != nil {
+		return fmt.Errorf("write %s failed: %w", r.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package command
+
+import (
+	"os"
+	"picochat/console"
+	"picochat/messages"
+	"picochat/utils"
+	"strings"
+	"testing"
+)
+
+func TestHandleApply(t *testing.T) {
+	t.Chdir(t.TempDir())
+	files := map[string]string{
+		"a.txt": "one\ntwo\nthree\nfour\n",
+		"b.txt": "alpha\nbeta\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("write %s failed: %v", name, err)
+		}
+	}
+
+	answer := "Changes:\n```diff\n" +
+		"--- a/a.txt\n+++ b/a.txt\n@@ -7,3 +7,3 @@\n two\n-three\n+THREE\n four\n" +
+		"--- a/b.txt\n+++ b/b.txt\n@@ -1,2 +1,2 @@\n gamma\n-beta\n+BETA\n" +
+		"--- /dev/null\n+++ b/sub/c.txt\n@@ -0,0 +1,1 @@\n+new\n" +
+		"```\n"
+	h := messages.NewHistory("prompt", 50)
+	if err := h.AddAssistant("", answer); err != nil {
+		t.Fatalf("add assistant failed: %v", err)
+	}
+
+	result := HandleCommand("/apply", h, strings.NewReader("n\n"))
+	if result.Info != "Nothing applied." {
+		t.Fatalf("expected canceled apply, got %+v", result)
+	}
+
+	result = HandleCommand("/apply", h, strings.NewReader("y\n"))
+	if result.Error != nil {
+		t.Fatalf("/apply failed: %v", result.Error)
+	}
+	if !strings.Contains(result.Warn, "1 file(s) rejected") {
+		t.Fatalf("expected rejected file warning, got %+v", result)
+	}
+
+	want := map[string]string{
+		"a.txt":     "one\ntwo\nTHREE\nfour\n",
+		"b.txt":     "alpha\nbeta\n",
+		"sub/c.txt": "new\n",
+	}
+	for name, content := range want {
+		data, err := os.ReadFile(name)
+		if err != nil || string(data) != content {
+			t.Fatalf("%s = %q (%v), want %q", name, data, err, content)
+		}
+	}
+
+	// the new file exists now, a.txt no longer matches: nothing to apply
+	if result := HandleCommand("/apply", h, strings.NewReader("y\n")); result.Error == nil {
+		t.Fatalf("expected error when all files are rejected, got %+v", result)
+	}
+	if result := HandleCommand("/apply #0", h, strings.NewReader("")); result.Info != "No diff blocks found." {
+		t.Fatalf("expected no diff blocks, got %+v", result)
+	}
+}
+
+func TestHandleApply_SameFileInSeveralBlocks(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("a.txt", []byte("1\n2\n3\n4\n5\n6\n7\n8\n"), 0644); err != nil {
+		t.Fatalf("write a.txt failed: %v", err)
+	}
+
+	answer := "First:\n```diff\n--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,3 @@\n 1\n-2\n+two\n 3\n```\n" +
+		"Then:\n```diff\n--- a/a.txt\n+++ b/a.txt\n@@ -6,3 +6,3 @@\n 6\n-7\n+seven\n 8\n```\n"
+	h := messages.NewHistory("prompt", 50)
+	if err := h.AddAssistant("", answer); err != nil {
+		t.Fatalf("add assistant failed: %v", err)
+	}
+
+	result := HandleCommand("/apply", h, strings.NewReader("y\n"))
+	if result.Error != nil {
+		t.Fatalf("/apply failed: %v", result.Error)
+	}
+	if result.Info != "a.txt patched." {
+		t.Fatalf("expected a single patched file, got %q", result.Info)
+	}
+	data, err := os.ReadFile("a.txt")
+	if want := "1\ntwo\n3\n4\n5\n6\nseven\n8\n"; err != nil || string(data) != want {
+		t.Fatalf("a.txt = %q (%v), want %q", data, err, want)
+	}
+
+	// the second block fails now, so the file is rejected as a whole
+	if err := os.WriteFile("a.txt", []byte("1\n2\n3\n4\n5\n6\nx\n8\n"), 0644); err != nil {
+		t.Fatalf("write a.txt failed: %v", err)
+	}
+	if result := HandleCommand("/apply", h, strings.NewReader("y\n")); result.Error == nil {
+		t.Fatalf("expected rejected file, got %+v", result)
+	}
+	if data, _ := os.ReadFile("a.txt"); string(data) != "1\n2\n3\n4\n5\n6\nx\n8\n" {
+		t.Fatalf("rejected file was written: %q", data)
+	}
+}
+
+func TestFormatPatchSummary_Theme(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("a.txt", []byte("one\n"), 0644); err != nil {
+		t.Fatalf("write a.txt failed: %v", err)
+	}
+	t.Cleanup(func() { _ = console.SetTheme("default", nil) })
+	if err := console.SetTheme("mono", map[string]string{"added": "underline"}); err != nil {
+		t.Fatalf("SetTheme failed: %v", err)
+	}
+
+	patches, err := utils.ParsePatch("--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-one\n+ONE\n" +
+		"--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-x\n+y\n")
+	if err != nil {
+		t.Fatalf("ParsePatch failed: %v", err)
+	}
+	summary := formatPatchSummary(dryRunPatches(patches))
+
+	for _, want := range []string{
+		console.Underline + "✓" + console.ColorReset,
+		console.Underline + "+1" + console.ColorReset + " -1",
+		console.Bold + "✗" + console.ColorReset,
+	} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary %q does not contain %q", summary, want)
+		}
+	}
+	if strings.Contains(summary, console.Green) || strings.Contains(summary, console.Red) {
+		t.Errorf("summary %q uses colors outside of the theme", summary)
+	}
+}
@@ -0,0 +1,225 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"picochat/messages"
+	"picochat/paths"
+	"picochat/utils"
+	"strings"
+)
+
+// codeBlocksOf returns the code blocks of the last assistant message or of
+// the message selected with "#<index>".
+//
+// Parameters:
+//
+//	sel (string)                    - "" for the last answer or "#<index>"
+//	history (*messages.ChatHistory) - the chat history
+//
+// Returns:
+//
+//	[]messages.CodeBlock - the code blocks of the message
+//	error                - error if the index is invalid
+func codeBlocksOf(sel string, history *messages.ChatHistory) ([]messages.CodeBlock, error) {
+	if indexStr, ok := strings.CutPrefix(sel, "#"); ok {
+		index, err := parseIndex(indexStr)
+		if err != nil {
+			return nil, err
+		}
+		msg, err := history.GetByIndex(index)
+		if err != nil {
+			return nil, err
+		}
+		return messages.ExtractCodeBlocks(msg.Content), nil
+	}
+	if sel != "" {
+		return nil, fmt.Errorf("invalid message selector %q (use #<index>)", sel)
+	}
+
+	msg, found := history.GetLastRole(messages.RoleAssistant)
+	if !found {
+		return nil, nil
+	}
+	return messages.ExtractCodeBlocks(msg.Content), nil
+}
+
+// selectCodeBlock returns the code block with the given 1-based number.
+//
+// Parameters:
+//
+//	blocks ([]messages.CodeBlock) - available code blocks
+//	numStr (string)               - the block number
+//
+// Returns:
+//
+//	messages.CodeBlock - the selected block
+//	error              - error if the number is invalid or out of range
+func selectCodeBlock(blocks []messages.CodeBlock, numStr string) (messages.CodeBlock, error) {
+	n, err := parseIndex(numStr)
+	if err != nil {
+		return messages.CodeBlock{}, err
+	}
+	if n < 1 || n > len(blocks) {
+		return messages.CodeBlock{}, fmt.Errorf("code block %d not found (%d available)", n, len(blocks))
+	}
+	return blocks[n-1], nil
+}
+
+// formatCodeBlockList renders an overview of code blocks with language,
+// line count and annotated file name.
+//
+// Parameters:
+//
+//	blocks ([]messages.CodeBlock) - the code blocks
+//
+// Returns:
+//
+//	string - the numbered list
+func formatCodeBlockList(blocks []messages.CodeBlock) string {
+	items := make([]string, len(blocks))
+	for i, b := range blocks {
+		lang := b.Lang
+		if lang == "" {
+			lang = "text"
+		}
+		items[i] = fmt.Sprintf("%s, %d lines", lang, b.Lines)
+		if b.Filename != "" {
+			items[i] += " - " + b.Filename
+		}
+	}
+	return utils.FormatList(items, "Code blocks", true)
+}
+
+// handleWrite executes /write <n> [path] and /write all [dir].
+//
+// Parameters:
+//
+//	args ([]string)                 - command arguments
+//	history (*messages.ChatHistory) - the chat history
+//	input (io.Reader)               - input stream for confirmations
+//
+// Returns:
+//
+//	CommandResult - info and warnings of the written files
+func handleWrite(args []string, history *messages.ChatHistory, input io.Reader) CommandResult {
+	if args[0] == "" {
+		return CommandResult{Error: fmt.Errorf("usage: /write <n> [path] or /write all [dir]")}
+	}
+	blocks, err := codeBlocksOf("", history)
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+	if len(blocks) == 0 {
+		return CommandResult{Warn: "No code blocks found."}
+	}
+
+	if args[0] == "all" {
+		dir := "."
+		if len(args) > 1 {
+			dir = args[1]
+		}
+		var infos, warnings []string
+		for i, b := range blocks {
+			name, err := safeRelPath(b.Filename)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("block %d skipped: %v", i+1, err))
+				continue
+			}
+			info, err := writeCodeFile(filepath.Join(dir, name), b.Code, input)
+			if err != nil {
+				return CommandResult{Info: strings.Join(infos, "\n"), Warn: joinWarnings(warnings), Error: err}
+			}
+			infos = append(infos, info)
+		}
+		return CommandResult{Info: strings.Join(infos, "\n"), Warn: joinWarnings(warnings)}
+	}
+
+	block, err := selectCodeBlock(blocks, args[0])
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+	var path string
+	if len(args) > 1 {
+		path = strings.Join(args[1:], " ")
+	} else if path, err = safeRelPath(block.Filename); err != nil {
+		return CommandResult{Error: fmt.Errorf("no path given: %w", err)}
+	}
+
+	info, err := writeCodeFile(path, block.Code, input)
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+	return CommandResult{Info: info}
+}
+
+// safeRelPath checks that a file name annotated by the model is a
+// relative path that stays inside the target directory.
+//
+// Parameters:
+//
+//	name (string) - the annotated file name
+//
+// Returns:
+//
+//	string - the cleaned path
+//	error  - error if the name is empty, absolute or leaves the directory
+func safeRelPath(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("no file name annotated")
+	}
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("unsafe file name %q", name)
+	}
+	return clean, nil
+}
+
+// writeCodeFile writes code to a file. If the file exists, a diff is shown
+// and the user has to confirm the overwrite.
+//
+// Parameters:
+//
+//	path (string)     - the target path
+//	code (string)     - the new file content
+//	input (io.Reader) - input stream for the confirmation
+//
+// Returns:
+//
+//	string - info about the result
+//	error  - error if the file cannot be read or written
+func writeCodeFile(path, code string, input io.Reader) (string, error) {
+	fullPath, err := paths.ExpandHomeDir(path)
+	if err != nil {
+		return "", err
+	}
+
+	old, err := os.ReadFile(fullPath)
+	switch {
+	case err == nil:
+		if string(old) == code {
+			return fmt.Sprintf("%s is unchanged.", path), nil
+		}
+		fmt.Print(utils.UnifiedDiff(path, path+" (new)", string(old), code))
+		ok, err := askConfirmation(fmt.Sprintf("Overwrite %s?", path), input)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return fmt.Sprintf("%s skipped.", path), nil
+		}
+	case os.IsNotExist(err):
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return "", fmt.Errorf("create directory failed: %w", err)
+		}
+	default:
+		return "", fmt.Errorf("read file failed: %w", err)
+	}
+
+	if err := os.WriteFile(fullPath, []byte(code), 0644); err != nil {
+		return "", fmt.Errorf("write file failed: %w", err)
+	}
+	return fmt.Sprintf("%s written (%d lines).", path, strings.Count(code, "\n")), nil
+}
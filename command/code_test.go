@@ -0,0 +1,93 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"picochat/messages"
+	"strings"
+	"testing"
+)
+
+const codeAnswer = "Two files:\n" +
+	"**main.go**\n```go\npackage main\n\nfunc main() {}\n```\n" +
+	"```go\n// ../escape.go\npackage x\n```\n" +
+	"```sh\ngo run .\n```\n"
+
+func newCodeHistory(t *testing.T) *messages.ChatHistory {
+	t.Helper()
+	h := messages.NewHistory("prompt", 50)
+	if err := h.AddAssistant("", codeAnswer); err != nil {
+		t.Fatalf("add assistant failed: %v", err)
+	}
+	return h
+}
+
+func TestHandleCode(t *testing.T) {
+	h := newCodeHistory(t)
+
+	result := HandleCommand("/code", h, strings.NewReader(""))
+	want := "Code blocks:\n(01) go, 3 lines - main.go\n(02) go, 2 lines - ../escape.go\n(03) sh, 1 lines"
+	if result.Output != want {
+		t.Fatalf("/code output = %q, want %q", result.Output, want)
+	}
+
+	result = HandleCommand("/code #0", h, strings.NewReader(""))
+	if result.Info != "No code blocks found." {
+		t.Fatalf("expected no code blocks in system prompt, got %+v", result)
+	}
+
+	payload, err := resolveCopyPayload("code 3", h)
+	if err != nil || payload.Text != "go run .\n" {
+		t.Fatalf("copy code 3 = %+v, %v", payload, err)
+	}
+	if _, err := resolveCopyPayload("code 4", h); err == nil {
+		t.Fatal("expected error for missing block")
+	}
+}
+
+func TestHandleWrite(t *testing.T) {
+	h := newCodeHistory(t)
+	dir := t.TempDir()
+
+	result := HandleCommand("/write all "+dir, h, strings.NewReader(""))
+	if result.Error != nil {
+		t.Fatalf("/write all failed: %v", result.Error)
+	}
+	if !strings.Contains(result.Warn, "unsafe file name") || !strings.Contains(result.Warn, "+1 more") {
+		t.Fatalf("expected skipped blocks, got warn %q", result.Warn)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "main.go"))
+	if err != nil || string(data) != "package main\n\nfunc main() {}\n" {
+		t.Fatalf("unexpected main.go: %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape.go")); err == nil {
+		t.Fatal("file written outside the target directory")
+	}
+
+	target := filepath.Join(dir, "sub", "run.sh")
+	result = HandleCommand("/write 3 "+target, h, strings.NewReader(""))
+	if result.Error != nil || !strings.Contains(result.Info, "written") {
+		t.Fatalf("/write 3 failed: %+v", result)
+	}
+
+	// existing file with other content needs a confirmation
+	if err := os.WriteFile(target, []byte("old\n"), 0644); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+	result = HandleCommand("/write 3 "+target, h, strings.NewReader("n\n"))
+	if data, _ := os.ReadFile(target); string(data) != "old\n" || !strings.Contains(result.Info, "skipped") {
+		t.Fatalf("expected unchanged file, got %q (%+v)", data, result)
+	}
+	result = HandleCommand("/write 3 "+target, h, strings.NewReader("y\n"))
+	if data, _ := os.ReadFile(target); string(data) != "go run .\n" {
+		t.Fatalf("expected overwritten file, got %q (%+v)", data, result)
+	}
+	result = HandleCommand("/write 3 "+target, h, strings.NewReader(""))
+	if !strings.Contains(result.Info, "unchanged") {
+		t.Fatalf("expected unchanged info, got %+v", result)
+	}
+
+	if result := HandleCommand("/write 9", h, strings.NewReader("")); result.Error == nil {
+		t.Fatal("expected error for missing block")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"picochat/config"
 	"picochat/console"
 	"picochat/envs"
+	"picochat/highlight"
 	"picochat/messages"
 	"picochat/output"
 	"picochat/paths"
@@ -27,9 +28,17 @@ type CommandResult struct {
 	Quit   bool
 	Pasted string
 	Retry  bool
+	Config *config.Config // optional config for the pasted request only
+	Steps  []string       // macro steps (commands or prompts) to run in order
+	Draft  string         // text put into the next input for review
 }
 
-var readClipboard = clipb.ReadClipboard
+var (
+	readClipboard      = clipb.ReadClipboard
+	readClipboardImage = clipb.ReadClipboardImage
+	editText           = utils.EditText
+	stdoutColors       = console.StdoutColors
+)
 
 // HandleCommand processes a command line input, performs the requested action,
 // and returns a CommandResult containing the outcome of the command.
@@ -45,6 +54,22 @@ var readClipboard = clipb.ReadClipboard
 //	CommandResult - a struct containing output, error, quit flag, prompt,
 //	and retry flag for the command.
 func HandleCommand(commandLine string, history *messages.ChatHistory, input io.Reader) CommandResult {
+	return handleCommand(commandLine, history, input, 0)
+}
+
+// handleCommand is HandleCommand with the alias expansion depth.
+//
+// Parameters:
+//
+//	commandLine (string)            - the command line
+//	history (*messages.ChatHistory) - the chat history
+//	input (io.Reader)               - input for interactive questions
+//	depth (int)                     - number of alias expansions so far
+//
+// Returns:
+//
+//	CommandResult - the outcome of the command
+func handleCommand(commandLine string, history *messages.ChatHistory, input io.Reader, depth int) CommandResult {
 	cfg, _, err := config.Get()
 	if err != nil {
 		return CommandResult{Error: fmt.Errorf("read config failed: %w", err)}
@@ -52,6 +77,10 @@ func HandleCommand(commandLine string, history *messages.ChatHistory, input io.R
 
 	client := backend.New(cfg)
 
+	if after, ok := strings.CutPrefix(strings.TrimSpace(commandLine), "!"); ok {
+		commandLine = "/run " + after // "!cmd" is a shortcut for "/run cmd"
+	}
+
 	cmd, args := parseCommandArgs(commandLine)
 	switch cmd {
 	case "hello":
@@ -95,7 +124,7 @@ func HandleCommand(commandLine string, history *messages.ChatHistory, input io.R
 			}
 		}
 
-		filename, err := messages.SaveHistoryToFile(argFilename, history.Get(), overwrite)
+		filename, err := messages.SaveHistoryToFile(argFilename, history, overwrite)
 		if err != nil {
 			return CommandResult{Error: fmt.Errorf("save history failed: %w", err)}
 		}
@@ -121,21 +150,98 @@ func HandleCommand(commandLine string, history *messages.ChatHistory, input io.R
 		if err != nil {
 			return CommandResult{Error: fmt.Errorf("load history failed: %w", err)}
 		}
+		history.Checkpoint()
 		history.Replace(loaded.Get())
+		history.Persona = loaded.Persona
+		if loaded.Persona != "" {
+			return CommandResult{Info: fmt.Sprintf("History file %q loaded (persona %q).", filename, loaded.Persona)}
+		}
 		return CommandResult{Info: fmt.Sprintf("History file %q loaded.", filename)}
-	case "image":
-		if args[0] == "" {
-			return CommandResult{Error: fmt.Errorf("no image file path provided")}
+	case "image", "images":
+		switch args[0] {
+		case "", "list":
+			if len(cfg.ImagePaths) == 0 {
+				return CommandResult{Info: "No images attached."}
+			}
+			return CommandResult{Output: utils.FormatList(cfg.ImagePaths, "Attached images", false)}
+		case "clear":
+			cfg.ImagePaths = nil
+			return CommandResult{Info: "Attached images removed."}
+		case "paste":
+			data, err := readClipboardImage()
+			if err != nil {
+				return CommandResult{Error: err}
+			}
+			ref, stats, err := messages.StoreImageData(data)
+			if err != nil {
+				return CommandResult{Error: fmt.Errorf("store clipboard image failed: %w", err)}
+			}
+			cfg.ImagePaths = append(cfg.ImagePaths, ref)
+			return CommandResult{Info: fmt.Sprintf("Clipboard image attached: %s (%d queued).", stats, len(cfg.ImagePaths))}
+		}
+
+		// store local files right away, so the upload size can be reported
+		var queued, info []string
+		for _, image := range args {
+			if err := ValidateImage(image); err != nil {
+				return CommandResult{Error: err}
+			}
+			if messages.IsImageURL(image) {
+				queued = append(queued, image)
+				info = append(info, fmt.Sprintf("Image URL attached: %s", image))
+				continue
+			}
+			ref, stats, err := messages.StoreImage(image)
+			if err != nil {
+				return CommandResult{Error: fmt.Errorf("store image %q failed: %w", image, err)}
+			}
+			queued = append(queued, ref)
+			info = append(info, fmt.Sprintf("Image %s attached: %s", filepath.Base(image), stats))
+		}
+		cfg.ImagePaths = append(cfg.ImagePaths, queued...)
+		info = append(info, fmt.Sprintf("%d image(s) attached to next prompt.", len(cfg.ImagePaths)))
+		return CommandResult{Info: strings.Join(info, "\n")}
+	case "code":
+		blocks, err := codeBlocksOf(args[0], history)
+		if err != nil {
+			return CommandResult{Error: fmt.Errorf("list code blocks failed: %w", err)}
+		}
+		if len(blocks) == 0 {
+			return CommandResult{Info: "No code blocks found."}
+		}
+		return CommandResult{Output: formatCodeBlockList(blocks)}
+	case "write":
+		return handleWrite(args, history, input)
+	case "apply":
+		return handleApply(args[0], history, input)
+	case "run":
+		_, line := splitFirstWord(commandLine)
+		return handleRun(cfg, line, input)
+	case "file", "files":
+		switch args[0] {
+		case "":
+			if len(cfg.FilePaths) == 0 {
+				return CommandResult{Info: "No files attached."}
+			}
+			return CommandResult{Output: utils.FormatList(cfg.FilePaths, "Attached files", false)}
+		case "clear":
+			cfg.FilePaths = nil
+			return CommandResult{Info: "Attached files removed."}
+		}
+
+		bundle, err := messages.BuildFileBundle(append(slices.Clone(cfg.FilePaths), args...), messages.MaxAttachTokens, backend.SupportsFileInput(cfg.Backend))
+		if err != nil {
+			return CommandResult{Error: fmt.Errorf("attach files failed: %w", err)}
 		}
-		if !paths.FileExists(args[0]) {
-			return CommandResult{Error: fmt.Errorf("image file not found")}
+		cfg.FilePaths = bundle.Files
+		return CommandResult{
+			Info: fmt.Sprintf("%d file(s) attached to the next prompt (~%.0f tokens, %d as native document).", len(bundle.Files), math.Ceil(bundle.Tokens), len(bundle.Documents)),
+			Warn: joinWarnings(bundle.Skipped),
 		}
-		cfg.ImagePath = args[0]
-		return CommandResult{Info: fmt.Sprintf("Image file path set to: %s", cfg.ImagePath)}
 	case "info":
 		serverVersion, err := client.GetServerVersion()
 		if err != nil {
-			serverVersion = console.Colorize(console.Red, fmt.Sprintf("%s connection error", console.ErrPrefix))
+			serverVersion = console.Colorize(console.ActiveTheme().Error, console.ErrSymbol+" connection error")
 		}
 
 		list := []string{
@@ -146,6 +252,10 @@ func HandleCommand(commandLine string, history *messages.ChatHistory, input io.R
 			fmt.Sprintf("Current model is %q", cfg.Model),
 			fmt.Sprintf("Context has %d messages (max. %d)", history.Len(), history.MaxCtx()),
 			fmt.Sprintf("Context token estimation: %.0f", math.Ceil(history.EstimateTokens())),
+			fmt.Sprintf("Images attached to next prompt: %d", len(cfg.ImagePaths)),
+			fmt.Sprintf("Files attached to next prompt: %d", len(cfg.FilePaths)),
+			fmt.Sprintf("Command outputs attached to next prompt: %d", len(cfg.RunOutputs)),
+			fmt.Sprintf("Undo steps available: %d (redo: %d)", history.UndoSteps(), history.RedoSteps()),
 			fmt.Sprintf("Server version: %s", serverVersion),
 		}
 
@@ -159,13 +269,77 @@ func HandleCommand(commandLine string, history *messages.ChatHistory, input io.R
 		if err != nil {
 			return CommandResult{Error: err}
 		}
+		if _, err := history.GetByIndex(index); err == nil {
+			history.Checkpoint()
+		}
 		if !history.Trim(index) {
 			return CommandResult{Warn: "Chat history not changed."}
 		}
 		return CommandResult{Info: "Chat history has been truncated."}
+	case "delete":
+		idxArg, ok := strings.CutPrefix(args[0], "#")
+		if !ok || idxArg == "" {
+			return CommandResult{Error: fmt.Errorf("missing index argument")}
+		}
+		index, err := parseIndex(idxArg)
+		if err != nil {
+			return CommandResult{Error: err}
+		}
+		if err := history.RecordChange(func() error { return history.Delete(index) }); err != nil {
+			return CommandResult{Error: fmt.Errorf("delete message failed: %w", err)}
+		}
+		return CommandResult{Info: fmt.Sprintf("Message #%d deleted.", index)}
+	case "inject":
+		_, line := splitFirstWord(commandLine)
+		index, role, text, err := parseInjectArgs(line, history.Len())
+		if err != nil {
+			return CommandResult{Error: fmt.Errorf("parse args failed: %w", err)}
+		}
+		if err := history.RecordChange(func() error { return history.Insert(index, role, text) }); err != nil {
+			return CommandResult{Error: fmt.Errorf("inject message failed: %w", err)}
+		}
+		return CommandResult{Info: fmt.Sprintf("Message #%d (%s) injected.", index, role)}
+	case "edit":
+		idxArg, ok := strings.CutPrefix(args[0], "#")
+		if !ok || idxArg == "" {
+			return CommandResult{Error: fmt.Errorf("missing index argument")}
+		}
+		index, err := parseIndex(idxArg)
+		if err != nil {
+			return CommandResult{Error: err}
+		}
+		msg, err := history.GetByIndex(index)
+		if err != nil {
+			return CommandResult{Error: fmt.Errorf("edit message failed: %w", err)}
+		}
+		edited, err := editText(msg.Content, ".md")
+		if err != nil {
+			return CommandResult{Error: fmt.Errorf("edit message failed: %w", err)}
+		}
+		if strings.TrimSpace(edited) == "" {
+			return CommandResult{Warn: "Edit canceled (empty message)."}
+		}
+		if edited == msg.Content {
+			return CommandResult{Warn: "Message not changed."}
+		}
+		if err := history.RecordChange(func() error { return history.SetContent(index, edited) }); err != nil {
+			return CommandResult{Error: fmt.Errorf("edit message failed: %w", err)}
+		}
+		return CommandResult{Info: fmt.Sprintf("Message #%d updated.", index)}
+	case "compose":
+		_, text := splitFirstWord(commandLine)
+		edited, err := editText(text, ".md")
+		if err != nil {
+			return CommandResult{Error: fmt.Errorf("compose prompt failed: %w", err)}
+		}
+		if strings.TrimSpace(edited) == "" {
+			return CommandResult{Warn: "Compose canceled (empty prompt)."}
+		}
+		return CommandResult{Draft: edited}
 	case "message":
+		theme := codeTheme(cfg)
 		if idxArg, ok := strings.CutPrefix(args[0], "#"); ok {
-			msg, err := getMessageByIndex(idxArg, history)
+			msg, err := getMessageByIndex(idxArg, history, theme)
 			if err != nil {
 				return CommandResult{Error: err}
 			}
@@ -175,21 +349,21 @@ func HandleCommand(commandLine string, history *messages.ChatHistory, input io.R
 		switch args[0] {
 		case "":
 			msg := history.GetLast().Content
-			return CommandResult{Output: msg}
+			return CommandResult{Output: highlight.Markdown(msg, theme, "")}
 		case "all":
-			conversation := output.FormatConversation(history.Get(), true)
+			conversation := output.FormatConversation(history.Get(), stdoutColors(), theme)
 			return CommandResult{Output: conversation}
 		case messages.RoleAssistant, messages.RoleUser, messages.RoleSystem:
 			msg, found := history.GetLastRole(args[0])
 			if found {
-				return CommandResult{Output: msg.Content}
+				return CommandResult{Output: highlight.Markdown(msg.Content, theme, "")}
 			}
 			return CommandResult{Warn: fmt.Sprintf("No element for role %q found.", args)}
 		default:
 			return CommandResult{Error: fmt.Errorf("unknown argument")}
 		}
 	case "copy":
-		payload, err := resolveCopyPayload(args[0], history)
+		payload, err := resolveCopyPayload(strings.Join(args, " "), history)
 		if err != nil {
 			return CommandResult{Error: fmt.Errorf("copy message failed: %w", err)}
 		}
@@ -201,24 +375,51 @@ func HandleCommand(commandLine string, history *messages.ChatHistory, input io.R
 		}
 		return CommandResult{Info: payload.Info}
 	case "paste":
-		tpl, err := config.GetTemplate(args[0])
-		if err != nil {
-			return CommandResult{Error: err}
+		if args[0] != "" {
+			if _, err := config.LookupTemplate(args[0]); err != nil {
+				return CommandResult{Error: err}
+			}
 		}
 		clip, err := readClipboard()
 		if err != nil {
 			return CommandResult{Error: err}
 		}
 		text := clip
-		if tpl != "" {
-			text = fmt.Sprintf("%s\n\n%s", tpl, clip)
+		var reqCfg *config.Config
+		var warn string
+		if args[0] != "" {
+			key, tplArgs, _ := ParseTemplateArgs(args)
+			text, reqCfg, warn, err = ExpandTemplate(key, tplArgs, clip)
+			if err != nil {
+				return CommandResult{Error: err}
+			}
 		}
 		count := utf8.RuneCountInString(clip)
 		return CommandResult{
 			Info:   fmt.Sprintf("Pasted %d characters from clipboard.", count),
+			Warn:   warn,
 			Pasted: text,
+			Config: reqCfg,
+		}
+	case "tpl", "template":
+		if args[0] == "" {
+			return CommandResult{Output: config.ListTemplates()}
+		}
+		key, tplArgs, input := ParseTemplateArgs(args)
+		text, reqCfg, warn, err := ExpandTemplate(key, tplArgs, input)
+		if err != nil {
+			return CommandResult{Error: err}
+		}
+		return CommandResult{
+			Info:   fmt.Sprintf("Template %q applied.", key),
+			Warn:   warn,
+			Pasted: text,
+			Config: reqCfg,
 		}
 	case "retry":
+		if !history.IsEmpty() && history.CheckIfLastEntryIsRole(messages.RoleAssistant) {
+			history.Checkpoint()
+		}
 		history.Discard()
 		if history.IsEmpty() {
 			return CommandResult{Error: fmt.Errorf("chat history is empty")}
@@ -241,15 +442,10 @@ func HandleCommand(commandLine string, history *messages.ChatHistory, input io.R
 			return CommandResult{Output: list}
 		}
 
-		args := strings.TrimPrefix(args[0], "#") // accept and ignore # prefix
-		index, err := parseIndex(args)
+		model, err := resolveModel(args[0], client)
 		if err != nil {
 			return CommandResult{Error: err}
 		}
-		model, ok := utils.GetModelsByIndex(index)
-		if !ok {
-			return CommandResult{Error: fmt.Errorf("no value for given index found")}
-		}
 		cfg.Model = model
 		return CommandResult{Info: fmt.Sprintf("Switched model to %q.", model)}
 	case "set":
@@ -275,37 +471,107 @@ func HandleCommand(commandLine string, history *messages.ChatHistory, input io.R
 			return CommandResult{Error: fmt.Errorf("apply to config failed: %w", err)}
 		}
 
-		var warn string
-		if len(warnings) > 0 {
-			if len(warnings) == 1 {
-				warn = warnings[0]
-			} else {
-				warn = fmt.Sprintf("%s (+%d more)", warnings[0], len(warnings)-1)
-			}
-		}
+		warn := joinWarnings(warnings)
 
-		if key == "context" {
-			err := history.SetContextSize(cfg.Context)
+		if key == "context" && history.MaxCtx() != cfg.Context {
+			err := history.RecordChange(func() error { return history.SetContextSize(cfg.Context) })
 			if err != nil {
 				return CommandResult{Error: fmt.Errorf("set context size failed: %w", err)}
 			}
 		}
 		return CommandResult{Info: fmt.Sprintf("Config updated for %s.", key), Warn: warn}
+	case "system":
+		_, prompt := splitFirstWord(commandLine)
+		if prompt == "" {
+			return CommandResult{Output: history.Get()[0].Content}
+		}
+		if err := history.RecordChange(func() error { return history.SetContent(0, prompt) }); err != nil {
+			return CommandResult{Error: fmt.Errorf("set system prompt failed: %w", err)}
+		}
+		return CommandResult{Info: "System prompt replaced."}
+	case "persona":
+		if args[0] == "" {
+			current := history.Persona
+			if current == "" {
+				current = "[none]"
+			}
+			return CommandResult{Output: fmt.Sprintf("Active persona: %s\n\n%s", current, config.ListPersonas())}
+		}
+		persona, err := config.GetPersona(args[0])
+		if err != nil {
+			return CommandResult{Error: err}
+		}
+		warnings, err := applyPersona(cfg, history, args[0], persona)
+		if err != nil {
+			return CommandResult{Error: fmt.Errorf("apply persona failed: %w", err)}
+		}
+		return CommandResult{Info: fmt.Sprintf("Switched to persona %q.", args[0]), Warn: joinWarnings(warnings)}
 	case "clear":
+		if history.IsEmpty() {
+			return CommandResult{Warn: "Chat history is already empty."}
+		}
+		history.Checkpoint()
 		history.ClearExceptSystemPrompt()
 		return CommandResult{Info: "History cleared (system prompt retained)."}
+	case "undo":
+		if !history.Undo() {
+			return CommandResult{Warn: "Nothing to undo."}
+		}
+		cfg.Context = history.MaxCtx() // /set context is undone with the history
+		return CommandResult{Info: fmt.Sprintf("Last history change reverted (%d more undo steps).", history.UndoSteps())}
+	case "redo":
+		if !history.Redo() {
+			return CommandResult{Warn: "Nothing to redo."}
+		}
+		cfg.Context = history.MaxCtx()
+		return CommandResult{Info: fmt.Sprintf("History change restored (%d more redo steps).", history.RedoSteps())}
 	case "help":
 		switch args[0] {
 		case "env", "envs":
 			return CommandResult{Output: envs.ListEnvVars()}
 		case "tpl", "templates":
 			return CommandResult{Output: config.ListTemplates()}
+		case "personas":
+			return CommandResult{Output: config.ListPersonas()}
+		case "aliases", "macros":
+			return CommandResult{Output: config.ListAliases()}
 		default:
-			return CommandResult{Output: HelpText(args[0])}
+			return CommandResult{Output: userCommandHelp(args[0])}
 		}
 	default:
-		return CommandResult{Error: fmt.Errorf("unknown command")}
+		return expandUserCommand(cmd, args, history, input, depth)
+	}
+}
+
+// ValidateImage checks that an image can be attached to a prompt. Remote
+// http(s) URLs are accepted as they are and passed through to the backend.
+//
+// Parameters:
+//
+//	image (string) - a local file path or an image URL
+//
+// Returns:
+//
+//	error - error if the file is missing or not an image
+func ValidateImage(image string) error {
+	if messages.IsImageURL(image) {
+		return nil
+	}
+	fullPath, err := paths.ExpandHomeDir(image)
+	if err != nil {
+		return err
+	}
+	if !paths.FileExists(fullPath) {
+		return fmt.Errorf("image file %q not found", image)
+	}
+	mime, err := utils.GetMimeType(fullPath)
+	if err != nil {
+		return fmt.Errorf("read image %q failed: %w", image, err)
+	}
+	if !strings.HasPrefix(mime, "image/") {
+		return fmt.Errorf("%q is not an image (%s)", image, mime)
 	}
+	return nil
 }
 
 // parseCommandArgs splits the input string into a command and its arguments,
@@ -1,11 +1,17 @@
 package command
 
 import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
 	"os"
 	"path/filepath"
 	"slices"
 
 	"fmt"
+	"picochat/config"
+	"picochat/console"
 	"picochat/messages"
 	"picochat/paths"
 	"strings"
@@ -25,6 +31,387 @@ func TestHandleClear(t *testing.T) {
 	}
 }
 
+func TestHandleClear_EmptyRecordsNoUndo(t *testing.T) {
+	h := messages.NewHistory("initial system prompt", 50)
+
+	result := HandleCommand("/clear", h, strings.NewReader(""))
+	if result.Warn == "" || h.UndoSteps() != 0 {
+		t.Fatalf("expected warning and no undo step, got %+v and %d steps", result, h.UndoSteps())
+	}
+}
+
+func TestHandleUndoRedo_Trim(t *testing.T) {
+	h := messages.NewHistory("initial system prompt", 50)
+	h.AddUser("hello", "")
+	h.AddAssistant("", "hi")
+
+	result := HandleCommand("/trim #0", h, strings.NewReader(""))
+	if result.Error != nil || h.Len() != 1 {
+		t.Fatalf("trim failed: err=%v len=%d", result.Error, h.Len())
+	}
+
+	result = HandleCommand("/undo", h, strings.NewReader(""))
+	if result.Error != nil || h.Len() != 3 {
+		t.Fatalf("undo failed: err=%v len=%d", result.Error, h.Len())
+	}
+
+	result = HandleCommand("/redo", h, strings.NewReader(""))
+	if result.Error != nil || h.Len() != 1 {
+		t.Fatalf("redo failed: err=%v len=%d", result.Error, h.Len())
+	}
+
+	result = HandleCommand("/redo", h, strings.NewReader(""))
+	if result.Warn == "" {
+		t.Errorf("expected warning for empty redo stack, got %+v", result)
+	}
+}
+
+func TestHandleUndo_SetContext(t *testing.T) {
+	cfg, _, err := config.Get()
+	if err != nil {
+		t.Fatalf("config.Get failed: %v", err)
+	}
+	orig := cfg.Context
+	t.Cleanup(func() { cfg.Context = orig })
+
+	h := messages.NewHistory("initial system prompt", cfg.Context)
+	for i := range 4 {
+		h.AddUser(fmt.Sprintf("question %d", i), "")
+		h.AddAssistant("", fmt.Sprintf("answer %d", i))
+	}
+
+	result := HandleCommand("/set context=3", h, strings.NewReader(""))
+	if result.Error != nil || h.Len() != 3 || h.UndoSteps() != 1 {
+		t.Fatalf("set context failed: err=%v len=%d steps=%d", result.Error, h.Len(), h.UndoSteps())
+	}
+
+	result = HandleCommand("/undo", h, strings.NewReader(""))
+	if result.Error != nil || h.Len() != 9 || h.MaxCtx() != orig || cfg.Context != orig {
+		t.Fatalf("undo failed: err=%v len=%d context=%d config=%d", result.Error, h.Len(), h.MaxCtx(), cfg.Context)
+	}
+}
+
+func TestHandle_OneUndoStepPerCommand(t *testing.T) {
+	prevEditText := editText
+	t.Cleanup(func() { editText = prevEditText })
+	editText = func(text, suffix string) (string, error) { return text + "!", nil }
+
+	for _, line := range []string{"/delete #1", "/inject user hi", "/edit #1", "/system be brief", "/clear"} {
+		h := messages.NewHistory("initial system prompt", 50)
+		h.AddUser("hello", "")
+		h.AddAssistant("", "hi")
+
+		if result := HandleCommand(line, h, strings.NewReader("")); result.Error != nil {
+			t.Fatalf("%s failed: %v", line, result.Error)
+		}
+		if h.UndoSteps() != 1 {
+			t.Errorf("%s recorded %d undo steps, want 1", line, h.UndoSteps())
+		}
+	}
+}
+
+func TestHandleTrim_InvalidIndexRecordsNoUndo(t *testing.T) {
+	h := messages.NewHistory("initial system prompt", 50)
+	h.AddUser("hello", "")
+
+	_ = HandleCommand("/trim #9", h, strings.NewReader(""))
+	if h.UndoSteps() != 0 {
+		t.Fatalf("expected no undo step, got %d", h.UndoSteps())
+	}
+}
+
+func TestHandleDeleteInject(t *testing.T) {
+	h := messages.NewHistory("initial system prompt", 50)
+	h.AddUser("hello", "")
+
+	result := HandleCommand("/inject assistant Sure, here you go.", h, strings.NewReader(""))
+	if result.Error != nil {
+		t.Fatalf("inject failed: %v", result.Error)
+	}
+	last := h.GetLast()
+	if last.Role != messages.RoleAssistant || last.Content != "Sure, here you go." {
+		t.Fatalf("unexpected injected message: %+v", last)
+	}
+
+	result = HandleCommand("/inject #1 user Example question", h, strings.NewReader(""))
+	if result.Error != nil {
+		t.Fatalf("inject at index failed: %v", result.Error)
+	}
+	if msg, _ := h.GetByIndex(1); msg.Content != "Example question" {
+		t.Fatalf("unexpected message at index 1: %+v", msg)
+	}
+
+	text := "Step 1:\n\n    go build ./...\n\nStep 2:  test"
+	result = HandleCommand("/inject #2 assistant "+text, h, strings.NewReader(""))
+	if msg, _ := h.GetByIndex(2); result.Error != nil || msg.Content != text {
+		t.Fatalf("multiline message not kept: %+v (%v)", msg, result.Error)
+	}
+	if err := h.Delete(2); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	result = HandleCommand("/inject #1 user", h, strings.NewReader(""))
+	if result.Error == nil {
+		t.Fatal("expected error for missing text")
+	}
+
+	result = HandleCommand("/inject system nope", h, strings.NewReader(""))
+	if result.Error == nil {
+		t.Fatal("expected error for system role")
+	}
+
+	result = HandleCommand("/delete #1", h, strings.NewReader(""))
+	if result.Error != nil {
+		t.Fatalf("delete failed: %v", result.Error)
+	}
+	if h.Len() != 3 {
+		t.Fatalf("expected 3 messages after delete, got %d", h.Len())
+	}
+
+	result = HandleCommand("/delete #0", h, strings.NewReader(""))
+	if result.Error == nil {
+		t.Fatal("expected error when deleting system prompt")
+	}
+}
+
+func TestHandleEdit(t *testing.T) {
+	prevEditText := editText
+	t.Cleanup(func() {
+		editText = prevEditText
+	})
+
+	h := messages.NewHistory("initial system prompt", 50)
+	h.AddUser("hello", "")
+
+	editText = func(text, suffix string) (string, error) {
+		return text + " world", nil
+	}
+	result := HandleCommand("/edit #1", h, strings.NewReader(""))
+	if result.Error != nil {
+		t.Fatalf("edit failed: %v", result.Error)
+	}
+	if h.GetLast().Content != "hello world" {
+		t.Fatalf("unexpected content after edit: %q", h.GetLast().Content)
+	}
+
+	editText = func(text, suffix string) (string, error) {
+		return "", nil
+	}
+	result = HandleCommand("/edit #1", h, strings.NewReader(""))
+	if result.Warn == "" || h.GetLast().Content != "hello world" {
+		t.Fatalf("expected canceled edit, got %+v", result)
+	}
+}
+
+func TestHandleCompose(t *testing.T) {
+	prevEditText := editText
+	t.Cleanup(func() {
+		editText = prevEditText
+	})
+	h := messages.NewHistory("initial system prompt", 50)
+
+	var initial string
+	editText = func(text, suffix string) (string, error) {
+		initial = text
+		return "Summarize:\n" + text, nil
+	}
+	result := HandleCommand("/compose the  report\n- totals", h, strings.NewReader(""))
+	if result.Error != nil || result.Draft != "Summarize:\nthe  report\n- totals" || initial != "the  report\n- totals" {
+		t.Fatalf("unexpected compose result %+v, initial text %q", result, initial)
+	}
+	if h.Len() != 1 {
+		t.Fatalf("compose changed the history")
+	}
+
+	editText = func(text, suffix string) (string, error) {
+		return " \n", nil
+	}
+	result = HandleCommand("/compose", h, strings.NewReader(""))
+	if result.Warn == "" || result.Draft != "" {
+		t.Fatalf("expected canceled compose, got %+v", result)
+	}
+
+	editText = func(text, suffix string) (string, error) {
+		return "", errors.New("no editor")
+	}
+	result = HandleCommand("/compose", h, strings.NewReader(""))
+	if result.Error == nil {
+		t.Fatal("expected editor error")
+	}
+}
+
+func TestHandleSystem(t *testing.T) {
+	h := messages.NewHistory("initial system prompt", 50)
+
+	result := HandleCommand("/system", h, strings.NewReader(""))
+	if result.Output != "initial system prompt" {
+		t.Fatalf("unexpected output: %q", result.Output)
+	}
+
+	result = HandleCommand("/system You are a pirate.", h, strings.NewReader(""))
+	if result.Error != nil {
+		t.Fatalf("set system prompt failed: %v", result.Error)
+	}
+	if got := h.Get()[0]; got.Role != messages.RoleSystem || got.Content != "You are a pirate." {
+		t.Fatalf("unexpected system message: %+v", got)
+	}
+
+	prompt := "You are a pirate.\n\n- Answer  in rhymes.\n- Keep it short."
+	result = HandleCommand("/system "+prompt, h, strings.NewReader(""))
+	if result.Error != nil || h.Get()[0].Content != prompt {
+		t.Fatalf("multiline system prompt not kept: %q (%v)", h.Get()[0].Content, result.Error)
+	}
+}
+
+func TestHandleMessage_Highlight(t *testing.T) {
+	cfg, _, err := config.Get()
+	if err != nil {
+		t.Fatalf("config.Get failed: %v", err)
+	}
+	orig, origColors := cfg.Highlight, stdoutColors
+	t.Cleanup(func() { cfg.Highlight, stdoutColors = orig, origColors })
+	cfg.Highlight = "16"
+
+	h := messages.NewHistory("prompt", 50)
+	answer := "Run:\n```sh\necho hi\n```"
+	if err := h.AddAssistant("", answer); err != nil {
+		t.Fatalf("add answer failed: %v", err)
+	}
+
+	stdoutColors = func() bool { return false }
+	if result := HandleCommand("/message", h, strings.NewReader("")); result.Output != answer {
+		t.Fatalf("expected plain answer without colors, got %q", result.Output)
+	}
+
+	stdoutColors = func() bool { return true }
+	for _, line := range []string{"/message", "/message assistant", "/message #1", "/message all"} {
+		result := HandleCommand(line, h, strings.NewReader(""))
+		if !strings.Contains(result.Output, console.Yellow+"echo"+console.ColorReset) {
+			t.Errorf("%s: expected highlighted code, got %q", line, result.Output)
+		}
+	}
+}
+
+func TestHandleFile(t *testing.T) {
+	cfg, _, err := config.Get()
+	if err != nil {
+		t.Fatalf("config.Get failed: %v", err)
+	}
+	t.Cleanup(func() { cfg.FilePaths = nil })
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0644); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "blob.bin"), []byte{0, 1, 2}, 0644); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+	h := messages.NewHistory("prompt", 50)
+
+	result := HandleCommand("/file "+dir, h, strings.NewReader(""))
+	if result.Error != nil {
+		t.Fatalf("/file failed: %v", result.Error)
+	}
+	if len(cfg.FilePaths) != 1 || !strings.Contains(result.Warn, "blob.bin") {
+		t.Fatalf("unexpected result: files=%v warn=%q", cfg.FilePaths, result.Warn)
+	}
+
+	result = HandleCommand("/file", h, strings.NewReader(""))
+	if !strings.Contains(result.Output, "main.go") {
+		t.Fatalf("expected attached file list, got %+v", result)
+	}
+
+	result = HandleCommand("/file "+filepath.Join(dir, "missing.txt"), h, strings.NewReader(""))
+	if result.Error == nil || len(cfg.FilePaths) != 1 {
+		t.Fatalf("expected error and unchanged files, got err=%v files=%v", result.Error, cfg.FilePaths)
+	}
+
+	_ = HandleCommand("/file clear", h, strings.NewReader(""))
+	if cfg.FilePaths != nil {
+		t.Fatalf("expected files to be cleared, got %v", cfg.FilePaths)
+	}
+}
+
+func TestHandleImage(t *testing.T) {
+	cfg, _, err := config.Get()
+	if err != nil {
+		t.Fatalf("config.Get failed: %v", err)
+	}
+	t.Cleanup(func() { cfg.ImagePaths = nil })
+	t.Cleanup(paths.OverrideHistoryPath(t.TempDir()))
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 4, 2))); err != nil {
+		t.Fatalf("encode png failed: %v", err)
+	}
+	pngData := buf.Bytes()
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "cat.png")
+	if err := os.WriteFile(imagePath, pngData, 0644); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+	textPath := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(textPath, []byte("hello"), 0644); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+
+	oldRead := readClipboardImage
+	t.Cleanup(func() { readClipboardImage = oldRead })
+	readClipboardImage = func() ([]byte, error) { return pngData, nil }
+
+	h := messages.NewHistory("prompt", 50)
+	steps := []struct {
+		line    string
+		wantErr bool
+		wantLen int
+	}{
+		{"/image " + imagePath, false, 1},
+		{"/image https://example.com/dog.jpg", false, 2},
+		{"/image " + textPath, true, 2},
+		{"/image " + filepath.Join(dir, "missing.png"), true, 2},
+		{"/image paste", false, 3},
+	}
+	for _, step := range steps {
+		result := HandleCommand(step.line, h, strings.NewReader(""))
+		if (result.Error != nil) != step.wantErr {
+			t.Fatalf("%s: error = %v, wantErr %v", step.line, result.Error, step.wantErr)
+		}
+		if len(cfg.ImagePaths) != step.wantLen {
+			t.Fatalf("%s: got %d images, want %d", step.line, len(cfg.ImagePaths), step.wantLen)
+		}
+	}
+	if !strings.HasPrefix(cfg.ImagePaths[0], "sha256:") || !strings.HasPrefix(cfg.ImagePaths[2], "sha256:") {
+		t.Fatalf("expected stored clipboard image ref, got %q", cfg.ImagePaths[2])
+	}
+
+	result := HandleCommand("/image list", h, strings.NewReader(""))
+	if !strings.Contains(result.Output, "dog.jpg") {
+		t.Fatalf("expected image list, got %+v", result)
+	}
+
+	readClipboardImage = func() ([]byte, error) { return nil, fmt.Errorf("clipboard contains no image") }
+	if result := HandleCommand("/image paste", h, strings.NewReader("")); result.Error == nil {
+		t.Fatal("expected clipboard error")
+	}
+
+	_ = HandleCommand("/image clear", h, strings.NewReader(""))
+	if cfg.ImagePaths != nil {
+		t.Fatalf("expected images to be cleared, got %v", cfg.ImagePaths)
+	}
+}
+
+func TestHandlePersona_Unknown(t *testing.T) {
+	h := messages.NewHistory("initial system prompt", 50)
+
+	result := HandleCommand("/persona does-not-exist", h, strings.NewReader(""))
+	if result.Error == nil {
+		t.Fatal("expected error for unknown persona")
+	}
+	if h.Persona != "" {
+		t.Fatalf("expected persona to stay empty, got %q", h.Persona)
+	}
+}
+
 func TestHandleHelp(t *testing.T) {
 	h := messages.NewHistory("prompt", 50)
 	result := HandleCommand("/help", h, strings.NewReader(""))
@@ -151,6 +538,42 @@ func TestHandleCommand_Paste_ReadClipboardError(t *testing.T) {
 	}
 }
 
+func TestHandleCommand_Template(t *testing.T) {
+	cfg, _, err := config.Get()
+	if err != nil {
+		t.Fatalf("load config failed: %v", err)
+	}
+	t.Cleanup(config.OverrideTemplates(map[string]config.Template{
+		"translate": {Prompt: "Translate into {{.Args.lang}}:\n{{.Input}}", Model: "translator"},
+		"eng":       {Prompt: "Translate into English."},
+	}))
+
+	history := messages.NewHistory("sys", 10)
+	result := HandleCommand("/tpl translate lang=French Good morning", history, strings.NewReader(""))
+	if result.Error != nil {
+		t.Fatalf("expected no error, got %v", result.Error)
+	}
+	if result.Pasted != "Translate into French:\nGood morning" {
+		t.Fatalf("unexpected prompt: %q", result.Pasted)
+	}
+	if result.Config == nil || result.Config.Model != "translator" {
+		t.Fatalf("expected request config with model override, got %+v", result.Config)
+	}
+	if cfg.Model == "translator" {
+		t.Fatal("template must not change the session model")
+	}
+
+	result = HandleCommand("/tpl eng Guten Morgen", history, strings.NewReader(""))
+	if result.Error != nil || result.Pasted != "Translate into English.\n\nGuten Morgen" || result.Config != nil {
+		t.Fatalf("unexpected legacy template result: %+v", result)
+	}
+
+	result = HandleCommand("/tpl translate Good morning", history, strings.NewReader(""))
+	if result.Error == nil {
+		t.Fatal("expected error for missing named argument")
+	}
+}
+
 func TestHandleCommand_Save_ExistingFile_NoOverwrite(t *testing.T) {
 	tmpDir := t.TempDir()
 	restore := paths.OverrideHistoryPath(tmpDir)
@@ -162,7 +585,7 @@ func TestHandleCommand_Save_ExistingFile_NoOverwrite(t *testing.T) {
 	}
 
 	existingName := "existing"
-	if _, err := messages.SaveHistoryToFile(existingName, h.Get(), false); err != nil {
+	if _, err := messages.SaveHistoryToFile(existingName, h, false); err != nil {
 		t.Fatalf("initial save failed: %v", err)
 	}
 
@@ -186,7 +609,7 @@ func TestHandleCommand_Save_ExistingFile_Overwrite(t *testing.T) {
 	}
 
 	existingName := "existing"
-	if _, err := messages.SaveHistoryToFile(existingName, h.Get(), false); err != nil {
+	if _, err := messages.SaveHistoryToFile(existingName, h, false); err != nil {
 		t.Fatalf("initial save failed: %v", err)
 	}
 
@@ -4,12 +4,15 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"picochat/backend"
+	"picochat/config"
 	"picochat/envs"
+	"picochat/highlight"
 	"picochat/messages"
 	"picochat/output"
 	"picochat/utils"
 	"picochat/vartypes"
-	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -60,6 +63,177 @@ func parseKeyVal(args string) (string, any, error) {
 	return key, convertedValue, nil
 }
 
+// parseInjectArgs parses the arguments of the /inject command in the form
+// "[#<index>] <role> <text>". Without index the message is appended. The
+// text keeps its spacing and line breaks.
+//
+// Parameters:
+//
+//	line (string) - the command arguments as entered
+//	length (int)  - current length of the chat history
+//
+// Returns:
+//
+//	int    - target index of the new message
+//	string - the message role
+//	string - the message text
+//	error  - error if any
+func parseInjectArgs(line string, length int) (int, string, string, error) {
+	index := length
+	first, rest := splitFirstWord(line)
+	if idxArg, ok := strings.CutPrefix(first, "#"); ok {
+		var err error
+		index, err = parseIndex(idxArg)
+		if err != nil {
+			return 0, "", "", err
+		}
+		first, rest = splitFirstWord(rest)
+	}
+
+	if first == "" || rest == "" {
+		return 0, "", "", fmt.Errorf("expected [#<index>] <role> <text>")
+	}
+	return index, strings.ToLower(first), rest, nil
+}
+
+// applyPersona applies the prompt, temperature and model of a persona to
+// the running session and records the persona name in the history.
+//
+// Parameters:
+//
+//	cfg (*config.Config)            - the active configuration
+//	history (*messages.ChatHistory) - the chat history to update
+//	name (string)                   - the persona key
+//	persona (config.Persona)        - the persona settings
+//
+// Returns:
+//
+//	[]string - warnings from config normalization if any
+//	error    - error if any
+func applyPersona(cfg *config.Config, history *messages.ChatHistory, name string, persona config.Persona) ([]string, error) {
+	var warnings []string
+	if persona.Temperature != nil {
+		var err error
+		warnings, err = config.Set("temperature", *persona.Temperature)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if model := strings.TrimSpace(persona.Model); model != "" {
+		cfg.Model = model
+	}
+
+	err := history.RecordChange(func() error {
+		if prompt := strings.TrimSpace(persona.Prompt); prompt != "" {
+			if err := history.SetContent(0, prompt); err != nil {
+				return err
+			}
+		}
+		history.Persona = name
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return warnings, nil
+}
+
+// joinWarnings condenses a list of warnings into a single line.
+//
+// Parameters:
+//
+//	warnings ([]string) - the warnings to join
+//
+// Returns:
+//
+//	string - the first warning plus the number of additional warnings
+func joinWarnings(warnings []string) string {
+	switch len(warnings) {
+	case 0:
+		return ""
+	case 1:
+		return warnings[0]
+	default:
+		return fmt.Sprintf("%s (+%d more)", warnings[0], len(warnings)-1)
+	}
+}
+
+// ParseTemplateArgs splits the arguments of a template call in the form
+// "<key> [name=value ...] [text ...]" into key, named arguments and free text.
+//
+// Parameters:
+//
+//	args ([]string) - the command arguments
+//
+// Returns:
+//
+//	string            - the template key
+//	map[string]string - the named arguments
+//	string            - the remaining free text input
+func ParseTemplateArgs(args []string) (string, map[string]string, string) {
+	if len(args) == 0 {
+		return "", nil, ""
+	}
+
+	key := args[0]
+	named := make(map[string]string)
+	rest := args[1:]
+	for len(rest) > 0 {
+		name, value, found := strings.Cut(rest[0], "=")
+		if !found || name == "" {
+			break
+		}
+		named[name] = value
+		rest = rest[1:]
+	}
+
+	return key, named, strings.Join(rest, " ")
+}
+
+// ExpandTemplate renders a template with the given arguments and input and
+// builds the config for the request if the template sets its own system
+// prompt, model, temperature or schema.
+//
+// Parameters:
+//
+//	key (string)             - the template key
+//	args (map[string]string) - named template arguments
+//	input (string)           - free text input for {{.Input}}
+//
+// Returns:
+//
+//	string         - the rendered prompt
+//	*config.Config - request config (nil if the template has no overrides)
+//	string         - warning from config normalization if any
+//	error          - error if any
+func ExpandTemplate(key string, args map[string]string, input string) (string, *config.Config, string, error) {
+	tpl, err := config.LookupTemplate(key)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	text, err := tpl.Render(config.NewTemplateVars(input, args, readClipboard))
+	if err != nil {
+		return "", nil, "", fmt.Errorf("template %q: %w", key, err)
+	}
+
+	if !tpl.HasOverrides() {
+		return text, nil, "", nil
+	}
+
+	cfg, _, err := config.Get()
+	if err != nil {
+		return "", nil, "", fmt.Errorf("read config failed: %w", err)
+	}
+	reqCfg, warnings, err := tpl.ApplyTo(cfg)
+	if err != nil {
+		return "", nil, "", fmt.Errorf("template %q: %w", key, err)
+	}
+	return text, reqCfg, joinWarnings(warnings), nil
+}
+
 // parseIndex parses an integer index from string input.
 //
 // Parameters:
@@ -79,18 +253,19 @@ func parseIndex(indexStr string) (int, error) {
 }
 
 // getMessageByIndex retrieves a history message by index and formats it with
-// a bold header line in the form "(index:role)".
+// a header line in the form "(index:role)".
 //
 // Parameters:
 //
 //	args (string) - the message index as string
 //	history (*messages.ChatHistory) - chat history used for index lookup
+//	theme (*highlight.Theme) - colors of fenced code, nil prints plain text
 //
 // Returns:
 //
 //	string - formatted message including header and content
 //	error  - error if index parsing or lookup fails
-func getMessageByIndex(args string, history *messages.ChatHistory) (string, error) {
+func getMessageByIndex(args string, history *messages.ChatHistory, theme *highlight.Theme) (string, error) {
 	index, err := parseIndex(args)
 	if err != nil {
 		return "", fmt.Errorf("get message failed: %w", err)
@@ -99,7 +274,23 @@ func getMessageByIndex(args string, history *messages.ChatHistory) (string, erro
 	if err != nil {
 		return "", fmt.Errorf("get message failed: %w", err)
 	}
-	return output.FormatMessage(msg, index, true, false), nil
+	return output.FormatMessage(msg, index, true, theme != nil, theme), nil
+}
+
+// codeTheme returns the colors of code blocks printed by a command.
+//
+// Parameters:
+//
+//	cfg (*config.Config) - the configuration with the highlight setting
+//
+// Returns:
+//
+//	*highlight.Theme - the colors, nil if stdout gets no colors or highlighting is off
+func codeTheme(cfg *config.Config) *highlight.Theme {
+	if !stdoutColors() {
+		return nil
+	}
+	return highlight.ThemeFor(cfg.Highlight)
 }
 
 // getHistoryFilename does the check of the filename for loading history sessions.
@@ -205,8 +396,7 @@ func askConfirmation(question string, input io.Reader) (bool, error) {
 	return converted.(bool), nil
 }
 
-// extractCodeBlock extracts the first code block from a string
-// formatted with triple backticks.
+// extractCodeBlock extracts the first fenced code block from a string.
 //
 // Parameters:
 //
@@ -217,12 +407,11 @@ func askConfirmation(question string, input io.Reader) (bool, error) {
 //	string - the extracted code block content.
 //	bool   - true if a code block was found, false otherwise.
 func extractCodeBlock(s string) (string, bool) {
-	re := regexp.MustCompile("(?s)```\\w*\\n(.*?)```")
-	match := re.FindStringSubmatch(s)
-	if len(match) >= 2 {
-		return match[1], true
+	blocks := messages.ExtractCodeBlocks(s)
+	if len(blocks) == 0 {
+		return "", false
 	}
-	return "", false
+	return blocks[0].Code, true
 }
 
 // encloseThinkingTags adds tags around a given string to
@@ -272,6 +461,7 @@ func resolveCopyPayload(args string, history *messages.ChatHistory) (copyPayload
 		args = messages.RoleAssistant
 	}
 
+	args, rest := splitFirstWord(args)
 	switch args {
 	case messages.RoleAssistant, messages.RoleUser, messages.RoleSystem:
 		lastMessage, found := history.GetLastRole(args)
@@ -284,7 +474,7 @@ func resolveCopyPayload(args string, history *messages.ChatHistory) (copyPayload
 		}, nil
 
 	case "all":
-		conversation := output.FormatConversation(history.Get(), false)
+		conversation := output.FormatConversation(history.Get(), false, nil)
 		return copyPayload{
 			Text: conversation,
 			Info: "Full conversation copied to clipboard.",
@@ -304,16 +494,90 @@ func resolveCopyPayload(args string, history *messages.ChatHistory) (copyPayload
 		if !found || lastMessage.Content == "" {
 			return copyPayload{Info: nothing}, nil
 		}
-		codeBlock, found := extractCodeBlock(lastMessage.Content)
-		if !found {
-			return copyPayload{Info: nothing}, nil
+		if rest == "" {
+			codeBlock, found := extractCodeBlock(lastMessage.Content)
+			if !found {
+				return copyPayload{Info: nothing}, nil
+			}
+			return copyPayload{
+				Text: codeBlock,
+				Info: "First code block copied to clipboard.",
+			}, nil
+		}
+		block, err := selectCodeBlock(messages.ExtractCodeBlocks(lastMessage.Content), rest)
+		if err != nil {
+			return copyPayload{}, err
 		}
 		return copyPayload{
-			Text: codeBlock,
-			Info: "First code block copied to clipboard.",
+			Text: block.Code,
+			Info: fmt.Sprintf("Code block %s copied to clipboard.", rest),
 		}, nil
 
 	default:
 		return copyPayload{}, fmt.Errorf("unknown copy argument")
 	}
 }
+
+// resolveModel returns the model for /models <arg>: an index of the last
+// listed models or a model name of the server.
+//
+// Parameters:
+//
+//	arg (string)            - index ("3" or "#3") or model name
+//	client (backend.Client) - backend client to check the model name
+//
+// Returns:
+//
+//	string - the model name
+//	error  - error if the index or name is unknown
+func resolveModel(arg string, client backend.Client) (string, error) {
+	if index, err := parseIndex(strings.TrimPrefix(arg, "#")); err == nil {
+		model, ok := utils.GetModelsByIndex(index)
+		if !ok {
+			return "", fmt.Errorf("no value for given index found")
+		}
+		return model, nil
+	}
+
+	models, err := client.GetAvailableModels()
+	if err != nil {
+		return "", fmt.Errorf("get models failed: %w", err)
+	}
+	if !slices.Contains(models, arg) {
+		return "", fmt.Errorf("model %q not found on the server", arg)
+	}
+	return arg, nil
+}
+
+// secretKeyWords mark /set keys whose values are not stored in the input
+// history, even if the key is unknown (e.g. a mistyped "apikey").
+var secretKeyWords = []string{"key", "token", "secret", "password"}
+
+// IsSensitive reports whether an input line sets a secret, such as
+// "/set api_key=...", and must not be stored in the input history.
+//
+// Parameters:
+//
+//	line (string) - the input line
+//
+// Returns:
+//
+//	bool - true if the line contains a secret
+func IsSensitive(line string) bool {
+	cmd, args := parseCommandArgs(line)
+	if cmd != "set" || args[0] == "" {
+		return false
+	}
+
+	key, _, _ := strings.Cut(strings.Join(args, ""), "=")
+	key = strings.ToLower(strings.TrimSpace(key))
+	if spec, ok := envs.EnvSpecByField(key); ok {
+		return spec.Sensitive
+	}
+	for _, word := range secretKeyWords {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
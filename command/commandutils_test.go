@@ -2,8 +2,11 @@ package command
 
 import (
 	"fmt"
+	"picochat/backend"
+	"picochat/config"
 	"picochat/envs"
 	"picochat/messages"
+	"picochat/utils"
 	"picochat/vartypes"
 	"reflect"
 	"strings"
@@ -257,3 +260,88 @@ func TestAskConfirmation(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyPersona(t *testing.T) {
+	cfg, _, err := config.Get()
+	if err != nil {
+		t.Fatalf("load config failed: %v", err)
+	}
+	prevModel, prevTemp := cfg.Model, cfg.Temperature
+	t.Cleanup(func() {
+		cfg.Model, cfg.Temperature = prevModel, prevTemp
+	})
+
+	temp := 0.3
+	h := messages.NewHistory("sys", 10)
+	persona := config.Persona{Prompt: "You are a reviewer.", Temperature: &temp, Model: "reviewer-model"}
+
+	if _, err := applyPersona(cfg, h, "review", persona); err != nil {
+		t.Fatalf("applyPersona returned error: %v", err)
+	}
+	if cfg.Model != "reviewer-model" {
+		t.Errorf("model = %q, want %q", cfg.Model, "reviewer-model")
+	}
+	if cfg.Temperature == nil || *cfg.Temperature != 0.3 {
+		t.Errorf("temperature = %v, want 0.3", cfg.Temperature)
+	}
+	if h.Get()[0].Content != "You are a reviewer." || h.Persona != "review" {
+		t.Errorf("unexpected history state: prompt=%q persona=%q", h.Get()[0].Content, h.Persona)
+	}
+	if !h.Undo() || h.Get()[0].Content != "sys" || h.Persona != "" {
+		t.Errorf("expected undo to restore prompt and persona")
+	}
+}
+
+type stubModelClient struct {
+	backend.Client
+	models []string
+}
+
+func (c stubModelClient) GetAvailableModels() ([]string, error) { return c.models, nil }
+
+func TestResolveModel(t *testing.T) {
+	client := stubModelClient{models: []string{"gemma3:4b", "qwen3:8b"}}
+	if _, err := utils.ListAvailableModels([]string{"qwen3:8b", "gemma3:4b"}); err != nil {
+		t.Fatalf("list models failed: %v", err)
+	}
+
+	tests := []struct {
+		arg     string
+		want    string
+		wantErr bool
+	}{
+		{"1", "gemma3:4b", false},
+		{"#2", "qwen3:8b", false},
+		{"qwen3:8b", "qwen3:8b", false},
+		{"9", "", true},
+		{"llama3", "", true},
+	}
+	for _, tt := range tests {
+		got, err := resolveModel(tt.arg, client)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("resolveModel(%q) = (%q, %v), want (%q, error=%v)", tt.arg, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestIsSensitive(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"/set api_key=sk-123", true},
+		{"/set API_KEY = sk-123", true},
+		{"/set apikey=sk-123", true},
+		{"/set auth_token=abc", true},
+		{"/set temperature=0.2", false},
+		{"/set", false},
+		{"/help set api_key", false},
+		{"please set api_key=1", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsSensitive(tt.line); got != tt.want {
+			t.Errorf("IsSensitive(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"picochat/backend"
+	"picochat/config"
+	"picochat/envs"
+	"picochat/paths"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var (
+	helpCommandRe = regexp.MustCompile(`^\s+(/[a-z?]+(?:, /[a-z?]+)*)`)
+
+	// completionModels caches the model list of the server for completion.
+	completionModels []string
+
+	// fetchModels is replaced in tests.
+	fetchModels = func() ([]string, error) {
+		cfg, _, err := config.Get()
+		if err != nil {
+			return nil, err
+		}
+		return backend.New(cfg).GetAvailableModels()
+	}
+)
+
+// Complete returns the Tab completion candidates for an input line: command
+// names after "/", /set keys, model names for /models, history files for
+// /load, template keys for /paste and /tpl, persona names for /persona and
+// file system paths for /image and /file.
+//
+// Parameters:
+//
+//	line (string) - the input up to the cursor
+//
+// Returns:
+//
+//	string   - the word that is completed (end of line)
+//	[]string - the candidates replacing the word
+func Complete(line string) (string, []string) {
+	if !strings.HasPrefix(line, "/") {
+		return "", nil
+	}
+	name, rest, hasArgs := strings.Cut(line, " ")
+	if !hasArgs {
+		return line, matchCandidates(commandNames(), line)
+	}
+
+	word := rest[strings.LastIndex(rest, " ")+1:]
+	firstArg := !strings.Contains(strings.TrimLeft(rest, " "), " ")
+	cmd, _ := parseCommandArgs(name)
+
+	switch cmd {
+	case "image", "images":
+		if firstArg {
+			return word, append(matchCandidates([]string{"clear", "list", "paste"}, word), completePath(word)...)
+		}
+		return word, completePath(word)
+	case "file", "files":
+		if firstArg {
+			return word, append(matchCandidates([]string{"clear"}, word), completePath(word)...)
+		}
+		return word, completePath(word)
+	}
+
+	if !firstArg {
+		return word, nil
+	}
+
+	var candidates []string
+	switch cmd {
+	case "set":
+		for _, spec := range envs.ConfigEnvVars {
+			if spec.Runtime {
+				candidates = append(candidates, spec.JsonField+"=")
+			}
+		}
+	case "models":
+		candidates = modelNames()
+	case "load":
+		candidates = historyNames()
+	case "paste", "tpl":
+		candidates = config.ListTemplateKeys()
+	case "persona":
+		candidates = config.ListPersonaKeys()
+	case "help":
+		for topic := range helpTopics {
+			if topic != "" {
+				candidates = append(candidates, topic)
+			}
+		}
+		candidates = append(candidates, "envs", "templates", "personas", "aliases")
+		sort.Strings(candidates)
+	}
+	return word, matchCandidates(candidates, word)
+}
+
+// commandNames returns the built-in commands of the help overview and the
+// configured aliases and macros.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	[]string - command names with leading slash
+func commandNames() []string {
+	var names []string
+	for _, line := range helpTopics[""] {
+		m := helpCommandRe.FindStringSubmatch(line)
+		if m == nil || strings.HasPrefix(m[1], "/?") {
+			continue
+		}
+		for name := range strings.SplitSeq(m[1], ", ") {
+			if name != "/?" {
+				names = append(names, name)
+			}
+		}
+	}
+	for _, name := range config.ListAliasNames() {
+		names = append(names, "/"+name)
+	}
+	sort.Strings(names)
+	return slices.Compact(names)
+}
+
+// matchCandidates returns the candidates starting with the word, ignoring
+// case.
+//
+// Parameters:
+//
+//	candidates ([]string) - all candidates
+//	word (string)         - the typed word
+//
+// Returns:
+//
+//	[]string - the matching candidates
+func matchCandidates(candidates []string, word string) []string {
+	var matches []string
+	lower := strings.ToLower(word)
+	for _, c := range candidates {
+		if strings.HasPrefix(strings.ToLower(c), lower) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+// modelNames returns the models of the server. The list is cached after
+// the first successful request.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	[]string - sorted model names, nil if the server cannot be reached
+func modelNames() []string {
+	if completionModels == nil {
+		models, err := fetchModels()
+		if err != nil {
+			return nil
+		}
+		completionModels = slices.Clone(models)
+		sort.Strings(completionModels)
+	}
+	return completionModels
+}
+
+// historyNames returns the names of the saved sessions without suffix.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	[]string - sorted session names
+func historyNames() []string {
+	dir, err := paths.GetHistoryPath()
+	if err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), paths.HistorySuffix); ok && !e.IsDir() {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// completePath returns the files and directories matching a partial path.
+// Directories end with "/"; hidden entries are only listed if the typed
+// name starts with a dot.
+//
+// Parameters:
+//
+//	word (string) - the partial path ("~/" is expanded for the lookup)
+//
+// Returns:
+//
+//	[]string - the matching paths as typed plus the completed name
+func completePath(word string) []string {
+	if word == "~" {
+		return []string{"~/"}
+	}
+	dir, base := word[:strings.LastIndex(word, "/")+1], word[strings.LastIndex(word, "/")+1:]
+	lookup := dir
+	if lookup == "" {
+		lookup = "."
+	}
+	lookup, err := paths.ExpandHomeDir(lookup)
+	if err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(lookup)
+	if err != nil {
+		return nil
+	}
+
+	var matches []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		candidate := dir + name
+		if info, err := os.Stat(filepath.Join(lookup, name)); err == nil && info.IsDir() {
+			candidate += "/"
+		}
+		matches = append(matches, candidate)
+	}
+	return matches
+}
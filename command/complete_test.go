@@ -0,0 +1,107 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"picochat/config"
+	"picochat/paths"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	if _, _, err := config.Get(); err != nil {
+		t.Fatalf("load config failed: %v", err)
+	}
+	t.Cleanup(config.OverrideTemplates(map[string]config.Template{
+		"eng": {Prompt: "Translate to English."},
+		"ger": {Prompt: "Translate to German."},
+	}))
+	t.Cleanup(config.OverrideAliases(map[string]string{"tr": "/paste eng"}, nil))
+
+	prevFetch, prevModels := fetchModels, completionModels
+	t.Cleanup(func() { fetchModels, completionModels = prevFetch, prevModels })
+	completionModels = nil
+	fetchModels = func() ([]string, error) { return []string{"qwen3:8b", "gemma3:12b", "gemma3:4b"}, nil }
+
+	historyDir := t.TempDir()
+	t.Cleanup(paths.OverrideHistoryPath(historyDir))
+	for _, name := range []string{"project.chat", "private.chat", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(historyDir, name), nil, 0644); err != nil {
+			t.Fatalf("write file failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		line     string
+		wantWord string
+		want     []string
+	}{
+		{"/mo", "/mo", []string{"/models"}},
+		{"/re", "/re", []string{"/redo", "/retry"}},
+		{"/t", "/t", []string{"/tr", "/trim"}},
+		{"/set te", "te", []string{"temperature="}},
+		{"/models gem", "gem", []string{"gemma3:12b", "gemma3:4b"}},
+		{"/load pr", "pr", []string{"private", "project"}},
+		{"/paste ", "", []string{"eng", "ger"}},
+		{"/v g", "g", []string{"ger"}},
+		{"/image p", "p", []string{"paste"}},
+		{"/? ima", "ima", []string{"image"}},
+		{"/models gemma3:4b x", "x", nil},
+		{"hello /mo", "", nil},
+	}
+
+	for _, tt := range tests {
+		word, got := Complete(tt.line)
+		if word != tt.wantWord || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Complete(%q) = (%q, %q), want (%q, %q)", tt.line, word, got, tt.wantWord, tt.want)
+		}
+	}
+}
+
+func TestCompletePath(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	for _, name := range []string{"docs/a.md", "docs/b.md", "main.go", ".hidden"} {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatalf("mkdir failed: %v", err)
+		}
+		if err := os.WriteFile(name, nil, 0644); err != nil {
+			t.Fatalf("write file failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"", []string{"docs/", "main.go"}},
+		{"d", []string{"docs/"}},
+		{"docs/", []string{"docs/a.md", "docs/b.md"}},
+		{"./m", []string{"./main.go"}},
+		{".h", []string{".hidden"}},
+		{"missing/", nil},
+	}
+	for _, tt := range tests {
+		if got := completePath(tt.word); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("completePath(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+
+	if _, got := Complete("/file main.go d"); !reflect.DeepEqual(got, []string{"docs/"}) {
+		t.Errorf("Complete(/file ...) = %q, want docs/", got)
+	}
+}
+
+func TestCommandNames(t *testing.T) {
+	names := commandNames()
+	for _, want := range []string{"/copy", "/c", "/undo", "/redo", "/run", "/help", "/bye"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("commandNames() missing %q: %v", want, names)
+		}
+	}
+	if slices.Contains(names, "/?") {
+		t.Error("commandNames() contains /?")
+	}
+}
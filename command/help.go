@@ -9,38 +9,134 @@ var helpTopics = map[string][]string{
 	"": {
 		"  [Ctrl]+D           Submit multiline input (EOF)",
 		"  [Esc], [Ctrl]+C    Cancel multiline input and return to prompt",
-		"  [Up] / [Down]      Browse prompt history (commands only)",
+		"  [Up] / [Down]      Move between lines or browse prompt history",
+		"  [Ctrl]+R           Search prompt history backwards",
+		"  [Tab]              Complete commands, keys, models, files and paths",
 		"  /copy, /c          Copy selected answer to clipboard",
 		"  /paste, /v         Paste clipboard content as user input and send",
 		"  /info              Show system information",
 		"  /trim              Remove all elements after given index",
+		"  /delete            Remove the message with the given index",
+		"  /inject            Insert a user or assistant message",
+		"  /edit              Edit the message with the given index in $EDITOR",
+		"  /compose           Write the next prompt in $EDITOR",
 		"  /message           Show message(s) from chat history",
 		"  /load              Load chat history from file",
 		"  /save              Save current chat history to file",
 		"  /models            List downloaded models (and switch models)",
 		"  /clear             Clear chat history (retaining system prompt)",
+		"  /undo, /redo       Revert or restore the last history change",
 		"  /set               Set session variables (key=value)",
-		"  /image             Set image file path",
+		"  /system            Show or replace the system prompt",
+		"  /persona           Apply a persona preset (prompt, temperature, model)",
+		"  /image             Attach images, image URLs or the clipboard image",
+		"  /file              Attach text files or directories to the next prompt",
+		"  /run, !<cmd>       Run a shell command (-a attaches its output)",
+		"  /code              List the code blocks of the last answer",
+		"  /write             Write code blocks of the last answer to files",
+		"  /apply             Apply unified diffs of the last answer to local files",
 		"  /retry             Resend the chat history excluding last answer",
 		"  /bye               Quit PicoChat",
 		"  /help, /?          Show available commands",
 		"",
 		"  /? envs            Show environment variable status table",
 		"  /? templates       Show template key and description table",
+		"  /? personas        Show persona presets table",
+		"  /? aliases         Show aliases and macros from the config file",
+		"  /? keys            Show the editing keys of the input",
+	},
+	"keys": {
+		"  [Ctrl]+A, [Home]   Move to the start of the line",
+		"  [Ctrl]+E, [End]    Move to the end of the line",
+		"  [Alt]+B / [Alt]+F  Move one word backward / forward",
+		"  [Up] / [Down]      Move between rows, then browse prompt history",
+		"  [Delete]           Delete the character under the cursor",
+		"  [Ctrl]+W           Delete the word before the cursor",
+		"  [Alt]+D            Delete the word after the cursor",
+		"  [Ctrl]+K           Delete to the end of the line",
+		"  [Ctrl]+U           Delete to the start of the line",
+		"  [Ctrl]+Y           Insert the last deleted text",
+		"  [Alt]+Y            Replace the inserted text with an older deleted text",
+		"  [Ctrl]+X [Ctrl]+E  Edit the input in $VISUAL/$EDITOR",
+		"  Vi mode ([Input] Mode = \"vi\"): [Esc] switches to normal mode,",
+		"  the input is canceled with [Input] CancelKey (default [Ctrl]+C)",
 	},
 	"copy": {
 		"  /copy              Copy the last answer to clipboard",
 		"  /copy code         Copy first code snippet enclosed in ``` to clipboard",
+		"  /copy code <n>     Copy code block <n> of the last answer (see /code)",
 		"  /copy think        Copy the last answer to clipboard & retain reasoning",
 		"  /copy all          Copy the full conversation without ANSI formatting",
 		"  /copy #<number>    Copy the message with index <number> to clipboard",
 		"  /copy <role>       Copy the last entry of the given role to clipboard",
 		"  Valid roles: system, user, assistant",
+		"  Code blocks are highlighted in the terminal (see /set highlight).",
 	},
 	"paste": {
 		"  /paste             Paste clipboard content as user prompt and send",
 		"  /paste <key>       Prepend template text to pasted clipboard content and send",
 	},
+	"inject": {
+		"  /inject <role> <text>      Append a message with the given role",
+		"  /inject #<n> <role> <text> Insert the message at index <n>",
+		"  Valid roles: user, assistant",
+		"  The system prompt always stays at index 0.",
+	},
+	"edit": {
+		"  /edit #<number>    Open the message with index <number> in $VISUAL/$EDITOR",
+		"  Saving an empty file cancels the edit.",
+	},
+	"compose": {
+		"  /compose           Open $VISUAL/$EDITOR and put the saved text into the input",
+		"  /compose <text>    Start the editor with <text>",
+		"  Submit the text with [Ctrl]+D; saving an empty file cancels.",
+	},
+	"image": {
+		"  /image             Show the images attached to the next prompt",
+		"  /image <path|url>  Attach image files or http(s) URLs (repeatable)",
+		"  /image paste       Attach the image on the clipboard",
+		"  /image clear       Remove all attached images",
+		"  URLs are passed to OpenAI backends unchanged and downloaded for Ollama.",
+	},
+	"file": {
+		"  /file              Show the files attached to the next prompt",
+		"  /file <path>...    Attach files, directories or glob patterns",
+		"  /file clear        Remove all attached files",
+		"  Directories respect .gitignore; binary and very large files are skipped.",
+		"  Text of PDF, DOCX, ODT, HTML and EPUB files is extracted.",
+	},
+	"code": {
+		"  /code              List code blocks of the last answer (language, lines, file name)",
+		"  /code #<number>    List code blocks of the message with index <number>",
+		"  /copy code <n>     Copy code block <n> to clipboard",
+	},
+	"write": {
+		"  /write <n> [path]  Write code block <n> to <path> (default: annotated file name)",
+		"  /write all [dir]   Write all blocks with annotated file names into <dir>",
+		"  Existing files show a diff and are only overwritten after confirmation.",
+	},
+	"apply": {
+		"  /apply             Apply the ```diff blocks of the last answer",
+		"  /apply #<number>   Apply the diff blocks of the message with index <number>",
+		"  All hunks are dry-run first; files with a failing hunk are rejected as a whole.",
+		"  The changes are written after confirmation.",
+	},
+	"run": {
+		"  /run <cmd>         Run <cmd> in the shell and show its output (same as !<cmd>)",
+		"  /run -a <cmd>      Run <cmd> and attach output and exit code to the next prompt",
+		"  /run               Show how many command outputs are attached",
+		"  /run -clear        Remove all attached command output",
+		"  Timeout, output limit and confirmation are set in the [Run] config table.",
+	},
+	"system": {
+		"  /system            Show the current system prompt",
+		"  /system <text>     Replace the system prompt of the current session",
+	},
+	"persona": {
+		"  /persona           Show the active persona and all presets",
+		"  /persona <name>    Apply prompt, temperature and model of [Personas.<name>]",
+		"  Saved sessions record the persona in use.",
+	},
 	"load": {
 		"  /load              Show list of history files and request filename",
 		"  /load <filename>   Load the history file with name <filename>",
@@ -57,7 +153,8 @@ var helpTopics = map[string][]string{
 	"models": {
 		"  /models            List the available models of the LLM server",
 		"  /models <number>   Load the model by index <number>",
-		"  To use the load option, list the available models first & check index.",
+		"  /models <name>     Load the model with name <name> ([Tab] completes names)",
+		"  To use the index option, list the available models first & check index.",
 	},
 	"save": {
 		"  /save <filename>   Save the history file with name <filename>",
@@ -73,6 +170,8 @@ var helpTopics = map[string][]string{
 		"  temperature        0..2",
 		"  top_p              0..1",
 		"  effort             none, low, medium, high",
+		"  markdown           true, false (render answers in the terminal)",
+		"  highlight          auto, off, 16, 256, truecolor (colors of code blocks)",
 	},
 }
 
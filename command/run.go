@@ -0,0 +1,220 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"picochat/config"
+	"picochat/messages"
+	"picochat/utils"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	defaultRunTimeout   = 30 * time.Second
+	defaultRunMaxOutput = 64 << 10
+)
+
+// runResult holds the outcome of a shell command.
+type runResult struct {
+	Output    string
+	ExitCode  int
+	Truncated bool
+	TimedOut  bool
+}
+
+// shellCommand builds the system shell invocation for a command line.
+//
+// Parameters:
+//
+//	ctx (context.Context) - context that stops the command on timeout
+//	line (string)         - the command line
+//
+// Returns:
+//
+//	*exec.Cmd - "sh -c" on Unix, "cmd /C" on Windows
+func shellCommand(ctx context.Context, line string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", line)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", line)
+}
+
+// handleRun executes /run [-a] <command>, shows the output and optionally
+// attaches it to the next prompt.
+//
+// Parameters:
+//
+//	cfg (*config.Config) - the active configuration
+//	line (string)        - everything after "/run"
+//	input (io.Reader)    - input stream for the confirmation question
+//
+// Returns:
+//
+//	CommandResult - output, info and warnings of the command
+func handleRun(cfg *config.Config, line string, input io.Reader) CommandResult {
+	attach := false
+	switch first, rest := splitFirstWord(line); first {
+	case "":
+		if len(cfg.RunOutputs) == 0 {
+			return CommandResult{Info: "No command output attached."}
+		}
+		return CommandResult{Info: fmt.Sprintf("%d command output(s) attached to next prompt.", len(cfg.RunOutputs))}
+	case "-clear":
+		cfg.RunOutputs = nil
+		return CommandResult{Info: "Attached command output removed."}
+	case "-a", "-attach":
+		attach = true
+		line = rest
+	}
+	if line == "" {
+		return CommandResult{Error: fmt.Errorf("no command provided")}
+	}
+
+	if needsRunConfirmation(cfg.Run, line) {
+		ok, err := askConfirmation(fmt.Sprintf("Run `%s`?", line), input)
+		if err != nil {
+			return CommandResult{Error: err}
+		}
+		if !ok {
+			return CommandResult{Info: "Command canceled."}
+		}
+	}
+
+	timeout := time.Duration(cfg.Run.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultRunTimeout
+	}
+	maxOutput := cfg.Run.MaxOutput
+	if maxOutput <= 0 {
+		maxOutput = defaultRunMaxOutput
+	}
+
+	res, err := runShell(line, timeout, maxOutput)
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+
+	var warnings []string
+	if res.TimedOut {
+		warnings = append(warnings, fmt.Sprintf("command timed out after %s", timeout))
+	}
+	if res.Truncated {
+		warnings = append(warnings, fmt.Sprintf("output truncated to %s", utils.FormatBytes(maxOutput)))
+	}
+
+	info := fmt.Sprintf("Exit code: %d", res.ExitCode)
+	if attach {
+		block := messages.FormatCommandBlock(line, res.Output, res.ExitCode)
+		cfg.RunOutputs = append(cfg.RunOutputs, block)
+		info += fmt.Sprintf("\nOutput attached to next prompt (~%.0f tokens).", messages.CalculateTokens(block))
+	}
+
+	return CommandResult{
+		Output: strings.TrimRight(res.Output, "\n"),
+		Info:   info,
+		Warn:   joinWarnings(warnings),
+	}
+}
+
+// needsRunConfirmation checks if a command has to be confirmed before it
+// is started.
+//
+// Parameters:
+//
+//	run (config.Run) - the [Run] settings
+//	line (string)    - the command line
+//
+// Returns:
+//
+//	bool - true if confirmation is enabled and the command is not allowed
+func needsRunConfirmation(run config.Run, line string) bool {
+	if !run.Confirm {
+		return false
+	}
+	name, _ := splitFirstWord(line)
+	return !slices.Contains(run.Allow, name)
+}
+
+// runShell runs a command line in the system shell with a timeout. Stdout
+// and stderr are combined and capped at maxOutput bytes; stdin is empty.
+//
+// Parameters:
+//
+//	line (string)            - the command line
+//	timeout (time.Duration)  - maximum run time
+//	maxOutput (int)          - maximum number of output bytes kept
+//
+// Returns:
+//
+//	runResult - output, exit code and truncation/timeout flags
+//	error     - error if the command cannot be started
+func runShell(line string, timeout time.Duration, maxOutput int) (runResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	out := &cappedBuffer{max: maxOutput}
+	cmd := shellCommand(ctx, line)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.WaitDelay = time.Second // don't wait for orphaned children holding the pipes
+
+	err := cmd.Run()
+	res := runResult{Output: out.buf.String(), Truncated: out.truncated, TimedOut: ctx.Err() != nil}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+	case res.TimedOut || errors.Is(err, exec.ErrWaitDelay):
+		res.ExitCode = -1
+	default:
+		return runResult{}, fmt.Errorf("run command failed: %w", err)
+	}
+	return res, nil
+}
+
+// cappedBuffer is a writer that keeps at most max bytes and drops the rest.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	if room := c.max - c.buf.Len(); room < len(p) {
+		c.truncated = true
+		if room > 0 {
+			c.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return c.buf.Write(p)
+}
+
+// splitFirstWord splits a string into its first word and the trimmed rest.
+//
+// Parameters:
+//
+//	s (string) - the input string
+//
+// Returns:
+//
+//	string - the first word
+//	string - the remaining text
+func splitFirstWord(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, unicode.IsSpace)
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimSpace(s[i:])
+}
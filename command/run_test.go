@@ -0,0 +1,86 @@
+package command
+
+import (
+	"picochat/config"
+	"picochat/messages"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell syntax")
+	}
+
+	tests := []struct {
+		name      string
+		line      string
+		timeout   time.Duration
+		maxOutput int
+		want      runResult
+	}{
+		{"stdout and stderr", "echo out; echo err >&2", time.Second * 5, 1024, runResult{Output: "out\nerr\n"}},
+		{"exit code", "echo fail; exit 3", time.Second * 5, 1024, runResult{Output: "fail\n", ExitCode: 3}},
+		{"truncated", "printf 0123456789", time.Second * 5, 4, runResult{Output: "0123", Truncated: true}},
+		{"timeout", "echo start; sleep 5", 200 * time.Millisecond, 1024, runResult{Output: "start\n", ExitCode: -1, TimedOut: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runShell(tt.line, tt.timeout, tt.maxOutput)
+			if err != nil {
+				t.Fatalf("runShell failed: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("runShell() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell syntax")
+	}
+	cfg, _, err := config.Get()
+	if err != nil {
+		t.Fatalf("config.Get failed: %v", err)
+	}
+	oldRun := cfg.Run
+	t.Cleanup(func() {
+		cfg.Run = oldRun
+		cfg.RunOutputs = nil
+	})
+	cfg.Run = config.Run{Confirm: true, Allow: []string{"echo"}}
+	h := messages.NewHistory("prompt", 50)
+
+	result := HandleCommand("!echo hello", h, strings.NewReader(""))
+	if result.Error != nil || result.Output != "hello" || len(cfg.RunOutputs) != 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	result = HandleCommand("/run -a echo  a   b", h, strings.NewReader(""))
+	if result.Error != nil || len(cfg.RunOutputs) != 1 || !strings.Contains(cfg.RunOutputs[0], "`echo  a   b` (exit code 0)") {
+		t.Fatalf("expected attached output, got %+v %v", result, cfg.RunOutputs)
+	}
+
+	result = HandleCommand("!printf x", h, strings.NewReader("n\n"))
+	if result.Error != nil || result.Output != "" || !strings.Contains(result.Info, "canceled") {
+		t.Fatalf("expected canceled command, got %+v", result)
+	}
+
+	result = HandleCommand("!printf x", h, strings.NewReader("y\n"))
+	if result.Output != "x" {
+		t.Fatalf("expected confirmed command to run, got %+v", result)
+	}
+
+	_ = HandleCommand("/run -clear", h, strings.NewReader(""))
+	if cfg.RunOutputs != nil {
+		t.Fatalf("expected outputs to be cleared, got %v", cfg.RunOutputs)
+	}
+	if result := HandleCommand("/run -a", h, strings.NewReader("")); result.Error == nil {
+		t.Fatal("expected error for missing command")
+	}
+}
@@ -0,0 +1,111 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"picochat/paths"
+	"strings"
+)
+
+// ScriptDelimiter ends a multi-line prompt in a script file.
+const ScriptDelimiter = "---"
+
+// ScriptStep is a single command or prompt of a script file.
+type ScriptStep struct {
+	Line      int    // 1-based line number of the first line
+	Text      string // command line or prompt text
+	IsCommand bool
+}
+
+// ParseScript splits a script into steps. Outside of a prompt, empty lines
+// and lines starting with "#" are ignored, and lines starting with "/" or
+// "!" are commands. Any other line starts a prompt that runs until a line
+// with only "---" or the end of the file; inside a prompt all lines are
+// kept as they are.
+//
+// Parameters:
+//
+//	r (io.Reader) - the script content
+//
+// Returns:
+//
+//	[]ScriptStep - the steps in order
+//	error        - error if the script cannot be read
+func ParseScript(r io.Reader) ([]ScriptStep, error) {
+	var steps []ScriptStep
+	var prompt []string
+	promptLine := 0
+
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(prompt, "\n"))
+		if text != "" {
+			steps = append(steps, ScriptStep{Line: promptLine, Text: text})
+		}
+		prompt = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+
+		if prompt != nil {
+			if trimmed == ScriptDelimiter {
+				flush()
+			} else {
+				prompt = append(prompt, line)
+			}
+			continue
+		}
+
+		switch {
+		case trimmed == "", trimmed == ScriptDelimiter, strings.HasPrefix(trimmed, "#"):
+			// skip blank lines, stray delimiters and comments
+		case IsCommandLine(trimmed):
+			steps = append(steps, ScriptStep{Line: n, Text: trimmed, IsCommand: true})
+		default:
+			prompt = []string{line}
+			promptLine = n
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read script failed: %w", err)
+	}
+	flush()
+
+	return steps, nil
+}
+
+// LoadScript reads and parses a script file.
+//
+// Parameters:
+//
+//	path (string) - path of the script file ("~" is expanded)
+//
+// Returns:
+//
+//	[]ScriptStep - the steps in order
+//	error        - error if the file cannot be read or has no steps
+func LoadScript(path string) ([]ScriptStep, error) {
+	fullPath, err := paths.ExpandHomeDir(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("open script failed: %w", err)
+	}
+	defer f.Close()
+
+	steps, err := ParseScript(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("script %s has no steps", path)
+	}
+	return steps, nil
+}
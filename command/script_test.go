@@ -0,0 +1,68 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseScript(t *testing.T) {
+	script := `# review workflow
+/load project
+
+/set temperature=0.2
+Summarize the following points:
+# a heading inside the prompt
+/not a command here
+---
+!git status
+Short prompt without delimiter
+`
+	want := []ScriptStep{
+		{Line: 2, Text: "/load project", IsCommand: true},
+		{Line: 4, Text: "/set temperature=0.2", IsCommand: true},
+		{Line: 5, Text: "Summarize the following points:\n# a heading inside the prompt\n/not a command here"},
+		{Line: 9, Text: "!git status", IsCommand: true},
+		{Line: 10, Text: "Short prompt without delimiter"},
+	}
+
+	got, err := ParseScript(strings.NewReader(script))
+	if err != nil {
+		t.Fatalf("ParseScript() error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseScript() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseScript_Empty(t *testing.T) {
+	got, err := ParseScript(strings.NewReader("# only comments\n\n---\n"))
+	if err != nil || len(got) != 0 {
+		t.Fatalf("ParseScript() = (%+v, %v), want no steps", got, err)
+	}
+}
+
+func TestLoadScript(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "flow.pchat")
+	if err := os.WriteFile(path, []byte("/clear\nhello\n"), 0644); err != nil {
+		t.Fatalf("write script failed: %v", err)
+	}
+	steps, err := LoadScript(path)
+	if err != nil || len(steps) != 2 {
+		t.Fatalf("LoadScript() = (%+v, %v), want two steps", steps, err)
+	}
+
+	empty := filepath.Join(dir, "empty.pchat")
+	if err := os.WriteFile(empty, []byte("# nothing\n"), 0644); err != nil {
+		t.Fatalf("write script failed: %v", err)
+	}
+	if _, err := LoadScript(empty); err == nil {
+		t.Fatal("expected error for script without steps")
+	}
+	if _, err := LoadScript(filepath.Join(dir, "missing.pchat")); err == nil {
+		t.Fatal("expected error for missing script")
+	}
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"maps"
+	"picochat/utils"
+	"sort"
+	"strings"
+)
+
+type Macro struct {
+	Description string   `toml:"Description"`
+	Steps       []string `toml:"Steps"`
+}
+
+var (
+	aliases map[string]string
+	macros  map[string]Macro
+)
+
+// setAliases stores loaded aliases and macros as internal copies. Keys are
+// matched case-insensitively and without a leading slash.
+//
+// Parameters:
+//
+//	inAliases (map[string]string) - loaded aliases from config
+//	inMacros (map[string]Macro)   - loaded macros from config
+//
+// Returns:
+//
+//	none
+func setAliases(inAliases map[string]string, inMacros map[string]Macro) {
+	aliases, macros = nil, nil
+	if len(inAliases) > 0 {
+		aliases = make(map[string]string, len(inAliases))
+		for k, v := range inAliases {
+			aliases[normalizeCommandKey(k)] = v
+		}
+	}
+	if len(inMacros) > 0 {
+		macros = make(map[string]Macro, len(inMacros))
+		for k, v := range inMacros {
+			macros[normalizeCommandKey(k)] = v
+		}
+	}
+}
+
+// OverrideAliases replaces the loaded aliases and macros for testing
+// purposes.
+//
+// Parameters:
+//
+//	inAliases (map[string]string) - aliases to use
+//	inMacros (map[string]Macro)   - macros to use
+//
+// Returns:
+//
+//	restore func() - restores the previous aliases and macros
+func OverrideAliases(inAliases map[string]string, inMacros map[string]Macro) (restore func()) {
+	prevAliases, prevMacros := aliases, macros
+	setAliases(inAliases, inMacros)
+	return func() {
+		aliases, macros = prevAliases, prevMacros
+	}
+}
+
+// LookupAlias returns the expansion of an alias.
+//
+// Parameters:
+//
+//	name (string) - alias name (with or without leading slash)
+//
+// Returns:
+//
+//	string - the expansion
+//	bool   - true if the alias exists
+func LookupAlias(name string) (string, bool) {
+	v, ok := aliases[normalizeCommandKey(name)]
+	return v, ok
+}
+
+// LookupMacro returns a macro by name.
+//
+// Parameters:
+//
+//	name (string) - macro name (with or without leading slash)
+//
+// Returns:
+//
+//	Macro - the macro
+//	bool  - true if the macro exists
+func LookupMacro(name string) (Macro, bool) {
+	m, ok := macros[normalizeCommandKey(name)]
+	return m, ok
+}
+
+// ListAliases returns a markdown table of all aliases and macros.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - markdown table with name, type and expansion or description
+func ListAliases() string {
+	tableData := [][]string{{"Command", "Type", "Expansion"}}
+
+	for _, key := range sortedKeys(aliases) {
+		tableData = append(tableData, []string{"/" + key, "alias", aliases[key]})
+	}
+	for _, key := range sortedKeys(macros) {
+		m := macros[key]
+		desc := strings.TrimSpace(m.Description)
+		if desc == "" {
+			desc = strings.Join(m.Steps, " → ")
+		}
+		tableData = append(tableData, []string{"/" + key, "macro", desc})
+	}
+
+	return utils.MarkdownTable(tableData)
+}
+
+// ListAliasNames returns the names of all aliases and macros.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	[]string - sorted names without leading slash
+func ListAliasNames() []string {
+	names := append(sortedKeys(aliases), sortedKeys(macros)...)
+	sort.Strings(names)
+	return names
+}
+
+// HasAliases checks if any alias or macro is defined.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	bool - true if at least one alias or macro exists
+func HasAliases() bool {
+	return len(aliases) > 0 || len(macros) > 0
+}
+
+// normalizeCommandKey lowercases a command name and strips the slash.
+//
+// Parameters:
+//
+//	name (string) - the command name, with or without slash
+//
+// Returns:
+//
+//	string - the key of the alias or macro
+func normalizeCommandKey(name string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "/"))
+}
+
+// sortedKeys returns the sorted keys of a map.
+//
+// Parameters:
+//
+//	m (map[string]V) - the map
+//
+// Returns:
+//
+//	[]string - the keys in ascending order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range maps.Keys(m) {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
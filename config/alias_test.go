@@ -0,0 +1,61 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestLookupAliasAndMacro(t *testing.T) {
+	t.Cleanup(OverrideAliases(
+		map[string]string{"/TR": "/paste eng"},
+		map[string]Macro{"Review": {Description: "Review diff", Steps: []string{"/run -a git diff", "Review it."}}},
+	))
+
+	if got, ok := LookupAlias("tr"); !ok || got != "/paste eng" {
+		t.Fatalf("LookupAlias(tr) = (%q, %v), want (%q, true)", got, ok, "/paste eng")
+	}
+	if got, ok := LookupAlias("/Tr"); !ok || got != "/paste eng" {
+		t.Fatalf("LookupAlias(/Tr) = (%q, %v), want (%q, true)", got, ok, "/paste eng")
+	}
+	if _, ok := LookupAlias("review"); ok {
+		t.Fatal("LookupAlias(review) found a macro")
+	}
+	if m, ok := LookupMacro("/review"); !ok || len(m.Steps) != 2 {
+		t.Fatalf("LookupMacro(/review) = (%+v, %v), want two steps", m, ok)
+	}
+
+	table := ListAliases()
+	for _, want := range []string{"/tr", "alias", "/paste eng", "/review", "macro", "Review diff"} {
+		if !strings.Contains(table, want) {
+			t.Errorf("ListAliases() missing %q:\n%s", want, table)
+		}
+	}
+}
+
+func TestAliases_DecodeTOML(t *testing.T) {
+	data := `
+[Aliases]
+  tr = "/paste eng"
+
+[Macros.review]
+  Description = "Review"
+  Steps = ["/run -a git diff", "Review the diff."]
+`
+	var cfg Config
+	if _, err := toml.Decode(data, &cfg); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if cfg.Aliases["tr"] != "/paste eng" {
+		t.Fatalf("Aliases = %v", cfg.Aliases)
+	}
+	if got := cfg.Macros["review"].Steps; len(got) != 2 || got[1] != "Review the diff." {
+		t.Fatalf("Macros = %+v", cfg.Macros)
+	}
+
+	t.Cleanup(OverrideAliases(nil, nil))
+	if HasAliases() {
+		t.Fatal("HasAliases() = true without aliases")
+	}
+}
@@ -22,12 +22,67 @@ type Config struct {
 	Effort      string   `json:"effort"`
 	Quiet       bool     `json:"quiet"`
 	Validate    bool     `json:"validate"`
+	Markdown    bool     `json:"markdown"`
+	Highlight   string   `json:"highlight"`
+	Images      Images   `toml:"Images" json:"images"`
+	Run         Run      `toml:"Run" json:"run"`
+	Input       Input    `toml:"Input" json:"input"`
+	Colors      Colors   `toml:"Colors" json:"colors"`
+
+	ConfigPath     string              `toml:"-"`
+	ImagePaths     []string            `toml:"-" json:"-"` // images (paths, refs or URLs) attached to the next prompt
+	FilePaths      []string            `toml:"-" json:"-"` // files attached to the next prompt
+	RunOutputs     []string            `toml:"-" json:"-"` // command output blocks attached to the next prompt
+	OutputFmt      string              `toml:"-"`
+	SchemaFmt      map[string]any      `toml:"-"`
+	PromptOverride string              `toml:"-" json:"-"`
+	Templates      map[string]Template `toml:"Templates"`
+	Personas       map[string]Persona  `toml:"Personas"`
+	Aliases        map[string]string   `toml:"Aliases"`
+	Macros         map[string]Macro    `toml:"Macros"`
+}
+
+// Images holds the preprocessing settings for attached images.
+type Images struct {
+	MaxDimension int `toml:"MaxDimension" json:"max_dimension"` // longest side in pixels, 0 disables resizing
+	Quality      int `toml:"Quality" json:"quality"`            // JPEG quality of re-encoded images
+	WebPQuality  int `toml:"WebPQuality" json:"webp_quality"`   // JPEG quality of converted WebP images, 0 uses Quality
+}
+
+// Run holds the settings for shell commands started with /run or !.
+type Run struct {
+	Confirm   bool     `toml:"Confirm" json:"confirm"`      // ask before each command
+	Allow     []string `toml:"Allow" json:"allow"`          // commands that never need a confirmation
+	Timeout   int      `toml:"Timeout" json:"timeout"`      // seconds, 0 uses the default
+	MaxOutput int      `toml:"MaxOutput" json:"max_output"` // bytes, 0 uses the default
+}
+
+// Input holds the key bindings of the input editor.
+type Input struct {
+	Mode      string `toml:"Mode" json:"mode"`            // "emacs" or "vi"
+	CancelKey string `toml:"CancelKey" json:"cancel_key"` // control key that cancels the input in vi mode
+}
 
-	ConfigPath string              `toml:"-"`
-	ImagePath  string              `toml:"-"` ////IMAGES
-	OutputFmt  string              `toml:"-"`
-	SchemaFmt  map[string]any      `toml:"-"`
-	Templates  map[string]Template `toml:"Templates"`
+// Colors holds the color theme and the styles of single UI elements. A
+// style is a list of color names, font styles, 256 color indexes or
+// #rrggbb values, e.g. "bold blue"; empty keeps the style of the theme.
+type Colors struct {
+	Theme     string `toml:"Theme" json:"theme"` // "default", "light" or "mono"
+	Prompt    string `toml:"Prompt" json:"prompt"`
+	Info      string `toml:"Info" json:"info"`
+	Warn      string `toml:"Warn" json:"warn"`
+	Error     string `toml:"Error" json:"error"`
+	Reasoning string `toml:"Reasoning" json:"reasoning"`
+	Status    string `toml:"Status" json:"status"`   // status line below the answer
+	Hint      string `toml:"Hint" json:"hint"`       // input hint and hidden completions
+	Heading   string `toml:"Heading" json:"heading"` // Markdown elements of answers
+	Marker    string `toml:"Marker" json:"marker"`
+	Quote     string `toml:"Quote" json:"quote"`
+	Code      string `toml:"Code" json:"code"`
+	Border    string `toml:"Border" json:"border"`
+	Label     string `toml:"Label" json:"label"`
+	Added     string `toml:"Added" json:"added"` // /apply summary
+	Removed   string `toml:"Removed" json:"removed"`
 }
 
 var (
@@ -94,8 +149,10 @@ func load(configPathArg string) {
 	// 4. Check value contraints
 	loadWarn = append(loadWarn, cfg.NormalizeConfig()...)
 
-	// 5. Load templates
+	// 5. Load templates, personas, aliases and macros
 	setTemplates(cfg.Templates)
+	setPersonas(cfg.Personas)
+	setAliases(cfg.Aliases, cfg.Macros)
 
 	cfg.ConfigPath = path
 	instance = &cfg
@@ -122,6 +179,23 @@ func defaultConfig() Config {
 		Effort:    "medium",
 		Quiet:     false,
 		Validate:  true, // can be disabled for debugging purposes
+		Markdown:  true,
+		Highlight: "auto",
+		Images: Images{
+			MaxDimension: 1568,
+			Quality:      85,
+		},
+		Run: Run{
+			Timeout:   30,
+			MaxOutput: 64 << 10,
+		},
+		Input: Input{
+			Mode:      "emacs",
+			CancelKey: "ctrl+c",
+		},
+		Colors: Colors{
+			Theme: "default",
+		},
 	}
 }
 
@@ -47,8 +47,8 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.ConfigPath != "" {
 		t.Errorf("ConfigPath default = %q, want empty string", cfg.ConfigPath)
 	}
-	if cfg.ImagePath != "" {
-		t.Errorf("ImagePath default = %q, want empty string", cfg.ImagePath)
+	if cfg.ImagePaths != nil {
+		t.Errorf("ImagePaths default = %v, want nil", cfg.ImagePaths)
 	}
 	if cfg.OutputFmt != "" {
 		t.Errorf("OutputFmt default = %q, want empty string", cfg.OutputFmt)
@@ -111,6 +111,32 @@ func (c *Config) NormalizeConfig() []string {
 		}
 	}
 
+	origHighlight := c.Highlight
+	if v, warn := normalizeHighlight(c.Highlight); v != c.Highlight {
+		c.Highlight = v
+		if warn {
+			warnings = append(warnings, fmt.Sprintf("config value 'highlight' (%q) invalid, normalized to %q", origHighlight, v))
+		}
+	}
+
+	origDim := c.Images.MaxDimension
+	if v, changed := clampInt("images.max_dimension", c.Images.MaxDimension, MinImageDim, MaxImageDim); changed {
+		c.Images.MaxDimension = v
+		warnings = append(warnings, fmt.Sprintf("config value 'Images.MaxDimension' (%d) out of range [%d..%d], clamped to %d", origDim, MinImageDim, MaxImageDim, v))
+	}
+
+	origQual := c.Images.Quality // 0 keeps the encoder default
+	if v, changed := clampInt("images.quality", c.Images.Quality, MinImageQual, MaxImageQual); changed && origQual != 0 {
+		c.Images.Quality = v
+		warnings = append(warnings, fmt.Sprintf("config value 'Images.Quality' (%d) out of range [%d..%d], clamped to %d", origQual, MinImageQual, MaxImageQual, v))
+	}
+
+	origWebPQual := c.Images.WebPQuality // 0 uses Quality
+	if v, changed := clampInt("images.webp_quality", c.Images.WebPQuality, MinImageQual, MaxImageQual); changed && origWebPQual != 0 {
+		c.Images.WebPQuality = v
+		warnings = append(warnings, fmt.Sprintf("config value 'Images.WebPQuality' (%d) out of range [%d..%d], clamped to %d", origWebPQual, MinImageQual, MaxImageQual, v))
+	}
+
 	origBackend := c.Backend
 	if v, warn := normalizeBackend(c.Backend); v != c.Backend {
 		c.Backend = v
@@ -119,9 +145,62 @@ func (c *Config) NormalizeConfig() []string {
 		}
 	}
 
+	origMode := c.Input.Mode
+	if v, warn := normalizeInputMode(c.Input.Mode); v != c.Input.Mode {
+		c.Input.Mode = v
+		if warn {
+			warnings = append(warnings, fmt.Sprintf("config value 'Input.Mode' (%q) invalid, normalized to %q", origMode, v))
+		}
+	}
+
+	origCancel := c.Input.CancelKey
+	if v, warn := normalizeCancelKey(c.Input.CancelKey); v != c.Input.CancelKey {
+		c.Input.CancelKey = v
+		if warn {
+			warnings = append(warnings, fmt.Sprintf("config value 'Input.CancelKey' (%q) invalid, normalized to %q", origCancel, v))
+		}
+	}
+
+	origTheme := c.Colors.Theme
+	if v, warn := normalizeTheme(c.Colors.Theme); v != c.Colors.Theme {
+		c.Colors.Theme = v
+		if warn {
+			warnings = append(warnings, fmt.Sprintf("config value 'Colors.Theme' (%q) invalid, normalized to %q", origTheme, v))
+		}
+	}
+
 	return warnings
 }
 
+// Overrides returns the styles of single UI elements by element name.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	map[string]string - the style specs, empty for the style of the theme
+func (c Colors) Overrides() map[string]string {
+	return map[string]string{
+		"prompt":    c.Prompt,
+		"info":      c.Info,
+		"warn":      c.Warn,
+		"error":     c.Error,
+		"reasoning": c.Reasoning,
+		"status":    c.Status,
+		"hint":      c.Hint,
+		"heading":   c.Heading,
+		"marker":    c.Marker,
+		"quote":     c.Quote,
+		"code":      c.Code,
+		"border":    c.Border,
+		"label":     c.Label,
+		"added":     c.Added,
+		"removed":   c.Removed,
+	}
+}
+
 // HasSchema checks if a JSON schema for structured output was loaded.
 //
 // Parameters:
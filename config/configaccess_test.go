@@ -275,6 +275,8 @@ func TestGetRuntimeConfigValues(t *testing.T) {
 			Reasoning:   true,
 			Effort:      "high",
 			Validate:    false,
+			Markdown:    true,
+			Highlight:   "256",
 		}
 
 		got, err := cfg.GetRuntimeConfigValues()
@@ -288,6 +290,8 @@ func TestGetRuntimeConfigValues(t *testing.T) {
 			"reasoning = true",
 			"effort = high",
 			"validate = false",
+			"markdown = true",
+			"highlight = 256",
 		}
 
 		if len(got) != len(want) {
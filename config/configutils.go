@@ -12,6 +12,10 @@ const (
 	MaxTemperature = 2.0
 	MinTopP        = 0.0
 	MaxTopP        = 1.0
+	MinImageDim    = 0
+	MaxImageDim    = 8192
+	MinImageQual   = 1
+	MaxImageQual   = 100
 )
 
 // clampInt clamps an integer value to the given inclusive range.
@@ -94,6 +98,32 @@ func normalizeEffort(raw string) (string, bool) {
 	}
 }
 
+// normalizeHighlight validates and normalizes the highlight setting of
+// code blocks.
+//
+// Parameters:
+//
+//	raw (string) - input highlight value
+//
+// Returns:
+//
+//	string - normalized highlight value
+//	bool   - true if fallback handling was applied
+func normalizeHighlight(raw string) (string, bool) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+
+	switch value {
+	case "auto", "off", "16", "256", "truecolor":
+		return value, false
+	case "":
+		return "auto", false
+	case "24bit":
+		return "truecolor", false
+	default:
+		return "auto", true
+	}
+}
+
 // normalizeBackend validates and normalizes the backend value.
 //
 // Parameters:
@@ -114,3 +144,73 @@ func normalizeBackend(raw string) (string, bool) {
 		return "ollama", true
 	}
 }
+
+// normalizeInputMode validates and normalizes the editing mode of the input.
+//
+// Parameters:
+//
+//	raw (string) - input mode value
+//
+// Returns:
+//
+//	string - normalized mode value
+//	bool   - true if fallback handling was applied
+func normalizeInputMode(raw string) (string, bool) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+
+	switch value {
+	case "emacs", "vi":
+		return value, false
+	case "":
+		return "emacs", false
+	default:
+		return "emacs", true
+	}
+}
+
+// normalizeTheme validates and normalizes the name of the color theme.
+//
+// Parameters:
+//
+//	raw (string) - input theme name
+//
+// Returns:
+//
+//	string - normalized theme name
+//	bool   - true if fallback handling was applied
+func normalizeTheme(raw string) (string, bool) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+
+	switch value {
+	case "default", "light", "mono":
+		return value, false
+	case "", "dark":
+		return "default", false
+	default:
+		return "default", true
+	}
+}
+
+// normalizeCancelKey validates and normalizes the cancel key of the vi mode.
+// Control keys with a function in the editor cannot be used.
+//
+// Parameters:
+//
+//	raw (string) - input key value, e.g. "Ctrl+G"
+//
+// Returns:
+//
+//	string - normalized key value
+//	bool   - true if fallback handling was applied
+func normalizeCancelKey(raw string) (string, bool) {
+	value := strings.ToLower(strings.ReplaceAll(raw, " ", ""))
+	if value == "" {
+		return "ctrl+c", false
+	}
+
+	letter, ok := strings.CutPrefix(value, "ctrl+")
+	if !ok || len(letter) != 1 || letter[0] < 'a' || letter[0] > 'z' || strings.Contains("dijmrx", letter) {
+		return "ctrl+c", true
+	}
+	return value, false
+}
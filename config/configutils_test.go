@@ -158,6 +158,45 @@ func TestNormalizeBackend(t *testing.T) {
 	}
 }
 
+func TestNormalizeInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		normalize func(string) (string, bool)
+		in        string
+		wantValue string
+		wantWarn  bool
+	}{
+		{name: "highlight 256", normalize: normalizeHighlight, in: "256", wantValue: "256", wantWarn: false},
+		{name: "highlight alias", normalize: normalizeHighlight, in: "24bit", wantValue: "truecolor", wantWarn: false},
+		{name: "highlight empty", normalize: normalizeHighlight, in: "", wantValue: "auto", wantWarn: false},
+		{name: "highlight invalid", normalize: normalizeHighlight, in: "rainbow", wantValue: "auto", wantWarn: true},
+		{name: "theme light", normalize: normalizeTheme, in: "Light", wantValue: "light", wantWarn: false},
+		{name: "theme dark alias", normalize: normalizeTheme, in: "dark", wantValue: "default", wantWarn: false},
+		{name: "theme invalid", normalize: normalizeTheme, in: "solarized", wantValue: "default", wantWarn: true},
+		{name: "mode vi", normalize: normalizeInputMode, in: "Vi", wantValue: "vi", wantWarn: false},
+		{name: "mode empty", normalize: normalizeInputMode, in: "", wantValue: "emacs", wantWarn: false},
+		{name: "mode invalid", normalize: normalizeInputMode, in: "vim", wantValue: "emacs", wantWarn: true},
+		{name: "cancel key", normalize: normalizeCancelKey, in: "Ctrl+G", wantValue: "ctrl+g", wantWarn: false},
+		{name: "cancel key with spaces", normalize: normalizeCancelKey, in: "ctrl + q", wantValue: "ctrl+q", wantWarn: false},
+		{name: "cancel key empty", normalize: normalizeCancelKey, in: "", wantValue: "ctrl+c", wantWarn: false},
+		{name: "cancel key used by editor", normalize: normalizeCancelKey, in: "ctrl+d", wantValue: "ctrl+c", wantWarn: true},
+		{name: "cancel key used by external edit", normalize: normalizeCancelKey, in: "ctrl+x", wantValue: "ctrl+c", wantWarn: true},
+		{name: "cancel key invalid", normalize: normalizeCancelKey, in: "esc", wantValue: "ctrl+c", wantWarn: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warn := tt.normalize(tt.in)
+			if got != tt.wantValue {
+				t.Fatalf("value = %q, want %q", got, tt.wantValue)
+			}
+			if warn != tt.wantWarn {
+				t.Fatalf("warn = %v, want %v", warn, tt.wantWarn)
+			}
+		})
+	}
+}
+
 func TestHasSchema(t *testing.T) {
 	t.Run("nil receiver", func(t *testing.T) {
 		var cfg *Config
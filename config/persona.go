@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"picochat/utils"
+	"sort"
+	"strings"
+)
+
+type Persona struct {
+	Description string   `toml:"Description"`
+	Prompt      string   `toml:"Prompt"`
+	Temperature *float64 `toml:"Temperature"`
+	Model       string   `toml:"Model"`
+}
+
+var personas map[string]Persona
+
+// setPersonas stores loaded personas as an internal copy.
+//
+// Parameters:
+//
+//	in (map[string]Persona) - loaded personas from config
+//
+// Returns:
+//
+//	none
+func setPersonas(in map[string]Persona) {
+	if len(in) == 0 {
+		personas = nil
+		return
+	}
+
+	personas = make(map[string]Persona, len(in))
+	maps.Copy(personas, in)
+}
+
+// GetPersona returns a persona by key.
+//
+// Parameters:
+//
+//	key (string) - persona key
+//
+// Returns:
+//
+//	Persona - the persona settings
+//	error   - error if not found or empty
+func GetPersona(key string) (Persona, error) {
+	key = strings.TrimSpace(key)
+	p, ok := personas[key]
+	if !ok {
+		return Persona{}, fmt.Errorf("persona %q not found", key)
+	}
+	if strings.TrimSpace(p.Prompt) == "" && p.Temperature == nil && strings.TrimSpace(p.Model) == "" {
+		return Persona{}, fmt.Errorf("persona %q has no settings", key)
+	}
+	return p, nil
+}
+
+// ListPersonaKeys returns all loaded persona keys.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	[]string - sorted list of persona keys
+func ListPersonaKeys() []string {
+	keys := make([]string, 0, len(personas))
+	for k := range personas {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ListPersonas returns a markdown table of all loaded personas.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - markdown table with persona key, model, temperature and description
+func ListPersonas() string {
+	tableData := make([][]string, 0, len(personas)+1)
+	tableData = append(tableData, []string{"Key", "Model", "Temperature", "Description"})
+
+	for _, key := range ListPersonaKeys() {
+		p := personas[key]
+		desc := strings.TrimSpace(p.Description)
+		if desc == "" {
+			desc = "[none]"
+		}
+		model := strings.TrimSpace(p.Model)
+		if model == "" {
+			model = "[unchanged]"
+		}
+		temp := "[unchanged]"
+		if p.Temperature != nil {
+			temp = fmt.Sprintf("%.2f", *p.Temperature)
+		}
+		tableData = append(tableData, []string{key, model, temp, desc})
+	}
+
+	return utils.MarkdownTable(tableData)
+}
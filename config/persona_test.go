@@ -0,0 +1,58 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetPersona(t *testing.T) {
+	prev := personas
+	t.Cleanup(func() {
+		personas = prev
+	})
+
+	temp := 0.2
+	setPersonas(map[string]Persona{
+		"dev":   {Prompt: "You are a developer", Temperature: &temp, Model: "coder"},
+		"empty": {Description: "no settings"},
+	})
+
+	p, err := GetPersona(" dev ")
+	if err != nil {
+		t.Fatalf("GetPersona(dev) returned error: %v", err)
+	}
+	if p.Prompt != "You are a developer" || p.Model != "coder" || p.Temperature == nil || *p.Temperature != 0.2 {
+		t.Fatalf("unexpected persona: %+v", p)
+	}
+	if _, err := GetPersona("missing"); err == nil {
+		t.Fatal("expected error for missing persona")
+	}
+	if _, err := GetPersona("empty"); err == nil {
+		t.Fatal("expected error for persona without settings")
+	}
+}
+
+func TestListPersonas(t *testing.T) {
+	prev := personas
+	t.Cleanup(func() {
+		personas = prev
+	})
+
+	temp := 0.7
+	setPersonas(map[string]Persona{
+		"writer": {Prompt: "Write", Temperature: &temp, Description: "Creative"},
+		"dev":    {Prompt: "Code", Model: "coder"},
+	})
+
+	got := ListPersonas()
+	lines := strings.Split(got, "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 table lines, got %d:\n%s", len(lines), got)
+	}
+	if !strings.Contains(lines[2], "dev") || !strings.Contains(lines[2], "coder") || !strings.Contains(lines[2], "[none]") {
+		t.Fatalf("unexpected dev row: %q", lines[2])
+	}
+	if !strings.Contains(lines[3], "writer") || !strings.Contains(lines[3], "0.70") || !strings.Contains(lines[3], "[unchanged]") {
+		t.Fatalf("unexpected writer row: %q", lines[3])
+	}
+}
@@ -3,14 +3,31 @@ package config
 import (
 	"fmt"
 	"maps"
+	"os"
+	"picochat/paths"
 	"picochat/utils"
 	"sort"
 	"strings"
+	"text/template"
+	"time"
 )
 
 type Template struct {
-	Description string `toml:"Description"`
-	Prompt      string `toml:"Prompt"`
+	Description string   `toml:"Description"`
+	Prompt      string   `toml:"Prompt"`
+	System      string   `toml:"System"`
+	Model       string   `toml:"Model"`
+	Temperature *float64 `toml:"Temperature"`
+	Schema      string   `toml:"Schema"`
+}
+
+// TemplateVars holds the values available as placeholders in template
+// prompts, e.g. {{.Input}}, {{.Clipboard}}, {{.Date}}, {{.File "path"}}
+// and named arguments as {{.Args.name}}.
+type TemplateVars struct {
+	Input     string
+	Args      map[string]string
+	clipboard func() (string, error)
 }
 
 var templates map[string]Template
@@ -34,6 +51,23 @@ func setTemplates(in map[string]Template) {
 	maps.Copy(templates, in)
 }
 
+// OverrideTemplates replaces the loaded templates for testing purposes.
+//
+// Parameters:
+//
+//	in (map[string]Template) - templates to use
+//
+// Returns:
+//
+//	restore func() - restores the previous templates
+func OverrideTemplates(in map[string]Template) (restore func()) {
+	prev := templates
+	setTemplates(in)
+	return func() {
+		templates = prev
+	}
+}
+
 // GetTemplate returns a template text by key.
 //
 // Parameters:
@@ -49,17 +83,191 @@ func GetTemplate(key string) (string, error) {
 	if key == "" {
 		return "", nil
 	}
+	tpl, err := LookupTemplate(key)
+	if err != nil {
+		return "", err
+	}
+	return tpl.Prompt, nil
+}
+
+// LookupTemplate returns the full template definition by key.
+//
+// Parameters:
+//
+//	key (string) - template key
+//
+// Returns:
+//
+//	Template - the template definition
+//	error    - error if not found or the prompt is empty
+func LookupTemplate(key string) (Template, error) {
+	key = strings.TrimSpace(key)
 	tpl, ok := templates[key]
 	if !ok {
-		return "", fmt.Errorf("template key %q not found", key)
+		return Template{}, fmt.Errorf("template key %q not found", key)
 	}
 	if strings.TrimSpace(tpl.Prompt) == "" {
-		return "", fmt.Errorf("template prompt for %q is empty", key)
+		return Template{}, fmt.Errorf("template prompt for %q is empty", key)
 	}
-	return tpl.Prompt, nil
+	return tpl, nil
+}
+
+// NewTemplateVars creates the placeholder values for rendering a template.
+//
+// Parameters:
+//
+//	input (string)                     - free text input (stdin, command text or clipboard)
+//	args (map[string]string)           - named arguments
+//	clipboard (func() (string, error)) - lazy clipboard reader
+//
+// Returns:
+//
+//	TemplateVars - the placeholder values
+func NewTemplateVars(input string, args map[string]string, clipboard func() (string, error)) TemplateVars {
+	return TemplateVars{Input: input, Args: args, clipboard: clipboard}
+}
+
+// Clipboard returns the clipboard content. It is only read if the
+// template actually uses the placeholder.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - clipboard content
+//	error  - error if any
+func (v TemplateVars) Clipboard() (string, error) {
+	if v.clipboard == nil {
+		return "", fmt.Errorf("clipboard not available")
+	}
+	return v.clipboard()
+}
+
+// File returns the content of a local text file.
+//
+// Parameters:
+//
+//	path (string) - path to the file (~ is expanded)
+//
+// Returns:
+//
+//	string - file content
+//	error  - error if any
+func (v TemplateVars) File(path string) (string, error) {
+	fullPath, err := paths.ExpandHomeDir(path)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("read file %q failed: %w", path, err)
+	}
+	return strings.TrimRight(string(data), "\n"), nil
+}
+
+// Date returns the current date in ISO format.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - date as YYYY-MM-DD
+func (v TemplateVars) Date() string {
+	return time.Now().Format("2006-01-02")
+}
+
+// Render builds the final user prompt. Templates without placeholders keep
+// the classic behavior and prepend the prompt to the input text.
+//
+// Parameters:
+//
+//	vars (TemplateVars) - placeholder values
+//
+// Returns:
+//
+//	string - the rendered prompt
+//	error  - error if parsing/rendering fails or input is missing
+func (t Template) Render(vars TemplateVars) (string, error) {
+	if !strings.Contains(t.Prompt, "{{") {
+		if strings.TrimSpace(vars.Input) == "" {
+			return "", fmt.Errorf("template needs input text")
+		}
+		return fmt.Sprintf("%s\n\n%s", t.Prompt, vars.Input), nil
+	}
+
+	tpl, err := template.New("prompt").Option("missingkey=error").Parse(t.Prompt)
+	if err != nil {
+		return "", fmt.Errorf("parse template failed: %w", err)
+	}
+
+	var b strings.Builder
+	if err := tpl.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("render template failed: %w", err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// HasOverrides checks if the template changes any session settings.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	bool - true if system prompt, model, temperature or schema are set
+func (t Template) HasOverrides() bool {
+	return strings.TrimSpace(t.System) != "" || strings.TrimSpace(t.Model) != "" ||
+		t.Temperature != nil || strings.TrimSpace(t.Schema) != ""
+}
+
+// ApplyTo returns a copy of the config with the template settings applied.
+// The original config is not modified, so the settings only affect the
+// request the copy is used for.
+//
+// Parameters:
+//
+//	cfg (*Config) - the active configuration
+//
+// Returns:
+//
+//	*Config  - the config copy with overrides
+//	[]string - warnings for clamped values
+//	error    - error if the schema file cannot be loaded
+func (t Template) ApplyTo(cfg *Config) (*Config, []string, error) {
+	if cfg == nil {
+		return nil, nil, fmt.Errorf("config is nil")
+	}
+
+	next := *cfg
+	if system := strings.TrimSpace(t.System); system != "" {
+		next.PromptOverride = system
+	}
+	if model := strings.TrimSpace(t.Model); model != "" {
+		next.Model = model
+	}
+	if t.Temperature != nil {
+		temp := *t.Temperature
+		next.Temperature = &temp
+	}
+	if schemaPath := strings.TrimSpace(t.Schema); schemaPath != "" {
+		schema, err := utils.LoadSchemaFromFile(schemaPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load json schema file failed: %w", err)
+		}
+		next.SchemaFmt = schema
+		next.OutputFmt = "plain" // Schema overrules output format
+	}
+
+	warnings := next.NormalizeConfig()
+	return &next, warnings, nil
 }
 
-// listTemplateKeys returns all loaded template keys.
+// ListTemplateKeys returns all loaded template keys.
 //
 // Parameters:
 //
@@ -68,7 +276,7 @@ func GetTemplate(key string) (string, error) {
 // Returns:
 //
 //	[]string - sorted list of template keys
-func listTemplateKeys() []string {
+func ListTemplateKeys() []string {
 	keys := make([]string, 0, len(templates))
 	for k := range templates {
 		keys = append(keys, k)
@@ -90,7 +298,7 @@ func ListTemplates() string {
 	tableData := make([][]string, 0, len(templates)+1)
 	tableData = append(tableData, []string{"Key", "Description"})
 
-	for _, key := range listTemplateKeys() {
+	for _, key := range ListTemplateKeys() {
 		desc := strings.TrimSpace(templates[key].Description)
 		if desc == "" {
 			desc = "[none]"
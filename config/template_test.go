@@ -1,6 +1,9 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -106,3 +109,103 @@ func TestSetTemplates_EmptyClears(t *testing.T) {
 		t.Fatalf("expected header-only table, got:\n%s", got)
 	}
 }
+
+func TestTemplateRender(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "notes.txt")
+	if err := os.WriteFile(file, []byte("file content\n"), 0644); err != nil {
+		t.Fatalf("write notes.txt: %v", err)
+	}
+
+	clipboardCalls := 0
+	clipboard := func() (string, error) {
+		clipboardCalls++
+		return "from clipboard", nil
+	}
+
+	tests := []struct {
+		name    string
+		prompt  string
+		input   string
+		args    map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "legacy prepends prompt", prompt: "Translate:", input: "Hallo", want: "Translate:\n\nHallo"},
+		{name: "legacy without input fails", prompt: "Translate:", wantErr: true},
+		{name: "input placeholder", prompt: "Say {{.Input}}!", input: "hi", want: "Say hi!"},
+		{name: "named argument", prompt: "Translate into {{.Args.lang}}: {{.Input}}", input: "Hallo", args: map[string]string{"lang": "French"}, want: "Translate into French: Hallo"},
+		{name: "missing argument fails", prompt: "Translate into {{.Args.lang}}", args: map[string]string{}, wantErr: true},
+		{name: "clipboard placeholder", prompt: "Fix: {{.Clipboard}}", want: "Fix: from clipboard"},
+		{name: "file placeholder", prompt: "Review {{.File \"" + filepath.ToSlash(file) + "\"}}", want: "Review file content"},
+		{name: "missing file fails", prompt: "{{.File \"/does/not/exist\"}}", wantErr: true},
+		{name: "invalid syntax fails", prompt: "{{.Input", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl := Template{Prompt: tt.prompt}
+			got, err := tpl.Render(NewTemplateVars(tt.input, tt.args, clipboard))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if clipboardCalls != 1 {
+		t.Errorf("expected clipboard to be read once, got %d", clipboardCalls)
+	}
+}
+
+func TestTemplateRender_Date(t *testing.T) {
+	got, err := Template{Prompt: "Today is {{.Date}}"}.Render(NewTemplateVars("", nil, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !regexp.MustCompile(`^Today is \d{4}-\d{2}-\d{2}$`).MatchString(got) {
+		t.Fatalf("unexpected date output %q", got)
+	}
+}
+
+func TestTemplateApplyTo(t *testing.T) {
+	tmpDir := t.TempDir()
+	schemaFile := filepath.Join(tmpDir, "schema.json")
+	if err := os.WriteFile(schemaFile, []byte(`{"type":"object"}`), 0644); err != nil {
+		t.Fatalf("write schema.json: %v", err)
+	}
+
+	temp := 5.0
+	base := &Config{Model: "base", OutputFmt: "json", Context: 10, Effort: "medium", Backend: "ollama"}
+	tpl := Template{Prompt: "x", System: "Be brief.", Model: "other", Temperature: &temp, Schema: schemaFile}
+
+	if !tpl.HasOverrides() {
+		t.Fatal("expected template to have overrides")
+	}
+
+	got, warnings, err := tpl.ApplyTo(base)
+	if err != nil {
+		t.Fatalf("ApplyTo returned error: %v", err)
+	}
+	if got.Model != "other" || got.PromptOverride != "Be brief." || !got.HasSchema() || got.OutputFmt != "plain" {
+		t.Fatalf("unexpected config copy: %+v", got)
+	}
+	if got.Temperature == nil || *got.Temperature != MaxTemperature || len(warnings) != 1 {
+		t.Fatalf("expected clamped temperature with warning, got %v %v", got.Temperature, warnings)
+	}
+	if base.Model != "base" || base.PromptOverride != "" || base.HasSchema() || base.Temperature != nil {
+		t.Fatalf("base config modified: %+v", base)
+	}
+
+	if (Template{Prompt: "x"}).HasOverrides() {
+		t.Fatal("expected plain template without overrides")
+	}
+}
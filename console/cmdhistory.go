@@ -1,13 +1,43 @@
 package console
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// maxHistoryEntries caps the number of remembered prompts and commands.
+const maxHistoryEntries = 1000
+
 type commandHistory struct {
 	entries []string
 	index   int
+	path    string // history file, empty if the history is not persisted
 }
 
 var cmdHistory = &commandHistory{}
 
-// AddCommand adds a command to the history.
+// LoadHistory reads the input history from a file and appends all further
+// entries to it. The file holds one JSON string per line, so multiline
+// prompts are kept intact. Duplicates and entries beyond the size cap are
+// removed from the file while loading.
+//
+// Parameters:
+//
+//	path (string) - the history file, a missing file is created on demand
+//
+// Returns:
+//
+//	error - error if the file cannot be read or compacted
+func LoadHistory(path string) error {
+	return cmdHistory.load(path)
+}
+
+// AddCommand adds a command or prompt to the history. If the history is
+// persisted, the entry is appended to the history file.
 //
 // Parameters:
 //
@@ -15,9 +45,9 @@ var cmdHistory = &commandHistory{}
 //
 // Returns:
 //
-//	none
-func AddCommand(cmd string) {
-	cmdHistory.add(cmd)
+//	error - error if the history file cannot be written
+func AddCommand(cmd string) error {
+	return cmdHistory.add(cmd)
 }
 
 // PrevCommand returns the previous command in the history.
@@ -46,6 +76,69 @@ func NextCommand() string {
 	return cmdHistory.next()
 }
 
+// load reads the history file and rewrites it if it contains duplicates,
+// too many or unreadable entries.
+//
+// Parameters:
+//
+//	path (string) - the history file
+//
+// Returns:
+//
+//	error - error if the file cannot be read or written
+func (h *commandHistory) load(path string) error {
+	h.path = path
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read input history failed: %w", err)
+	}
+
+	lines := 0
+	for line := range bytes.SplitSeq(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		lines++
+		var entry string
+		if json.Unmarshal(line, &entry) == nil && entry != "" {
+			h.push(entry)
+		}
+	}
+	h.index = h.len()
+
+	if lines != h.len() {
+		return h.save()
+	}
+	return nil
+}
+
+// save replaces the history file with the current entries.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	error - error if the file cannot be written
+func (h *commandHistory) save() error {
+	var buf bytes.Buffer
+	for _, entry := range h.entries {
+		buf.Write(encodeEntry(entry))
+	}
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("write input history failed: %w", err)
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		return fmt.Errorf("write input history failed: %w", err)
+	}
+	return nil
+}
+
 // add adds a command to the history.
 //
 // Parameters:
@@ -54,16 +147,65 @@ func NextCommand() string {
 //
 // Returns:
 //
+//	error - error if the history file cannot be written
+func (h *commandHistory) add(cmd string) error {
+	if strings.TrimSpace(cmd) == "" {
+		return nil
+	}
+	h.push(cmd)
+	h.index = h.len()
+
+	if h.path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("open input history failed: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(encodeEntry(cmd)); err != nil {
+		return fmt.Errorf("write input history failed: %w", err)
+	}
+	return nil
+}
+
+// push appends an entry, removes an older copy of it and drops the oldest
+// entries beyond the size cap.
+//
+// Parameters:
+//
+//	entry (string) - the entry to append
+//
+// Returns:
+//
 //	none
-func (h *commandHistory) add(cmd string) {
-	if cmd == "" {
-		return
+func (h *commandHistory) push(entry string) {
+	if i := slices.Index(h.entries, entry); i >= 0 {
+		h.entries = slices.Delete(h.entries, i, i+1)
 	}
-	// Optional: avoid duplicate entries
-	if h.len() == 0 || h.entries[h.len()-1] != cmd {
-		h.entries = append(h.entries, cmd)
+	h.entries = append(h.entries, entry)
+	if over := h.len() - maxHistoryEntries; over > 0 {
+		h.entries = slices.Delete(h.entries, 0, over)
 	}
-	h.index = h.len()
+}
+
+// search returns the newest entry before an index that contains the query.
+//
+// Parameters:
+//
+//	query (string) - the text to find
+//	before (int)   - the search starts with the entry before this index
+//
+// Returns:
+//
+//	int - index of the matching entry, -1 if there is none
+func (h *commandHistory) search(query string, before int) int {
+	for i := min(before, h.len()) - 1; i >= 0; i-- {
+		if strings.Contains(h.entries[i], query) {
+			return i
+		}
+	}
+	return -1
 }
 
 // prev returns the previous command in the history.
@@ -118,3 +260,17 @@ func (h *commandHistory) next() string {
 func (h *commandHistory) len() int {
 	return len(h.entries)
 }
+
+// encodeEntry encodes a history entry as a JSON line.
+//
+// Parameters:
+//
+//	entry (string) - the entry
+//
+// Returns:
+//
+//	[]byte - the JSON string with a trailing line break
+func encodeEntry(entry string) []byte {
+	data, _ := json.Marshal(entry) // a string cannot fail
+	return append(data, '\n')
+}
@@ -1,6 +1,12 @@
 package console
 
-import "testing"
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
 
 func TestCommandHistory_Internal(t *testing.T) {
 	tests := []struct {
@@ -82,3 +88,93 @@ func TestCommandHistory_Global(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandHistory_LoadCompactsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input_history.jsonl")
+	content := `"/help"` + "\n" + `"first\nsecond"` + "\n" + `not json` + "\n" + `"/help"` + "\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("write history failed: %v", err)
+	}
+
+	h := &commandHistory{}
+	if err := h.load(path); err != nil {
+		t.Fatalf("load() error: %v", err)
+	}
+	want := []string{"first\nsecond", "/help"}
+	if !slices.Equal(h.entries, want) {
+		t.Fatalf("entries = %q, want %q", h.entries, want)
+	}
+	data, _ := os.ReadFile(path)
+	if got := string(data); got != `"first\nsecond"`+"\n"+`"/help"`+"\n" {
+		t.Fatalf("file not compacted:\n%s", got)
+	}
+
+	if err := h.add("/models"); err != nil {
+		t.Fatalf("add() error: %v", err)
+	}
+	reloaded := &commandHistory{}
+	if err := reloaded.load(path); err != nil {
+		t.Fatalf("reload error: %v", err)
+	}
+	if !slices.Equal(reloaded.entries, append(want, "/models")) {
+		t.Fatalf("reloaded entries = %q", reloaded.entries)
+	}
+	if reloaded.prev() != "/models" {
+		t.Fatal("expected navigation to start with the newest entry")
+	}
+}
+
+func TestCommandHistory_MissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input_history.jsonl")
+	h := &commandHistory{}
+	if err := h.load(path); err != nil {
+		t.Fatalf("load() error: %v", err)
+	}
+	if err := h.add("hello"); err != nil {
+		t.Fatalf("add() error: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != `"hello"`+"\n" {
+		t.Fatalf("unexpected file content %q", data)
+	}
+}
+
+func TestCommandHistory_DedupeAndCap(t *testing.T) {
+	h := &commandHistory{}
+	for i := range maxHistoryEntries + 5 {
+		h.add(fmt.Sprintf("entry %d", i))
+	}
+	h.add("entry 10")
+	h.add("  ")
+
+	if h.len() != maxHistoryEntries {
+		t.Fatalf("len = %d, want %d", h.len(), maxHistoryEntries)
+	}
+	if h.entries[0] != "entry 5" || h.entries[h.len()-1] != "entry 10" {
+		t.Fatalf("unexpected first/last entries %q %q", h.entries[0], h.entries[h.len()-1])
+	}
+	if slices.Index(h.entries, "entry 10") != h.len()-1 {
+		t.Fatal("older duplicate was not removed")
+	}
+}
+
+func TestCommandHistory_Search(t *testing.T) {
+	h := &commandHistory{entries: []string{"/set temperature=0.2", "explain\nthe code", "/models", "explain this"}}
+
+	tests := []struct {
+		query  string
+		before int
+		want   int
+	}{
+		{"explain", 4, 3},
+		{"explain", 3, 1},
+		{"the code", 4, 1},
+		{"explain", 1, -1},
+		{"temp", 99, 0},
+		{"missing", 4, -1},
+	}
+	for _, tt := range tests {
+		if got := h.search(tt.query, tt.before); got != tt.want {
+			t.Errorf("search(%q, %d) = %d, want %d", tt.query, tt.before, got, tt.want)
+		}
+	}
+}
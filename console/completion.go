@@ -0,0 +1,235 @@
+package console
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// maxCompletionRows limits the candidate list shown below the prompt.
+const maxCompletionRows = 8
+
+// Completer returns the word before the cursor that is completed and the
+// candidates that can replace it.
+type Completer func(line string) (string, []string)
+
+var completer Completer
+
+// completionState tracks a Tab completion with several candidates, so that
+// repeated Tab presses cycle through them.
+type completionState struct {
+	candidates []string
+	index      int // selected candidate, -1 before cycling
+	start      int // rune position of the completed word
+	listed     bool
+}
+
+// SetCompleter registers the function used for Tab completion.
+//
+// Parameters:
+//
+//	c (Completer) - the completion function, nil disables completion
+//
+// Returns:
+//
+//	none
+func SetCompleter(c Completer) {
+	completer = c
+}
+
+// complete handles a Tab key press. A single candidate is inserted, several
+// candidates are completed to their common prefix and listed below the
+// prompt; further Tab presses cycle through the list.
+//
+// Parameters:
+//
+//	line ([]rune)   - the current input line
+//	cursorPos (int) - the cursor position
+//
+// Returns:
+//
+//	[]rune - the completed line
+//	int    - the new cursor position
+func (c *completionState) complete(line []rune, cursorPos int) ([]rune, int) {
+	if len(c.candidates) > 0 {
+		c.index = (c.index + 1) % len(c.candidates)
+		line, cursorPos = replaceWord(line, c.start, cursorPos, c.candidates[c.index])
+		return line, cursorPos
+	}
+
+	if completer == nil {
+		return line, cursorPos
+	}
+	word, candidates := completer(string(line[:cursorPos]))
+	if len(candidates) == 0 {
+		fmt.Print("\a")
+		return line, cursorPos
+	}
+	start := max(cursorPos-len([]rune(word)), 0)
+
+	if len(candidates) == 1 {
+		text := candidates[0]
+		if !strings.HasSuffix(text, "/") && !strings.HasSuffix(text, "=") {
+			text += " "
+		}
+		return replaceWord(line, start, cursorPos, text)
+	}
+
+	if prefix := commonPrefix(candidates); len([]rune(prefix)) >= len([]rune(word)) {
+		line, cursorPos = replaceWord(line, start, cursorPos, prefix)
+	}
+	c.candidates = candidates
+	c.index = -1
+	c.start = start
+	c.listed = true
+	return line, cursorPos
+}
+
+// reset ends a completion. The caller removes the candidate list.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (c *completionState) reset() {
+	*c = completionState{}
+}
+
+// list returns the rows of the candidate list shown below the prompt.
+//
+// Parameters:
+//
+//	width (int) - the terminal width
+//
+// Returns:
+//
+//	[]string - the rows, nil without a list
+func (c *completionState) list(width int) []string {
+	if !c.listed {
+		return nil
+	}
+	return formatCandidates(c.candidates, c.index, width)
+}
+
+// replaceWord replaces the runes between start and the cursor.
+//
+// Parameters:
+//
+//	line ([]rune)   - the current input line
+//	start (int)     - start of the word
+//	cursorPos (int) - end of the word
+//	text (string)   - the replacement
+//
+// Returns:
+//
+//	[]rune - the new line
+//	int    - the cursor position after the replacement
+func replaceWord(line []rune, start, cursorPos int, text string) ([]rune, int) {
+	repl := []rune(text)
+	newLine := make([]rune, 0, len(line)-(cursorPos-start)+len(repl))
+	newLine = append(newLine, line[:start]...)
+	newLine = append(newLine, repl...)
+	newLine = append(newLine, line[cursorPos:]...)
+	return newLine, start + len(repl)
+}
+
+// commonPrefix returns the longest common prefix of all candidates.
+//
+// Parameters:
+//
+//	candidates ([]string) - the candidates
+//
+// Returns:
+//
+//	string - the common prefix
+func commonPrefix(candidates []string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	prefix := []rune(candidates[0])
+	for _, c := range candidates[1:] {
+		r := []rune(c)
+		n := 0
+		for n < len(prefix) && n < len(r) && prefix[n] == r[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return string(prefix)
+}
+
+// formatCandidates arranges the candidates in columns that fit the
+// terminal width. Paths are shown with their last element only.
+//
+// Parameters:
+//
+//	candidates ([]string) - the candidates
+//	selected (int)        - index of the highlighted candidate, -1 for none
+//	width (int)           - the terminal width
+//
+// Returns:
+//
+//	[]string - the rows of the list
+func formatCandidates(candidates []string, selected int, width int) []string {
+	labels := make([]string, len(candidates))
+	colWidth := 0
+	for i, c := range candidates {
+		labels[i] = candidateLabel(c)
+		colWidth = max(colWidth, runewidth.StringWidth(labels[i])+2)
+	}
+	cols := max(1, width/colWidth)
+	total := (len(labels) + cols - 1) / cols
+
+	// long lists show a window around the selected candidate
+	first, visible := 0, total
+	if total > maxCompletionRows {
+		visible = maxCompletionRows - 1 // the last row counts the rest
+		if selected >= 0 && selected/cols >= visible {
+			first = selected/cols - visible + 1
+		}
+	}
+
+	var rows []string
+	shown := 0
+	for r := first; r < first+visible; r++ {
+		var sb strings.Builder
+		for col := range cols {
+			i := r*cols + col
+			if i >= len(labels) {
+				break
+			}
+			cell := runewidth.FillRight(labels[i], colWidth-2)
+			if i == selected {
+				cell = Reverse + cell + ColorReset
+			}
+			sb.WriteString(cell + "  ")
+			shown++
+		}
+		rows = append(rows, strings.TrimRight(sb.String(), " "))
+	}
+	if hidden := len(labels) - shown; hidden > 0 {
+		rows = append(rows, paint(stdoutColors, theme.Hint, fmt.Sprintf("(%d more)", hidden)))
+	}
+	return rows
+}
+
+// candidateLabel shortens a path candidate to its last element.
+//
+// Parameters:
+//
+//	c (string) - the candidate
+//
+// Returns:
+//
+//	string - the label shown in the list
+func candidateLabel(c string) string {
+	trimmed := strings.TrimSuffix(c, "/")
+	if i := strings.LastIndex(trimmed, "/"); i > 0 {
+		return c[i+1:]
+	}
+	return c
+}
@@ -0,0 +1,98 @@
+package console
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommonPrefix(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"/models"}, "/models"},
+		{[]string{"/redo", "/retry"}, "/re"},
+		{[]string{"gemma3:4b", "qwen"}, ""},
+		{[]string{"größe", "größer"}, "größe"},
+	}
+	for _, tt := range tests {
+		if got := commonPrefix(tt.in); got != tt.want {
+			t.Errorf("commonPrefix(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCompletionState_Complete(t *testing.T) {
+	prev := completer
+	t.Cleanup(func() { completer = prev })
+	completer = func(line string) (string, []string) {
+		word := line[strings.LastIndex(line, " ")+1:]
+		switch word {
+		case "/mo":
+			return word, []string{"/models"}
+		case "/re":
+			return word, []string{"/redo", "/retry"}
+		case "do":
+			return word, []string{"docs/"}
+		}
+		return word, nil
+	}
+
+	var c completionState
+	line, pos := c.complete([]rune("/mo"), 3)
+	if string(line) != "/models " || pos != 8 || c.listed {
+		t.Fatalf("single candidate: got %q at %d", string(line), pos)
+	}
+
+	line, pos = c.complete([]rune("/file do"), 8)
+	if string(line) != "/file docs/" || pos != 11 {
+		t.Fatalf("directory candidate: got %q at %d", string(line), pos)
+	}
+
+	// several candidates: common prefix, then cycling
+	line, pos = c.complete([]rune("/re"), 3)
+	if string(line) != "/re" || !c.listed || c.index != -1 {
+		t.Fatalf("several candidates: got %q, state %+v", string(line), c)
+	}
+	if rows := c.list(80); len(rows) != 1 || !strings.Contains(rows[0], "/retry") {
+		t.Fatalf("candidate list: %q", rows)
+	}
+	line, pos = c.complete(line, pos)
+	if string(line) != "/redo" {
+		t.Fatalf("first cycle: got %q", string(line))
+	}
+	line, pos = c.complete(line, pos)
+	if string(line) != "/retry" || pos != 6 {
+		t.Fatalf("second cycle: got %q at %d", string(line), pos)
+	}
+	line, _ = c.complete(line, pos)
+	if string(line) != "/redo" {
+		t.Fatalf("wrap around: got %q", string(line))
+	}
+
+	c.reset()
+	if c.listed || c.candidates != nil || c.list(80) != nil {
+		t.Fatalf("reset kept state: %+v", c)
+	}
+}
+
+func TestFormatCandidates(t *testing.T) {
+	rows := formatCandidates([]string{"docs/a.md", "docs/b.md", "docs/sub/"}, 1, 80)
+	if len(rows) != 1 || !strings.Contains(rows[0], "a.md") || !strings.Contains(rows[0], Reverse+"b.md") || !strings.Contains(rows[0], "sub/") {
+		t.Fatalf("unexpected rows: %q", rows)
+	}
+
+	many := make([]string, 40)
+	for i := range many {
+		many[i] = strings.Repeat("x", 30) + string(rune('a'+i%26))
+	}
+	rows = formatCandidates(many, -1, 40) // one column
+	if len(rows) != maxCompletionRows || !strings.Contains(rows[len(rows)-1], "(33 more)") {
+		t.Fatalf("long list: got %d rows, last %q", len(rows), rows[len(rows)-1])
+	}
+	rows = formatCandidates(many, 20, 40)
+	if !strings.Contains(strings.Join(rows, "\n"), Reverse) {
+		t.Fatalf("selected candidate not visible: %q", rows)
+	}
+}
@@ -3,10 +3,10 @@ package console
 // Constants
 const (
 	Prompt     string = ">>> "
-	ShadowText string = Gray256 + "Send a message (/? for help)" + ColorReset
-	InfoPrefix string = Green + Bold + "✓" + ColorReset
-	WarnPrefix string = BrightYellow + Bold + "!" + ColorReset
-	ErrPrefix  string = Bold + "×" + Regular
+	ShadowText string = "Send a message (/? for help)"
+	InfoSymbol string = "✓"
+	WarnSymbol string = "!"
+	ErrSymbol  string = "×"
 )
 
 const (
@@ -15,10 +15,17 @@ const (
 	CursorBack      string = "\033[D"
 	CursorForward   string = "\033[C"
 	CursorToColumn  string = "\033[%dG" // requires a parameter
+	CursorUp        string = "\033[%dA" // requires a parameter
+	ClearBelow      string = "\033[J"
+	SaveCursor      string = "\0337"
+	RestoreCursor   string = "\0338"
 	DisableLineWrap string = "\033[?7l"
 	EnableLineWrap  string = "\033[?7h"
 	DisableCursor   string = "\033[?25l"
 	EnableCursor    string = "\033[?25h"
+
+	EnableBracketedPaste  string = "\033[?2004h"
+	DisableBracketedPaste string = "\033[?2004l"
 )
 
 const (
@@ -66,14 +73,27 @@ const (
 	Gray256   = "\033[38;5;244m"
 	BgGray256 = "\033[48;5;244m"
 
+	DarkGray256   = "\033[38;5;240m"
+	BgDarkGray256 = "\033[48;5;240m"
+
 	LightGray256   = "\033[38;5;252m"
 	BgLightGray256 = "\033[48;5;252m"
 
+	Pink256   = "\033[38;5;204m"
+	Orange256 = "\033[38;5;215m"
+	Tan256    = "\033[38;5;180m"
+	Green256  = "\033[38;5;114m"
+	Cyan256   = "\033[38;5;80m"
+	Blue256   = "\033[38;5;75m"
+
 	// Style
 	Regular     string = "\033[22m"
 	Bold        string = "\033[1m"
+	Dim         string = "\033[2m"
 	Italics     string = "\033[3m"
+	Underline   string = "\033[4m"
 	BoldItalics string = "\033[1;3m"
+	Reverse     string = "\033[7m"
 
 	// Reset
 	ColorReset string = "\033[0m"
@@ -0,0 +1,359 @@
+package console
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// maxKills limits the number of texts in the kill ring.
+const maxKills = 10
+
+// editAction is the kind of the previous edit, so that consecutive kills
+// are collected in one kill ring entry and Alt+Y can replace a yank.
+type editAction int
+
+const (
+	actionNone editAction = iota
+	actionKill
+	actionYank
+)
+
+// position is a cursor position in the editor.
+type position struct {
+	row int
+	col int
+}
+
+// killRing keeps the killed texts for Ctrl+Y and Alt+Y. It is shared by all
+// inputs, so text killed in one prompt can be yanked into the next one.
+type killRing struct {
+	entries []string
+	index   int // entry of the last yank
+}
+
+var kills = &killRing{}
+
+// edit applies a cursor movement, an editing key or a printable character.
+//
+// Parameters:
+//
+//	k (key) - the pressed key
+//
+// Returns:
+//
+//	bool - false if the key is not an editing key
+func (e *editor) edit(k key) bool {
+	last := e.lastAction
+	e.lastAction = actionNone
+
+	cursor := position{e.row, e.col}
+	lineEnd := position{e.row, len(e.lines[e.row])}
+
+	switch k {
+	case 1, keyHome: // Ctrl+A
+		e.col = 0
+	case 5, keyEnd: // Ctrl+E
+		e.col = lineEnd.col
+	case keyLeft:
+		e.col = prevGrapheme(e.lines[e.row], e.col)
+	case keyRight:
+		e.col = nextGrapheme(e.lines[e.row], e.col)
+	case keyWordLeft: // Alt+B
+		e.moveTo(e.wordStart(cursor, isWordRune))
+	case keyWordRight: // Alt+F
+		e.moveTo(e.wordEnd(cursor))
+	case keyUp:
+		if !e.moveRow(true) {
+			if cmd := PrevCommand(); cmd != "" {
+				e.setText(cmd)
+			}
+		}
+	case keyDown:
+		if !e.moveRow(false) {
+			e.setText(NextCommand())
+		}
+	case 127: // Backspace
+		e.backspace()
+	case keyDelete:
+		if next := e.nextPosition(cursor); next != cursor {
+			e.deleteRange(cursor, next)
+		}
+	case 23: // Ctrl+W
+		e.kill(e.wordStart(cursor, isNotSpace), cursor, last, true)
+	case keyDeleteWord: // Alt+D
+		e.kill(cursor, e.wordEnd(cursor), last, false)
+	case 11: // Ctrl+K
+		if cursor == lineEnd {
+			lineEnd = e.nextPosition(cursor) // kill the line break
+		}
+		e.kill(cursor, lineEnd, last, false)
+	case 21: // Ctrl+U
+		e.kill(position{e.row, 0}, cursor, last, true)
+	case 25: // Ctrl+Y
+		if text, ok := kills.yank(); ok {
+			e.yank(text)
+		}
+	case keyYankPop: // Alt+Y
+		if last == actionYank {
+			e.deleteRange(e.yankStart, cursor)
+			e.yank(kills.rotate())
+		}
+	default:
+		if k < 32 {
+			return false
+		}
+		e.insert(rune(k))
+	}
+	return true
+}
+
+// moveTo moves the cursor.
+//
+// Parameters:
+//
+//	p (position) - the new cursor position
+//
+// Returns:
+//
+//	none
+func (e *editor) moveTo(p position) {
+	e.row, e.col = p.row, p.col
+}
+
+// insertText inserts text at the cursor and moves the cursor behind it.
+//
+// Parameters:
+//
+//	text (string) - the text, may contain line breaks
+//
+// Returns:
+//
+//	none
+func (e *editor) insertText(text string) {
+	line := e.lines[e.row]
+	parts := strings.Split(text, "\n")
+	newLines := make([][]rune, len(parts))
+	for i, part := range parts {
+		newLines[i] = []rune(part)
+	}
+
+	last := len(newLines) - 1
+	newLines[0] = slices.Concat(line[:e.col], newLines[0])
+	col := len(newLines[last])
+	newLines[last] = slices.Concat(newLines[last], line[e.col:])
+
+	e.lines = slices.Replace(e.lines, e.row, e.row+1, newLines...)
+	e.row += last
+	e.col = col
+}
+
+// deleteRange removes the text between two positions and moves the cursor
+// to the start of the range.
+//
+// Parameters:
+//
+//	from (position) - start of the range
+//	to (position)   - end of the range
+//
+// Returns:
+//
+//	string - the removed text
+func (e *editor) deleteRange(from, to position) string {
+	removed := make([]string, 0, to.row-from.row+1)
+	for r := from.row; r <= to.row; r++ {
+		line := e.lines[r]
+		start, end := 0, len(line)
+		if r == from.row {
+			start = from.col
+		}
+		if r == to.row {
+			end = to.col
+		}
+		removed = append(removed, string(line[start:end]))
+	}
+
+	e.lines[from.row] = slices.Concat(e.lines[from.row][:from.col], e.lines[to.row][to.col:])
+	e.lines = slices.Delete(e.lines, from.row+1, to.row+1)
+	e.moveTo(from)
+	return strings.Join(removed, "\n")
+}
+
+// kill removes a range and stores it in the kill ring. A kill directly
+// after another kill extends the last ring entry.
+//
+// Parameters:
+//
+//	from (position)   - start of the range
+//	to (position)     - end of the range
+//	last (editAction) - the action of the previous key
+//	backward (bool)   - the text was killed backwards from the cursor
+//
+// Returns:
+//
+//	none
+func (e *editor) kill(from, to position, last editAction, backward bool) {
+	if from == to {
+		e.lastAction = last // keep collecting
+		return
+	}
+	kills.add(e.deleteRange(from, to), last == actionKill, backward)
+	e.lastAction = actionKill
+}
+
+// yank inserts a text of the kill ring and remembers its start for Alt+Y.
+//
+// Parameters:
+//
+//	text (string) - the text
+//
+// Returns:
+//
+//	none
+func (e *editor) yank(text string) {
+	e.yankStart = position{e.row, e.col}
+	e.insertText(text)
+	e.lastAction = actionYank
+}
+
+// nextPosition returns the position after the character (grapheme cluster)
+// at p, which is the start of the next line at the end of a line.
+//
+// Parameters:
+//
+//	p (position) - the position
+//
+// Returns:
+//
+//	position - the next position, p itself at the end of the input
+func (e *editor) nextPosition(p position) position {
+	switch {
+	case p.col < len(e.lines[p.row]):
+		return position{p.row, nextGrapheme(e.lines[p.row], p.col)}
+	case p.row < len(e.lines)-1:
+		return position{p.row + 1, 0}
+	}
+	return p
+}
+
+// wordStart returns the start of the word before p. At the start of a line
+// the search continues at the end of the previous line.
+//
+// Parameters:
+//
+//	p (position)             - the position
+//	inWord (func(rune) bool) - reports whether a rune belongs to a word
+//
+// Returns:
+//
+//	position - the start of the word
+func (e *editor) wordStart(p position, inWord func(rune) bool) position {
+	if p.col == 0 && p.row > 0 {
+		p = position{p.row - 1, len(e.lines[p.row-1])}
+	}
+	line := e.lines[p.row]
+	for p.col > 0 && !inWord(line[p.col-1]) {
+		p.col--
+	}
+	for p.col > 0 && inWord(line[p.col-1]) {
+		p.col--
+	}
+	p.col = graphemeStart(line, p.col)
+	return p
+}
+
+// wordEnd returns the end of the word after p. At the end of a line the
+// search continues at the start of the next line.
+//
+// Parameters:
+//
+//	p (position) - the position
+//
+// Returns:
+//
+//	position - the end of the word
+func (e *editor) wordEnd(p position) position {
+	if p.col == len(e.lines[p.row]) && p.row < len(e.lines)-1 {
+		p = position{p.row + 1, 0}
+	}
+	line := e.lines[p.row]
+	for p.col < len(line) && !isWordRune(line[p.col]) {
+		p.col++
+	}
+	for p.col < len(line) && isWordRune(line[p.col]) {
+		p.col++
+	}
+	p.col = graphemeEnd(line, p.col)
+	return p
+}
+
+// isWordRune reports whether a rune is part of a word for Alt+B, Alt+F and
+// Alt+D. Combining marks belong to the letter before them.
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.M, r)
+}
+
+// isNotSpace reports whether a rune is part of a word for Ctrl+W, which
+// deletes up to the previous whitespace.
+func isNotSpace(r rune) bool {
+	return !unicode.IsSpace(r)
+}
+
+// add stores a killed text.
+//
+// Parameters:
+//
+//	text (string)   - the killed text
+//	extend (bool)   - add the text to the last entry instead of a new one
+//	backward (bool) - the text was killed backwards and is prepended
+//
+// Returns:
+//
+//	none
+func (k *killRing) add(text string, extend, backward bool) {
+	if extend && len(k.entries) > 0 {
+		last := len(k.entries) - 1
+		if backward {
+			k.entries[last] = text + k.entries[last]
+		} else {
+			k.entries[last] += text
+		}
+		return
+	}
+	k.entries = append(k.entries, text)
+	if over := len(k.entries) - maxKills; over > 0 {
+		k.entries = slices.Delete(k.entries, 0, over)
+	}
+}
+
+// yank returns the latest killed text.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - the text
+//	bool   - false if nothing has been killed
+func (k *killRing) yank() (string, bool) {
+	if len(k.entries) == 0 {
+		return "", false
+	}
+	k.index = len(k.entries) - 1
+	return k.entries[k.index], true
+}
+
+// rotate returns the killed text before the one of the last yank.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - the text
+func (k *killRing) rotate() string {
+	k.index = (k.index - 1 + len(k.entries)) % len(k.entries)
+	return k.entries[k.index]
+}
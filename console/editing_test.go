@@ -0,0 +1,185 @@
+package console
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func newTestEditor(text string, row, col int) *editor {
+	e := newEditor()
+	e.setText(text)
+	e.row, e.col = row, col
+	return e
+}
+
+func TestEditor_MovementKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		row     int
+		col     int
+		keys    []key
+		wantRow int
+		wantCol int
+	}{
+		{"line start", "hello world", 0, 6, []key{1}, 0, 0},
+		{"line end", "hello world", 0, 2, []key{5}, 0, 11},
+		{"home and end", "one\ntwo", 1, 1, []key{keyEnd, keyHome}, 1, 0},
+		{"word left", "hello big world", 0, 15, []key{keyWordLeft, keyWordLeft}, 0, 6},
+		{"word left across lines", "hello\nworld", 1, 0, []key{keyWordLeft}, 0, 0},
+		{"word right", "hello, big world", 0, 0, []key{keyWordRight, keyWordRight}, 0, 10},
+		{"word right across lines", "hello\nworld", 0, 5, []key{keyWordRight}, 1, 5},
+		{"up keeps terminal column", "first line\nabcdef", 1, 6, []key{keyUp}, 0, 2},
+		{"down clamps column", "first line\nab", 0, 8, []key{keyDown}, 1, 2},
+		{"left stops at line start", "ab", 0, 1, []key{keyLeft, keyLeft}, 0, 0},
+		{"right stops at line end", "ab", 0, 1, []key{keyRight, keyRight}, 0, 2},
+		{"right over emoji sequence", "a👨‍👩‍👧b", 0, 1, []key{keyRight}, 0, 6},
+		{"left over flag", "🇩🇪x", 0, 2, []key{keyLeft}, 0, 0},
+		{"left over cjk", "中国", 0, 2, []key{keyLeft}, 0, 1},
+		{"word right with combining accent", "cafe\u0301 bar", 0, 0, []key{keyWordRight}, 0, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(tt.text, tt.row, tt.col)
+			for _, k := range tt.keys {
+				if !e.edit(k) {
+					t.Fatalf("key %d not handled", k)
+				}
+			}
+			if e.row != tt.wantRow || e.col != tt.wantCol {
+				t.Fatalf("cursor at %d:%d, want %d:%d", e.row, e.col, tt.wantRow, tt.wantCol)
+			}
+			if e.text() != tt.text {
+				t.Fatalf("text changed to %q", e.text())
+			}
+		})
+	}
+}
+
+func TestEditor_DeleteKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		row      int
+		col      int
+		keys     []key
+		wantText string
+		wantKill string
+	}{
+		{"delete char", "abc", 0, 1, []key{keyDelete}, "ac", ""},
+		{"delete joins lines", "ab\ncd", 0, 2, []key{keyDelete}, "abcd", ""},
+		{"ctrl+w", "git commit -m", 0, 10, []key{23}, "git  -m", "commit"},
+		{"ctrl+w twice collects", "git commit -m", 0, 10, []key{23, 23}, " -m", "git commit"},
+		{"alt+d", "hello big world", 0, 5, []key{keyDeleteWord}, "hello world", " big"},
+		{"ctrl+k", "hello world", 0, 5, []key{11}, "hello", " world"},
+		{"ctrl+k at line end joins", "ab\ncd", 0, 2, []key{11}, "abcd", "\n"},
+		{"ctrl+k twice collects", "ab\ncd", 0, 1, []key{11, 11}, "acd", "b\n"},
+		{"ctrl+u", "hello world", 0, 6, []key{21}, "world", "hello "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kills = &killRing{}
+			e := newTestEditor(tt.text, tt.row, tt.col)
+			for _, k := range tt.keys {
+				e.edit(k)
+			}
+			if e.text() != tt.wantText {
+				t.Fatalf("text = %q, want %q", e.text(), tt.wantText)
+			}
+			got, _ := kills.yank()
+			if got != tt.wantKill {
+				t.Fatalf("kill ring = %q, want %q", got, tt.wantKill)
+			}
+		})
+	}
+}
+
+func TestEditor_YankRing(t *testing.T) {
+	kills = &killRing{}
+	e := newTestEditor("one two", 0, 7)
+	e.edit(23)     // kill "two"
+	e.edit('x')    // break the kill sequence
+	e.edit(23)     // kill "x"
+	e.edit(keyEnd) // break the kill sequence
+	e.edit(21)     // kill "one "
+
+	e.edit(25) // Ctrl+Y
+	if e.text() != "one " {
+		t.Fatalf("yank = %q", e.text())
+	}
+	for _, want := range []string{"x", "two", "one "} {
+		e.edit(keyYankPop)
+		if e.text() != want || e.col != len(want) {
+			t.Fatalf("yank-pop = %q at %d, want %q", e.text(), e.col, want)
+		}
+	}
+
+	e.edit(keyLeft)
+	e.edit(keyYankPop) // only directly after a yank
+	if e.text() != "one " {
+		t.Fatalf("yank-pop after move changed text to %q", e.text())
+	}
+
+	e = newTestEditor("ab", 0, 1)
+	kills.add("x\ny", false, false)
+	e.edit(25)
+	if e.text() != "ax\nyb" || e.row != 1 || e.col != 1 {
+		t.Fatalf("multiline yank = %q at %d:%d", e.text(), e.row, e.col)
+	}
+}
+
+func TestEditor_UpDownHistory(t *testing.T) {
+	prev := cmdHistory
+	t.Cleanup(func() { cmdHistory = prev })
+	cmdHistory = &commandHistory{entries: []string{"older", "first\nsecond"}}
+	cmdHistory.index = cmdHistory.len()
+
+	e := newEditor()
+	e.edit(keyUp)
+	if e.text() != "first\nsecond" || e.row != 1 {
+		t.Fatalf("Up recalled %q at row %d", e.text(), e.row)
+	}
+	e.edit(keyUp) // moves to the first line
+	if e.text() != "first\nsecond" || e.row != 0 {
+		t.Fatalf("Up in entry: %q at row %d", e.text(), e.row)
+	}
+	e.edit(keyUp)
+	if e.text() != "older" {
+		t.Fatalf("Up on first line recalled %q", e.text())
+	}
+	e.edit(keyDown)
+	if e.text() != "first\nsecond" {
+		t.Fatalf("Down recalled %q", e.text())
+	}
+	e.edit(keyDown)
+	if e.text() != "" {
+		t.Fatalf("Down past newest = %q", e.text())
+	}
+}
+
+func TestReadEscapeSequence(t *testing.T) {
+	tests := []struct {
+		seq  string
+		want key
+	}{
+		{"A", keyUp},
+		{"D", keyLeft},
+		{"1;5C", keyWordRight},
+		{"1;3D", keyWordLeft},
+		{"H", keyHome},
+		{"F", keyEnd},
+		{"1~", keyHome},
+		{"4~", keyEnd},
+		{"3~", keyDelete},
+		{"5~", keyUnknown},
+	}
+	for _, tt := range tests {
+		reader := bufio.NewReader(strings.NewReader(tt.seq))
+		if got := readEscapeSequence(reader); got != tt.want {
+			t.Errorf("readEscapeSequence(%q) = %d, want %d", tt.seq, got, tt.want)
+		}
+	}
+}
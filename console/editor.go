@@ -0,0 +1,591 @@
+package console
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// editor is the input buffer of the raw mode prompt. It keeps all lines of
+// the input, so that the whole input can be redrawn after an edit, a
+// history recall, a terminal resize or during a reverse search. Lines
+// longer than the terminal are soft-wrapped: they are shown on several
+// terminal rows but stay one line of the input.
+type editor struct {
+	lines      [][]rune
+	row        int      // line of the cursor
+	col        int      // rune position of the cursor in its line
+	width      int      // terminal width used for soft-wrapping
+	screenRow  int      // terminal row of the cursor relative to the first row
+	footer     []string // rows shown below the input, e.g. completions
+	search     *historySearch
+	vi         *viState   // nil without vi mode
+	lastAction editAction // kill or yank of the previous key
+	yankStart  position   // start of the text inserted by the last yank
+}
+
+// historySearch is the state of an incremental reverse history search.
+type historySearch struct {
+	query  []rune
+	index  int // matching history entry, -1 if there is none
+	failed bool
+}
+
+// newEditor returns an editor with an empty input.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	*editor - the editor
+func newEditor() *editor {
+	return &editor{lines: [][]rune{{}}, width: 80}
+}
+
+// text returns the input with its lines joined by line breaks.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - the input
+func (e *editor) text() string {
+	lines := make([]string, len(e.lines))
+	for i, line := range e.lines {
+		lines[i] = string(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isEmpty reports whether nothing has been entered.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	bool - true if the input is empty
+func (e *editor) isEmpty() bool {
+	return len(e.lines) == 1 && len(e.lines[0]) == 0
+}
+
+// setText replaces the input and moves the cursor to its end.
+//
+// Parameters:
+//
+//	text (string) - the new input, may contain line breaks
+//
+// Returns:
+//
+//	none
+func (e *editor) setText(text string) {
+	e.lines = nil
+	for line := range strings.SplitSeq(text, "\n") {
+		e.lines = append(e.lines, []rune(line))
+	}
+	e.moveToEnd()
+}
+
+// command returns the input if it is a single command line. Commands start
+// with "/" or "!" (shell command).
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - the trimmed command line
+//	bool   - true if the input is a command
+func (e *editor) command() (string, bool) {
+	line := strings.TrimSpace(string(e.lines[0]))
+	if len(e.lines) == 1 && isCommandText(line) {
+		return line, true
+	}
+	return "", false
+}
+
+// isCommandText reports whether trimmed input is a command: "/cmd" or a
+// shell command "!cmd".
+//
+// Parameters:
+//
+//	text (string) - the trimmed input
+//
+// Returns:
+//
+//	bool - true if the input is a command
+func isCommandText(text string) bool {
+	return strings.HasPrefix(text, "/") || strings.HasPrefix(text, "!")
+}
+
+// moveToEnd moves the cursor to the end of the last line.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (e *editor) moveToEnd() {
+	e.row = len(e.lines) - 1
+	e.col = len(e.lines[e.row])
+}
+
+// insert inserts a character at the cursor.
+//
+// Parameters:
+//
+//	r (rune) - the character
+//
+// Returns:
+//
+//	none
+func (e *editor) insert(r rune) {
+	e.lines[e.row], e.col = insertCharAt(e.lines[e.row], e.col, r)
+}
+
+// backspace deletes the character before the cursor. At the start of a
+// line, the line is joined with the previous one.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (e *editor) backspace() {
+	switch {
+	case e.col > 0:
+		e.lines[e.row], e.col = deleteCharAt(e.lines[e.row], e.col)
+	case e.row > 0:
+		prev := e.lines[e.row-1]
+		e.col = len(prev)
+		e.lines[e.row-1] = append(slices.Clip(prev), e.lines[e.row]...)
+		e.lines = slices.Delete(e.lines, e.row, e.row+1)
+		e.row--
+	}
+}
+
+// newline splits the line at the cursor.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (e *editor) newline() {
+	line := e.lines[e.row]
+	rest := slices.Clone(line[e.col:])
+	e.lines[e.row] = line[:e.col:e.col]
+	e.lines = slices.Insert(e.lines, e.row+1, rest)
+	e.row++
+	e.col = 0
+}
+
+// startSearch begins a reverse history search.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (e *editor) startSearch() {
+	e.search = &historySearch{index: -1}
+}
+
+// searchKey handles a key while the reverse search is active. Ctrl+R finds
+// the next older match, Backspace shortens the query, Ctrl+G and Escape
+// cancel the search and any other key takes the match into the input.
+//
+// Parameters:
+//
+//	k (key)             - the pressed key
+//	h (*commandHistory) - the searched history
+//
+// Returns:
+//
+//	none
+func (e *editor) searchKey(k key, h *commandHistory) {
+	s := e.search
+	switch {
+	case k == 18: // Ctrl+R
+		before := h.len()
+		if s.index >= 0 {
+			before = s.index
+		}
+		s.find(h, before)
+	case k == 127: // Backspace
+		if len(s.query) > 0 {
+			s.query = s.query[:len(s.query)-1]
+		}
+		s.index = -1
+		s.find(h, h.len())
+	case k == 7 || k == keyEscape: // Ctrl+G
+		e.search = nil
+	case k >= 32:
+		s.query = append(s.query, rune(k))
+		before := h.len()
+		if s.index >= 0 {
+			before = s.index + 1 // the current match may still match
+		}
+		s.find(h, before)
+	default:
+		if s.index >= 0 {
+			e.setText(h.entries[s.index])
+			h.index = s.index
+		}
+		e.search = nil
+	}
+}
+
+// find selects the newest entry before an index that contains the query.
+// Without a match the previous match stays selected.
+//
+// Parameters:
+//
+//	h (*commandHistory) - the searched history
+//	before (int)        - the search starts with the entry before this index
+//
+// Returns:
+//
+//	none
+func (s *historySearch) find(h *commandHistory, before int) {
+	if len(s.query) == 0 {
+		s.failed = false
+		return
+	}
+	i := h.search(string(s.query), before)
+	if i < 0 {
+		s.failed = true
+		return
+	}
+	s.index = i
+	s.failed = false
+}
+
+// prompt returns the prefix shown instead of the input prompt.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - the search prompt with the query
+func (s *historySearch) prompt() string {
+	label := "reverse-i-search"
+	if s.failed {
+		label = "failed " + label
+	}
+	return fmt.Sprintf("(%s)`%s': ", label, string(s.query))
+}
+
+// view returns the escape sequences that redraw the input from its first
+// terminal row, and the row the cursor ends up in. During a search, the
+// matching history entry is shown with the query highlighted.
+//
+// Parameters:
+//
+//	h (*commandHistory) - the history of the search
+//
+// Returns:
+//
+//	string - the output for the terminal
+//	int    - the terminal row of the cursor relative to the first row
+func (e *editor) view(h *commandHistory) (string, int) {
+	var sb strings.Builder
+	if e.screenRow > 0 {
+		fmt.Fprintf(&sb, CursorUp, e.screenRow)
+	}
+	sb.WriteString("\r" + ClearBelow)
+
+	prefix, lines, row, col, query := e.prompt(), e.lines, e.row, e.col, ""
+	if s := e.search; s != nil {
+		prefix, row, col, query = s.prompt(), 0, 0, string(s.query)
+		if s.index >= 0 {
+			lines = nil
+			for line := range strings.SplitSeq(h.entries[s.index], "\n") {
+				lines = append(lines, []rune(line))
+			}
+			row, col = matchPosition(lines, s.query)
+		}
+	}
+	indent := runewidth.StringWidth(prefix)
+	if e.search == nil {
+		prefix = Colorize(theme.Prompt, prefix)
+	}
+
+	var rows []string
+	cursorRow, cursorX := 0, indent
+	if e.search == nil && e.isEmpty() {
+		rows = []string{prefix + shadowText()}
+	} else {
+		for i, line := range lines {
+			lineIndent := 0
+			if i == 0 {
+				lineIndent = indent
+			}
+			cursor := -1
+			if i == row {
+				cursor = col
+			}
+			starts := softWrap(line, lineIndent, e.width, cursor)
+			if i == row {
+				r, x := cursorCell(line, starts, lineIndent, col)
+				cursorRow, cursorX = len(rows)+r, x
+			}
+			for j, start := range starts {
+				end := len(line)
+				if j < len(starts)-1 {
+					end = starts[j+1]
+				}
+				text := highlightMatches(displayText(line[start:end]), query)
+				if i == 0 && j == 0 {
+					text = prefix + text
+				}
+				rows = append(rows, text)
+			}
+		}
+	}
+	sb.WriteString(strings.Join(slices.Concat(rows, e.footer), "\r\n"))
+
+	if up := len(rows) + len(e.footer) - 1 - cursorRow; up > 0 {
+		fmt.Fprintf(&sb, CursorUp, up)
+	}
+	fmt.Fprintf(&sb, CursorToColumn, cursorX+1)
+	return sb.String(), cursorRow
+}
+
+// render redraws the input.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (e *editor) render() {
+	out, row := e.view(cmdHistory)
+	fmt.Print(out)
+	e.screenRow = row
+}
+
+// matchPosition returns the position of the first occurrence of the query.
+//
+// Parameters:
+//
+//	lines ([][]rune) - the lines of a history entry
+//	query ([]rune)   - the search query
+//
+// Returns:
+//
+//	int - line of the match
+//	int - rune position of the match in its line
+func matchPosition(lines [][]rune, query []rune) (int, int) {
+	for row, line := range lines {
+		if i := strings.Index(string(line), string(query)); i >= 0 {
+			return row, len([]rune(string(line)[:i]))
+		}
+	}
+	return 0, 0
+}
+
+// highlightMatches shows all occurrences of the query in reverse video.
+//
+// Parameters:
+//
+//	line (string)  - the text
+//	query (string) - the search query, empty for no highlighting
+//
+// Returns:
+//
+//	string - the text with escape sequences around the matches
+func highlightMatches(line, query string) string {
+	if query == "" {
+		return line
+	}
+	return strings.ReplaceAll(line, query, Reverse+query+ColorReset)
+}
+
+// softWrap splits a line into the parts shown on the terminal rows. A
+// character (grapheme cluster) that does not fit into a row moves to the
+// next one. If the
+// cursor is at the end of a line that fills its last row completely, the
+// line gets an empty row for the cursor.
+//
+// Parameters:
+//
+//	line ([]rune) - the line
+//	indent (int)  - the width of the prompt in front of the line
+//	width (int)   - the terminal width
+//	cursor (int)  - the cursor position in the line, -1 on other lines
+//
+// Returns:
+//
+//	[]int - the rune positions at which the rows start, the first is 0
+func softWrap(line []rune, indent, width, cursor int) []int {
+	starts := []int{0}
+	x := indent
+	for i, cluster := range graphemes(line) {
+		w := graphemeWidth(cluster)
+		if x+w > width && x > 0 {
+			starts = append(starts, i)
+			x = 0
+		}
+		x += w
+	}
+	if x >= width && cursor == len(line) {
+		starts = append(starts, len(line))
+	}
+	return starts
+}
+
+// cursorCell returns the terminal row and column of a position in a
+// soft-wrapped line.
+//
+// Parameters:
+//
+//	line ([]rune) - the line
+//	starts ([]int) - the row starts from softWrap
+//	indent (int)   - the width of the prompt in front of the line
+//	col (int)      - the rune position
+//
+// Returns:
+//
+//	int - the row relative to the first row of the line
+//	int - the column, starting at 0
+func cursorCell(line []rune, starts []int, indent, col int) (int, int) {
+	row := 0
+	for i, start := range starts {
+		if start <= col {
+			row = i
+		}
+	}
+	x := visualWidth(line[starts[row]:], col-starts[row])
+	if row == 0 {
+		x += indent
+	}
+	return row, x
+}
+
+// columnAt returns the rune position in a row of a soft-wrapped line that
+// is closest to a terminal column without passing it.
+//
+// Parameters:
+//
+//	line ([]rune)  - the line
+//	starts ([]int) - the row starts from softWrap
+//	indent (int)   - the width of the prompt in front of the line
+//	row (int)      - the row
+//	x (int)        - the terminal column
+//
+// Returns:
+//
+//	int - the rune position
+func columnAt(line []rune, starts []int, indent, row, x int) int {
+	end, last := len(line), row == len(starts)-1
+	if !last {
+		end = starts[row+1] // the row end belongs to the next row
+	}
+	pos := 0
+	if row == 0 {
+		pos = indent
+	}
+	col := starts[row]
+	for i, cluster := range graphemes(line) {
+		if i < starts[row] {
+			continue
+		}
+		next, w := i+utf8.RuneCountInString(cluster), graphemeWidth(cluster)
+		if next > end || (next == end && !last) || pos+w > x {
+			break
+		}
+		pos += w
+		col = next
+	}
+	return col
+}
+
+// moveRow moves the cursor to the terminal row above or below. The rows
+// are those of a soft-wrapped line or the adjacent rows of the previous or
+// next line, and the cursor keeps its terminal column where possible.
+//
+// Parameters:
+//
+//	up (bool) - move up instead of down
+//
+// Returns:
+//
+//	bool - false if the cursor is on the first or last row of the input
+func (e *editor) moveRow(up bool) bool {
+	line, indent := e.lines[e.row], e.indent(e.row)
+	starts := softWrap(line, indent, e.width, e.col)
+	r, x := cursorCell(line, starts, indent, e.col)
+
+	switch {
+	case up && r > 0:
+		r--
+	case !up && r < len(starts)-1:
+		r++
+	case up && e.row > 0:
+		e.row--
+		line, indent = e.lines[e.row], e.indent(e.row)
+		starts = softWrap(line, indent, e.width, -1)
+		r = len(starts) - 1
+	case !up && e.row < len(e.lines)-1:
+		e.row++
+		line, indent = e.lines[e.row], e.indent(e.row)
+		starts = softWrap(line, indent, e.width, -1)
+		r = 0
+	default:
+		return false
+	}
+	e.col = columnAt(line, starts, indent, r, x)
+	return true
+}
+
+// indent returns the width of the prompt in front of a line.
+//
+// Parameters:
+//
+//	row (int) - the line
+//
+// Returns:
+//
+//	int - the prompt width, 0 for all lines but the first
+func (e *editor) indent(row int) int {
+	if row > 0 {
+		return 0
+	}
+	return runewidth.StringWidth(e.prompt())
+}
+
+// displayText returns a line as it is printed, with tabs expanded to
+// spaces.
+//
+// Parameters:
+//
+//	line ([]rune) - the line
+//
+// Returns:
+//
+//	string - the printable text
+func displayText(line []rune) string {
+	return strings.ReplaceAll(string(line), "\t", strings.Repeat(" ", tabWidth))
+}
@@ -0,0 +1,175 @@
+package console
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEditor_Editing(t *testing.T) {
+	e := newEditor()
+	for _, r := range "hello world" {
+		e.insert(r)
+	}
+	e.col = 5
+	e.newline()
+	if got := e.text(); got != "hello\n world" || e.row != 1 || e.col != 0 {
+		t.Fatalf("after newline: %q at %d:%d", got, e.row, e.col)
+	}
+
+	e.backspace()
+	if got := e.text(); got != "hello world" || e.row != 0 || e.col != 5 {
+		t.Fatalf("after joining backspace: %q at %d:%d", got, e.row, e.col)
+	}
+
+	e.setText("first\nsecond")
+	if e.row != 1 || e.col != 6 || e.isEmpty() {
+		t.Fatalf("setText cursor at %d:%d", e.row, e.col)
+	}
+	e.row, e.col = 0, 0
+	e.backspace()
+	if got := e.text(); got != "first\nsecond" {
+		t.Fatalf("backspace at start changed text to %q", got)
+	}
+}
+
+func TestSoftWrap(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		indent int
+		width  int
+		cursor int
+		want   []int
+	}{
+		{"fits", "hello", 4, 10, 0, []int{0}},
+		{"wraps after indent", "hello world", 4, 10, 0, []int{0, 6}},
+		{"several rows", "abcdefghij", 0, 4, -1, []int{0, 4, 8}},
+		{"full last row", "abcdefgh", 0, 4, -1, []int{0, 4}},
+		{"cursor after full last row", "abcdefgh", 0, 4, 8, []int{0, 4, 8}},
+		{"wide character moves to next row", "ab世界", 0, 5, 0, []int{0, 3}},
+		{"tab", "a\tb", 0, 5, 0, []int{0, 2}},
+		{"emoji sequence moves as a whole", "ab👨‍👩‍👧", 0, 3, -1, []int{0, 2}},
+		{"empty", "", 4, 10, 0, []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := softWrap([]rune(tt.line), tt.indent, tt.width, tt.cursor)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("softWrap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditor_SoftWrapView(t *testing.T) {
+	e := newTestEditor("abcdefghij\nxy", 0, 8)
+	e.width = 8 // ">>> " and 4 characters per first row
+	out, row := e.view(cmdHistory)
+	if !strings.Contains(out, ">>> abcd\r\nefghij\r\nxy") {
+		t.Fatalf("unexpected rows: %q", out)
+	}
+	if row != 1 || !strings.HasSuffix(out, fmt.Sprintf(CursorUp, 1)+fmt.Sprintf(CursorToColumn, 5)) {
+		t.Fatalf("cursor row %d, output %q", row, out)
+	}
+
+	e.screenRow = row
+	e.footer = []string{"candidates"}
+	e.moveToEnd()
+	out, row = e.view(cmdHistory)
+	if !strings.HasPrefix(out, fmt.Sprintf(CursorUp, 1)+"\r"+ClearBelow) {
+		t.Fatalf("redraw does not start at the first row: %q", out)
+	}
+	if row != 2 || !strings.Contains(out, "xy\r\ncandidates"+fmt.Sprintf(CursorUp, 1)) {
+		t.Fatalf("cursor row %d, output %q", row, out)
+	}
+}
+
+func TestEditor_SoftWrapNavigation(t *testing.T) {
+	e := newTestEditor("abcdefghijkl\nxy", 0, 10)
+	e.width = 8 // rows "abcd", "efghijkl" and an empty row for the cursor
+
+	e.edit(keyUp)
+	if e.row != 0 || e.col != 2 {
+		t.Fatalf("Up in wrapped line: %d:%d, want 0:2", e.row, e.col)
+	}
+	e.edit(keyDown)
+	if e.row != 0 || e.col != 10 {
+		t.Fatalf("Down in wrapped line: %d:%d, want 0:10", e.row, e.col)
+	}
+	e.col = 5
+	e.edit(keyUp) // the column is under the prompt
+	if e.row != 0 || e.col != 0 {
+		t.Fatalf("Up to first row: %d:%d, want 0:0", e.row, e.col)
+	}
+	e.col = 6
+	e.edit(keyDown)
+	if e.row != 1 || e.col != 2 {
+		t.Fatalf("Down to next line: %d:%d, want 1:2", e.row, e.col)
+	}
+	e.edit(keyUp) // onto the last row of the wrapped line
+	if e.row != 0 || e.col != 6 {
+		t.Fatalf("Up to wrapped line: %d:%d, want 0:6", e.row, e.col)
+	}
+}
+
+func TestEditor_ReverseSearch(t *testing.T) {
+	h := &commandHistory{entries: []string{"explain\nthe code", "/models", "explain this"}}
+	h.index = h.len()
+
+	e := newEditor()
+	e.setText("draft")
+	e.startSearch()
+	for _, r := range "expl" {
+		e.searchKey(key(r), h)
+	}
+	if e.search.index != 2 || e.search.failed {
+		t.Fatalf("search index = %d, failed = %v", e.search.index, e.search.failed)
+	}
+
+	e.searchKey(18, h) // Ctrl+R: next older match
+	if e.search.index != 0 {
+		t.Fatalf("Ctrl+R index = %d, want 0", e.search.index)
+	}
+	e.searchKey(18, h) // no older match
+	if e.search.index != 0 || !e.search.failed {
+		t.Fatalf("expected failed search at 0, got %d %v", e.search.index, e.search.failed)
+	}
+
+	out, row := e.view(h)
+	if !strings.Contains(out, "(failed reverse-i-search)`expl': "+Reverse+"expl"+ColorReset+"ain") {
+		t.Fatalf("match not highlighted: %q", out)
+	}
+	if row != 0 {
+		t.Fatalf("cursor row = %d, want 0", row)
+	}
+
+	e.searchKey(13, h) // Enter takes the match
+	if e.search != nil || e.text() != "explain\nthe code" || h.index != 0 {
+		t.Fatalf("accepted text = %q, history index = %d", e.text(), h.index)
+	}
+
+	e.setText("draft")
+	e.startSearch()
+	e.searchKey('x', h)
+	e.searchKey(7, h) // Ctrl+G cancels
+	if e.search != nil || e.text() != "draft" {
+		t.Fatalf("cancel changed input to %q", e.text())
+	}
+}
+
+func TestHighlightMatches(t *testing.T) {
+	tests := []struct {
+		line, query, want string
+	}{
+		{"a b a", "a", Reverse + "a" + ColorReset + " b " + Reverse + "a" + ColorReset},
+		{"abc", "", "abc"},
+		{"abc", "x", "abc"},
+	}
+	for _, tt := range tests {
+		if got := highlightMatches(tt.line, tt.query); got != tt.want {
+			t.Errorf("highlightMatches(%q, %q) = %q, want %q", tt.line, tt.query, got, tt.want)
+		}
+	}
+}
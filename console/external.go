@@ -0,0 +1,94 @@
+package console
+
+import (
+	"fmt"
+
+	"golang.org/x/term"
+)
+
+// ExternalEditor opens a text in an external editor and returns the saved
+// text. It is called with the terminal in normal (cooked) mode.
+type ExternalEditor func(text string) (string, error)
+
+var externalEditor ExternalEditor
+
+// draft is put into the next input, e.g. a prompt written with /compose.
+var draft string
+
+// SetExternalEditor registers the editor used for Ctrl+X Ctrl+E.
+//
+// Parameters:
+//
+//	e (ExternalEditor) - the editor function, nil disables the key
+//
+// Returns:
+//
+//	none
+func SetExternalEditor(e ExternalEditor) {
+	externalEditor = e
+}
+
+// SetDraft puts a text into the next input, where it can be reviewed and
+// changed before it is submitted.
+//
+// Parameters:
+//
+//	text (string) - the text
+//
+// Returns:
+//
+//	none
+func SetDraft(text string) {
+	draft = text
+}
+
+// editExternal opens the input in the external editor and loads the saved
+// text back into the input. Raw mode is left while the editor runs. If
+// the editor fails, the error is shown and the input is kept.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	error - error if raw mode cannot be enabled again
+func (s *inputSession) editExternal() error {
+	ed := s.ed
+	if externalEditor == nil {
+		fmt.Print("\a")
+		return nil
+	}
+
+	// leave the input on the screen and start the editor below it
+	ed.moveToEnd()
+	ed.footer = nil
+	ed.render()
+	fmt.Print("\r\n" + DisableBracketedPaste + EnableLineWrap)
+	if err := term.Restore(s.fd, s.oldState); err != nil {
+		return fmt.Errorf("restore terminal mode failed: %w", err)
+	}
+
+	text, err := externalEditor(ed.text())
+	if err != nil {
+		Error(fmt.Errorf("edit input failed: %w", err))
+	}
+
+	if _, err := term.MakeRaw(s.fd); err != nil {
+		return fmt.Errorf("enable raw input mode failed: %w", err)
+	}
+	fmt.Print(DisableLineWrap + EnableBracketedPaste)
+
+	if err == nil {
+		if ed.vi != nil {
+			ed.pushUndo()
+		}
+		ed.setText(text)
+		if ed.vi != nil {
+			ed.viClamp()
+		}
+	}
+	ed.screenRow = 0 // the input is drawn again below the editor output
+	ed.render()
+	return nil
+}
@@ -20,9 +20,12 @@ func Error(err error) {
 		return
 	}
 
-	errMsg := Colorize(Red, fmt.Sprintf("%s %s\n", ErrPrefix, err.Error()))
-	fmt.Print(ClearLine)
-	fmt.Fprint(os.Stderr, errMsg)
+	prefix := ErrSymbol
+	if stderrColors {
+		prefix = Bold + ErrSymbol + Regular
+	}
+	clearLine()
+	fmt.Fprintln(os.Stderr, paint(stderrColors, theme.Error, prefix+" "+err.Error()))
 }
 
 // Warn prints a warning message to stderr, prefixed with "warning:"
@@ -39,8 +42,8 @@ func Warn(msg string) {
 		return
 	}
 
-	fmt.Print(ClearLine)
-	fmt.Fprintf(os.Stderr, "%s %s\n", WarnPrefix, msg)
+	clearLine()
+	fmt.Fprintf(os.Stderr, "%s %s\n", paint(stderrColors, theme.Warn, WarnSymbol), msg)
 }
 
 // Warns calls the Warn func multiple times for a number of similar warnings.
@@ -79,8 +82,23 @@ func Info(msg string) {
 		return
 	}
 
-	fmt.Print(ClearLine)
-	fmt.Fprintf(os.Stdout, "%s %s\n", InfoPrefix, msg)
+	clearLine()
+	fmt.Fprintf(os.Stdout, "%s %s\n", Colorize(theme.Info, InfoSymbol), msg)
+}
+
+// clearLine clears the line of the spinner if stdout is a terminal.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func clearLine() {
+	if stdoutTerminal {
+		fmt.Print(ClearLine)
+	}
 }
 
 // SetCursorPos places the cursor into the given column
@@ -93,12 +111,14 @@ func Info(msg string) {
 //
 //	none
 func SetCursorPos(col int) {
-	fmt.Printf(CursorToColumn, col)
-
+	if stdoutTerminal {
+		fmt.Printf(CursorToColumn, col)
+	}
 }
 
 // Colorize is a Helper function for enclosing text in color Esc sequences.
-// A reset Esc sequence is added to the end of string.
+// A reset Esc sequence is added to the end of string. The text stays plain
+// if stdout gets no colors.
 //
 // Parameters:
 //
@@ -109,11 +129,25 @@ func SetCursorPos(col int) {
 //
 //	string - text enclosed in esc sequences
 func Colorize(color, text string) string {
-	return color + text + ColorReset
+	return paint(stdoutColors, color, text)
+}
+
+// RGB returns the Esc sequence of a truecolor (24 bit) foreground color.
+//
+// Parameters:
+//
+//	r, g, b (uint8) - the red, green and blue component
+//
+// Returns:
+//
+//	string - the Esc sequence
+func RGB(r, g, b uint8) string {
+	return fmt.Sprintf("\033[38;2;%d;%d;%dm", r, g, b)
 }
 
 // Style is a Helper function for enclosing text in font style Esc sequences.
-// A reset Esc sequence is added to the end of string.
+// A reset Esc sequence is added to the end of string. The text stays plain
+// if stdout gets no colors.
 //
 // Parameters:
 //
@@ -124,6 +158,9 @@ func Colorize(color, text string) string {
 //
 //	string - text enclosed in esc sequences
 func Style(fontstyle, text string) string {
+	if !stdoutColors {
+		return text
+	}
 	return fontstyle + text + Regular
 }
 
@@ -2,16 +2,48 @@ package console
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
+	"iter"
 	"os"
 	"slices"
 	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
 
+	uax29 "github.com/clipperhouse/uax29/v2/graphemes"
 	"github.com/mattn/go-runewidth"
 	"golang.org/x/term"
 )
 
+// key is a key press: a rune or one of the special keys below.
+type key rune
+
+const (
+	keyUnknown key = -(iota + 1)
+	keyEscape
+	keyUp
+	keyDown
+	keyRight
+	keyLeft
+	keyHome
+	keyEnd
+	keyDelete
+	keyWordLeft   // Alt+B
+	keyWordRight  // Alt+F
+	keyDeleteWord // Alt+D
+	keyYankPop    // Alt+Y
+	keyPasteStart // start of a bracketed paste
+)
+
+// pasteEnd ends a bracketed paste.
+const pasteEnd = "\033[201~"
+
+// tabWidth is the number of columns a tab is shown with.
+const tabWidth = 4
+
 type InputResult struct {
 	Text      string
 	IsCommand bool
@@ -20,7 +52,7 @@ type InputResult struct {
 	Error     error
 }
 
-// PromptWidth returns the width of the predefined prompt symbols
+// PromptWidth returns the width of the input prompt symbols
 // as correct runewidth calculation.
 //
 // Pramaters:
@@ -31,7 +63,7 @@ type InputResult struct {
 //
 //	int - the width of the symbols
 func PromptWidth() int {
-	return runewidth.StringWidth(Prompt)
+	return runewidth.StringWidth(InputPrompt())
 }
 
 // ReadMultilineInput reads multiline input from stdin. It handles raw mode,
@@ -50,202 +82,364 @@ func ReadMultilineInput() InputResult {
 	fd := int(in.Fd())
 	if !term.IsTerminal(fd) {
 		// stdin is not an interactive console → pipe or file
-		data, err := io.ReadAll(in)
-		if err != nil {
-			return InputResult{Error: fmt.Errorf("read stdin failed: %w", err)}
-		}
-		text := strings.TrimRight(string(data), "\n")
-		trimText := strings.TrimSpace(text)
-		return InputResult{Text: trimText, EOF: true, IsCommand: strings.HasPrefix(trimText, "/")}
-	} else {
-		oldState, err := term.MakeRaw(fd)
-		if err != nil {
-			return InputResult{Error: fmt.Errorf("enable raw input mode failed: %w", err)}
-		}
-		fmt.Print(DisableLineWrap)
-		defer func() {
-			fmt.Print(EnableLineWrap)
-			term.Restore(fd, oldState)
-		}()
+		return readPipedInput(in)
 	}
 
-	var lines []string
-	var currentLine []rune
-	var cursorPos int
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return InputResult{Error: fmt.Errorf("enable raw input mode failed: %w", err)}
+	}
+	fmt.Print(DisableLineWrap + EnableBracketedPaste)
+	defer func() {
+		fmt.Print(DisableBracketedPaste + EnableLineWrap)
+		term.Restore(fd, oldState)
+	}()
+
+	s := &inputSession{ed: newEditor(), reader: bufio.NewReader(in), fd: fd, oldState: oldState}
+	s.ed.width = getTerminalWidth(fd)
+	if draft != "" { // text prepared by /compose
+		s.ed.setText(draft)
+		draft = ""
+		s.ed.render()
+	}
+	if viMode {
+		s.ed.vi = &viState{}
+		s.ed.pushUndo()
+	}
 
-	reader := bufio.NewReader(in)
+	// the input is redrawn for the new width when the terminal is resized
+	var mu sync.Mutex
+	stopResize := watchResize(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if !s.done {
+			s.resize()
+		}
+	})
+	defer stopResize()
 
 	for {
-		r, _, err := reader.ReadRune()
+		k, err := readKey(s.reader, fd)
 		if err != nil {
 			break
 		}
 
-		firstLine := len(lines) == 0
+		mu.Lock()
+		result, done := s.handleKey(k)
+		s.done = done
+		mu.Unlock()
+		if done {
+			return result
+		}
+	}
 
-		switch r {
-		case 3: // Ctrl+C
-			if firstLine {
-				fmt.Print(ClearLine + Prompt)
-			}
-			return InputResult{Aborted: true}
+	return InputResult{Text: s.ed.text()}
+}
 
-		case 4: // Ctrl+D (EOF) → input finished
-			fmt.Println()
-			if len(currentLine) > 0 {
-				lines = append(lines, string(currentLine))
-			}
-			return InputResult{Text: strings.Join(lines, "\n"), EOF: false}
-
-		case 27: //  or Escape sequences
-			// Peek to see if there are more bytes (Escape sequence)
-			_ = setNonblock(fd, true)
-			peekBuf, err := reader.Peek(2)
-			_ = setNonblock(fd, false)
-			if err != nil || len(peekBuf) < 2 {
-				// Plain  → abort immediately
-				if firstLine {
-					fmt.Print(ClearLine + Prompt)
-				}
-				return InputResult{Aborted: true}
-			}
+// readPipedInput reads the whole input of a pipe or file. Like typed
+// input, text starting with "/" or "!" is a command.
+//
+// Parameters:
+//
+//	r (io.Reader) - the redirected stdin
+//
+// Returns:
+//
+//	InputResult - the trimmed text with EOF set
+func readPipedInput(r io.Reader) InputResult {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return InputResult{Error: fmt.Errorf("read stdin failed: %w", err)}
+	}
+	text := strings.TrimSpace(string(data))
+	return InputResult{Text: text, EOF: true, IsCommand: isCommandText(text)}
+}
 
-			// Read the Escape sequence
-			buf := make([]byte, 2)
-			n, _ := reader.Read(buf)
+// inputSession is the state of one ReadMultilineInput call in raw mode.
+type inputSession struct {
+	ed         *editor
+	completion completionState
+	reader     *bufio.Reader
+	fd         int
+	oldState   *term.State // terminal state before raw mode
+	ctrlX      bool        // Ctrl+X was pressed, Ctrl+E may follow
+	done       bool        // the input is finished, no more redraws
+}
 
-			if n < 2 || buf[0] != '[' {
-				// Unknown Escape sequence
-				continue
-			}
+// handleKey applies a key press to the input.
+//
+// Parameters:
+//
+//	k (key) - the pressed key
+//
+// Returns:
+//
+//	InputResult - the result if the input is finished
+//	bool        - true if the input is finished
+func (s *inputSession) handleKey(k key) (InputResult, bool) {
+	ed := s.ed
+	ed.width = getTerminalWidth(s.fd)
 
-			// Arrow keys (e.g. [A, [B, [C, [D])
-			switch buf[1] {
-			case 'A': // Up
-				if firstLine {
-					if cmd := PrevCommand(); cmd != "" {
-						currentLine = []rune(cmd)
-						cursorPos = len(currentLine)
-						updateCurrentLine(currentLine, true, cursorPos)
-					}
-				}
-				continue
-			case 'B': // Down
-				if firstLine {
-					currentLine = []rune(NextCommand())
-					cursorPos = len(currentLine)
-					updateCurrentLine(currentLine, true, cursorPos)
-				}
-				continue
-			case 'C': // Right
-				if cursorPos < len(currentLine) {
-					width := runewidth.RuneWidth(currentLine[cursorPos])
-					cursorPos++
-					// Move cursor forward by visual width of char
-					for range width {
-						fmt.Print(CursorForward)
-					}
-				}
-				continue
-			case 'D': // Left
-				if cursorPos > 0 {
-					cursorPos--
-					width := runewidth.RuneWidth(currentLine[cursorPos])
-					// Move cursor back by visual width of char
-					for range width {
-						fmt.Print(CursorBack)
-					}
-				}
-				continue
-			}
-			continue // ignore everything else
-		case 127: // Backspace
-			if cursorPos > 0 {
-				currentLine, cursorPos = deleteCharAt(currentLine, cursorPos)
-				updateCurrentLine(currentLine, firstLine, cursorPos)
-			}
-		case 13, 10: // Enter
-			line := string(currentLine)
-			trimLine := strings.TrimSpace(line)
+	if k != '\t' && s.completion.listed {
+		s.completion.reset()
+		ed.footer = nil
+		ed.render()
+	}
 
-			// Input is a command
-			if firstLine && strings.HasPrefix(trimLine, "/") {
-				return InputResult{Text: trimLine, IsCommand: true}
-			}
+	if k == keyPasteStart {
+		s.paste()
+		return InputResult{}, false
+	}
+
+	if ed.search != nil {
+		ed.searchKey(k, cmdHistory)
+		ed.render()
+		return InputResult{}, false
+	}
 
-			// first line is empty
-			if firstLine && len(trimLine) == 0 {
-				fmt.Print(ClearLine + Prompt)
+	if isCancelKey(k) {
+		if len(ed.lines) == 1 {
+			if ed.screenRow > 0 { // the line is soft-wrapped
+				fmt.Printf(CursorUp, ed.screenRow)
 			}
+			fmt.Print(ClearLine + ClearBelow + Colorize(theme.Prompt, InputPrompt()))
+		} else {
+			ed.moveToEnd()
+			ed.render()
+		}
+		return InputResult{Aborted: true}, true
+	}
 
-			lines = append(lines, line)
-			currentLine = []rune{}
-			cursorPos = 0
-			fmt.Print("\r\n") // Println not sufficient here
-		default:
-			currentLine, cursorPos = insertCharAt(currentLine, cursorPos, rune(r))
-			updateCurrentLine(currentLine, firstLine, cursorPos)
-
-			// A simple approach for word wrap at terminal windows width
-			lineLength := getTerminalWidth(fd) - 1
-			if firstLine {
-				lineLength = lineLength - PromptWidth()
+	if s.ctrlX {
+		s.ctrlX = false
+		if k == 5 { // Ctrl+X Ctrl+E → edit the input in the external editor
+			if err := s.editExternal(); err != nil {
+				return InputResult{Error: err}, true
 			}
-			if visualWidth(currentLine, cursorPos) >= lineLength {
-				previousLine, nextLine := determineLineBreak(currentLine)
-				updateCurrentLine(previousLine, firstLine, len(previousLine))
-				lines = append(lines, string(previousLine))
-				currentLine = nextLine
-				cursorPos = len(nextLine)
-				fmt.Println()
-				updateCurrentLine(currentLine, false, cursorPos)
+			return InputResult{}, false
+		}
+	}
+	if k == 24 { // Ctrl+X
+		s.ctrlX = true
+		return InputResult{}, false
+	}
+
+	if ed.vi != nil && (ed.vi.normal || k == keyEscape) && k != 4 && k != 18 {
+		if ed.viKey(k) { // Enter in normal mode
+			if cmd, ok := ed.command(); ok {
+				return InputResult{Text: cmd, IsCommand: true}, true
 			}
+			return finishInput(ed), true
 		}
+		ed.render()
+		return InputResult{}, false
 	}
 
-	return InputResult{Text: strings.Join(lines, "\n")}
+	switch k {
+	case 4: // Ctrl+D (EOF) → input finished
+		return finishInput(ed), true
+
+	case 18: // Ctrl+R → reverse history search
+		ed.startSearch()
+
+	case '\t': // Tab → complete commands and their arguments
+		if len(ed.lines) == 1 && strings.HasPrefix(strings.TrimSpace(string(ed.lines[0])), "/") {
+			ed.lines[0], ed.col = s.completion.complete(ed.lines[0], ed.col)
+			ed.footer = s.completion.list(ed.width)
+		} else {
+			ed.insert('\t')
+		}
+	case 13, 10: // Enter
+		if cmd, ok := ed.command(); ok {
+			return InputResult{Text: cmd, IsCommand: true}, true
+		}
+		ed.newline()
+	default:
+		if !ed.edit(k) {
+			return InputResult{}, false // ignore other control keys
+		}
+	}
+	ed.render()
+	return InputResult{}, false
 }
 
-// determineLineBreak splits a full input line at its last space. The text before
-// the space becomes the completed line and the following word remains editable
-// on the next line.
+// paste reads a bracketed paste and inserts it as a whole, so that line
+// breaks, tabs and escape sequences in the pasted text are not taken as
+// key presses. During a reverse search, the first pasted line extends the
+// query.
 //
 // Parameters:
 //
-//	currentLine ([]rune) - the current input line
+//	none
 //
 // Returns:
 //
-//	[]rune - completed line
-//	[]rune - new line
-func determineLineBreak(currentLine []rune) ([]rune, []rune) {
-	splitAt := -1
-	for i, r := range slices.Backward(currentLine) {
-		if r == ' ' {
-			splitAt = i
-			break
+//	none
+func (s *inputSession) paste() {
+	ed := s.ed
+	text := cleanPaste(readPaste(s.reader))
+	switch {
+	case ed.search != nil:
+		first, _, _ := strings.Cut(text, "\n")
+		for _, r := range strings.ReplaceAll(first, "\t", " ") {
+			ed.searchKey(key(r), cmdHistory)
 		}
+	case ed.vi != nil && ed.vi.normal:
+		ed.pushUndo()
+		ed.insertText(text)
+		ed.viClamp()
+	default:
+		ed.insertText(text)
 	}
-	if splitAt <= 0 || splitAt >= len(currentLine)-1 {
-		return currentLine, nil
+	ed.lastAction = actionNone
+	ed.render()
+}
+
+// resize redraws the input after the terminal width has changed.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (s *inputSession) resize() {
+	s.ed.width = getTerminalWidth(s.fd)
+	if s.completion.listed {
+		s.ed.footer = s.completion.list(s.ed.width)
 	}
+	s.ed.render()
+}
 
-	end := splitAt
-	for end > 0 && currentLine[end-1] == ' ' {
-		end--
+// finishInput moves the cursor below the input and returns it.
+//
+// Parameters:
+//
+//	ed (*editor) - the editor
+//
+// Returns:
+//
+//	InputResult - the entered text
+func finishInput(ed *editor) InputResult {
+	ed.moveToEnd()
+	ed.render()
+	fmt.Print("\r\n")
+	return InputResult{Text: ed.text(), EOF: false}
+}
+
+// readKey reads the next key press. Escape sequences of cursor keys and,
+// outside of vi mode, Alt key combinations are returned as a single key; a
+// lone Escape is returned as keyEscape.
+//
+// Parameters:
+//
+//	reader (*bufio.Reader) - the raw mode input
+//	fd (int)               - the file descriptor of the input
+//
+// Returns:
+//
+//	key   - the rune of the key or a special key
+//	error - error if the input cannot be read
+func readKey(reader *bufio.Reader, fd int) (key, error) {
+	r, _, err := reader.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if r != 27 {
+		return key(r), nil
+	}
+
+	// Peek to see if there are more bytes (Escape sequence)
+	_ = setNonblock(fd, true)
+	next, err := reader.Peek(1)
+	_ = setNonblock(fd, false)
+	if err != nil {
+		return keyEscape, nil
 	}
-	start := splitAt + 1
-	for start < len(currentLine) && currentLine[start] == ' ' {
-		start++
+	if viMode && next[0] != '[' && next[0] != 'O' {
+		return keyEscape, nil // Escape typed quickly before a normal mode command
 	}
 
-	previousLine := currentLine[:end]
-	nextLine := currentLine[start:]
-	return previousLine, nextLine
+	b, _ := reader.ReadByte()
+	switch b {
+	case '[', 'O':
+		return readEscapeSequence(reader), nil
+	case 'b', 'B':
+		return keyWordLeft, nil
+	case 'f', 'F':
+		return keyWordRight, nil
+	case 'd', 'D':
+		return keyDeleteWord, nil
+	case 'y', 'Y':
+		return keyYankPop, nil
+	}
+	return keyUnknown, nil
 }
 
-// deleteCharAt deletes the character at the cursor position in the current line.
-// If the cursor is at the beginning (pos 0), no deletion occurs.
+// readEscapeSequence reads the rest of a CSI or SS3 sequence (e.g. "[A",
+// "[3~", "[1;5C" or "OH") and maps it to a key.
+//
+// Parameters:
+//
+//	reader (*bufio.Reader) - the raw mode input after "\033[" or "\033O"
+//
+// Returns:
+//
+//	key - the special key, keyUnknown for unsupported sequences
+func readEscapeSequence(reader *bufio.Reader) key {
+	var params []byte
+	for {
+		c, err := reader.ReadByte()
+		if err != nil || len(params) > 8 {
+			return keyUnknown
+		}
+		if c < 0x40 || c > 0x7e {
+			params = append(params, c)
+			continue
+		}
+
+		// Ctrl or Alt with an arrow key moves by words
+		modified := strings.HasSuffix(string(params), ";5") || strings.HasSuffix(string(params), ";3")
+		switch c {
+		case 'A':
+			return keyUp
+		case 'B':
+			return keyDown
+		case 'C':
+			if modified {
+				return keyWordRight
+			}
+			return keyRight
+		case 'D':
+			if modified {
+				return keyWordLeft
+			}
+			return keyLeft
+		case 'H':
+			return keyHome
+		case 'F':
+			return keyEnd
+		case '~':
+			switch string(params) {
+			case "1", "7":
+				return keyHome
+			case "4", "8":
+				return keyEnd
+			case "3":
+				return keyDelete
+			case "200":
+				return keyPasteStart
+			}
+		}
+		return keyUnknown
+	}
+}
+
+// deleteCharAt deletes the character before the cursor position in the
+// current line. A character is a grapheme cluster, so an emoji sequence or
+// a letter with combining accents is deleted as a whole. If the cursor is
+// at the beginning (pos 0), no deletion occurs.
 //
 // Parameters:
 //
@@ -260,21 +454,8 @@ func deleteCharAt(line []rune, cursorPos int) ([]rune, int) {
 	if cursorPos == 0 || len(line) == 0 {
 		return line, cursorPos
 	}
-
-	// Split at cursor position
-	before := line[:cursorPos]
-	after := line[cursorPos:]
-
-	// Remove last character from before part
-	if len(before) > 0 {
-		before = before[:len(before)-1]
-	}
-
-	// Merge back together
-	newLine := append(before, after...)
-	newCursorPos := cursorPos - 1
-
-	return newLine, newCursorPos
+	start := prevGrapheme(line, cursorPos)
+	return slices.Delete(line, start, cursorPos), start
 }
 
 // insertCharAt inserts a character at the cursor position in the current line.
@@ -309,41 +490,68 @@ func insertCharAt(line []rune, cursorPos int, char rune) ([]rune, int) {
 	return newLine, newCursorPos
 }
 
-// updateCurrentLine redraws the current line after an edit
+// readPaste reads the text of a bracketed paste up to its end sequence.
 //
 // Parameters:
 //
-//	line ([]rune)    - the current line to be drawn
-//	firstLine (bool) - is the line the first line (true / false)
-//	cursorPos        - the cursor position for the redraw
+//	reader (*bufio.Reader) - the raw mode input after the paste start
 //
 // Returns:
 //
-//	none
-func updateCurrentLine(line []rune, firstLine bool, cursorPos int) {
-	fmt.Print(ClearLine)
-
-	prefix := ""
-	prefixWidth := 0
-	if firstLine {
-		prefix = Prompt
-		prefixWidth = PromptWidth()
+//	string - the pasted text
+func readPaste(reader *bufio.Reader) string {
+	var buf []byte
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return string(buf)
+		}
+		buf = append(buf, b)
+		if bytes.HasSuffix(buf, []byte(pasteEnd)) {
+			return string(buf[:len(buf)-len(pasteEnd)])
+		}
 	}
+}
 
-	visualPos := visualWidth(line, cursorPos)
-
-	if firstLine && len(line) == 0 {
-		fmt.Print(Prompt + ShadowText)
-	} else {
-		fmt.Print(prefix + string(line))
+// cleanPaste prepares pasted text for the input. Line endings become "\n",
+// escape sequences and control characters other than line breaks and tabs
+// are removed.
+//
+// Parameters:
+//
+//	text (string) - the pasted text
+//
+// Returns:
+//
+//	string - the text to insert
+func cleanPaste(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	var sb strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == 27: // skip an escape sequence
+			if i+1 < len(runes) && runes[i+1] == '[' {
+				i++
+				for i+1 < len(runes) && (runes[i+1] < 0x40 || runes[i+1] > 0x7e) {
+					i++
+				}
+			}
+			i++
+		case r == '\n' || r == '\t' || !unicode.IsControl(r):
+			sb.WriteRune(r)
+		}
 	}
-	SetCursorPos(visualPos + prefixWidth + 1)
+	return sb.String()
 }
 
 // visualWidth calculates the visual display width of a rune slice up to
-// a given position. This accounts for characters that occupy multiple
-// terminal columns (e.g., CJK characters, emojis) or zero columns
-// (e.g., combining characters).
+// a given position. The width is measured per grapheme cluster, so
+// characters that occupy multiple terminal columns (e.g., CJK characters,
+// emoji sequences), combining characters and tabs are accounted for.
 //
 // Parameters:
 //
@@ -358,12 +566,129 @@ func visualWidth(line []rune, pos int) int {
 		pos = len(line)
 	}
 	width := 0
-	for _, r := range line[:pos] {
-		width += runewidth.RuneWidth(r)
+	for _, cluster := range graphemes(line[:pos]) {
+		width += graphemeWidth(cluster)
 	}
 	return width
 }
 
+// graphemeWidth returns the number of terminal columns of a grapheme
+// cluster.
+//
+// Parameters:
+//
+//	cluster (string) - the grapheme cluster
+//
+// Returns:
+//
+//	int - the width in terminal columns
+func graphemeWidth(cluster string) int {
+	if cluster == "\t" {
+		return tabWidth
+	}
+	return runewidth.StringWidth(cluster)
+}
+
+// graphemes iterates over the extended grapheme clusters of a line, the
+// characters as the user sees them.
+//
+// Parameters:
+//
+//	line ([]rune) - the line
+//
+// Returns:
+//
+//	iter.Seq2[int, string] - the rune position and the text of each cluster
+func graphemes(line []rune) iter.Seq2[int, string] {
+	return func(yield func(int, string) bool) {
+		pos := 0
+		tokens := uax29.FromString(string(line))
+		for tokens.Next() {
+			cluster := tokens.Value()
+			if !yield(pos, cluster) {
+				return
+			}
+			pos += utf8.RuneCountInString(cluster)
+		}
+	}
+}
+
+// prevGrapheme returns the start of the grapheme cluster before a position.
+//
+// Parameters:
+//
+//	line ([]rune) - the line
+//	col (int)     - the position
+//
+// Returns:
+//
+//	int - the rune position, 0 at the start of the line
+func prevGrapheme(line []rune, col int) int {
+	prev := 0
+	for pos := range graphemes(line) {
+		if pos >= col {
+			break
+		}
+		prev = pos
+	}
+	return prev
+}
+
+// nextGrapheme returns the end of the grapheme cluster at a position.
+//
+// Parameters:
+//
+//	line ([]rune) - the line
+//	col (int)     - the position
+//
+// Returns:
+//
+//	int - the rune position, len(line) at the end of the line
+func nextGrapheme(line []rune, col int) int {
+	for pos := range graphemes(line) {
+		if pos > col {
+			return pos
+		}
+	}
+	return len(line)
+}
+
+// graphemeStart moves a position inside a grapheme cluster to the start
+// of the cluster.
+//
+// Parameters:
+//
+//	line ([]rune) - the line
+//	col (int)     - the position
+//
+// Returns:
+//
+//	int - the position of the cluster start, col itself on a boundary
+func graphemeStart(line []rune, col int) int {
+	if col >= len(line) {
+		return len(line)
+	}
+	return prevGrapheme(line, col+1)
+}
+
+// graphemeEnd moves a position inside a grapheme cluster to the end of the
+// cluster.
+//
+// Parameters:
+//
+//	line ([]rune) - the line
+//	col (int)     - the position
+//
+// Returns:
+//
+//	int - the position of the cluster end, col itself on a boundary
+func graphemeEnd(line []rune, col int) int {
+	if start := graphemeStart(line, col); start != col {
+		return nextGrapheme(line, start)
+	}
+	return col
+}
+
 // getTerminalWidth returns the current width of the terminal window
 //
 // Parameters:
@@ -375,7 +700,7 @@ func visualWidth(line []rune, pos int) int {
 //	int - width of the window
 func getTerminalWidth(fd int) int {
 	width, _, err := term.GetSize(fd)
-	if err != nil {
+	if err != nil || width <= 0 {
 		// Fallback to a default width if terminal size cannot be determined
 		return 80
 	}
@@ -1,8 +1,8 @@
 package console
 
 import (
-	"io"
-	"os"
+	"bufio"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -53,6 +53,12 @@ func TestDeleteCharAt(t *testing.T) {
 		{"delete start", "Hello", 1, "ello", 0},
 		{"delete empty", "", 0, "", 0},
 		{"delete pos 0", "Hello", 0, "Hello", 0},
+		{"delete cjk", "中国是", 2, "中是", 1},
+		{"delete emoji", "English? 😊", 10, "English? ", 9},
+		{"delete zwj sequence", "a👨‍👩‍👧b", 6, "ab", 1},
+		{"delete flag", "🇩🇪🇫🇷", 4, "🇩🇪", 2},
+		{"delete combining accent", "cafe\u0301", 5, "caf", 3},
+		{"delete skin tone", "👍🏽!", 2, "!", 0},
 	}
 
 	for _, tt := range tests {
@@ -69,68 +75,128 @@ func TestDeleteCharAt(t *testing.T) {
 	}
 }
 
-func TestDetermineLineBreak(t *testing.T) {
+// --- bracketed paste -----------------------------------------------
+
+func TestReadPaste(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("200~line one\r\n\tline two\033[201~x"))
+	if k := readEscapeSequence(reader); k != keyPasteStart {
+		t.Fatalf("paste start = %d", k)
+	}
+	if got := readPaste(reader); got != "line one\r\n\tline two" {
+		t.Fatalf("readPaste() = %q", got)
+	}
+	if r, _, _ := reader.ReadRune(); r != 'x' {
+		t.Fatalf("input after paste = %q", r)
+	}
+}
+
+func TestCleanPaste(t *testing.T) {
 	tests := []struct {
-		name       string
-		input      string
-		wantBefore string
-		wantAfter  string
+		name  string
+		input string
+		want  string
 	}{
-		{"split at last space", "hello world", "hello", "world"},
-		{"collapse spaces after split", "hello   world", "hello", "world"},
-		{"unicode keeps rune cursor index", "你好 世界", "你好", "世界"},
-		{"no space", "helloworld", "helloworld", ""},
-		{"trailing space", "hello ", "hello ", ""},
-		{"empty string", "", "", ""},
-		{"single character", "a", "a", ""},
-		{"only spaces", "   ", "   ", ""},
-		{"space at start", " hello world", " hello", "world"},
+		{"plain text", "hello", "hello"},
+		{"line endings", "a\r\nb\rc\n", "a\nb\nc\n"},
+		{"tabs kept", "\tif x {", "\tif x {"},
+		{"color codes removed", "\033[31mred\033[0m", "red"},
+		{"other escapes removed", "a\033Mb\033[1;5Dc", "abc"},
+		{"control characters removed", "a\x03b\x7fc\x00", "abc"},
+		{"unicode", "größe 世界", "größe 世界"},
 	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			before, after := determineLineBreak([]rune(tt.input))
-			if string(before) != tt.wantBefore {
-				t.Errorf("before = %q, want %q", string(before), tt.wantBefore)
-			}
-			if string(after) != tt.wantAfter {
-				t.Errorf("after = %q, want %q", string(after), tt.wantAfter)
+			if got := cleanPaste(tt.input); got != tt.want {
+				t.Errorf("cleanPaste(%q) = %q, want %q", tt.input, got, tt.want)
 			}
 		})
 	}
 }
 
-// --- updateCurrentLine ---------------------------------------------
+// --- visualWidth ---------------------------------------------------
 
-func captureOutput(f func()) string {
-	old := os.Stdout // save original
-	r, w, _ := os.Pipe()
-	os.Stdout = w
+func TestVisualWidth(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+		want  int
+	}{
+		{"hello", 5, 5},
+		{"hello", 2, 2},
+		{"世界", 2, 4},
+		{"a\tb", 3, 2 + tabWidth},
+		{"中国是一个", 5, 10},
+		{"😊 ok", 4, 5},
+		{"👨‍👩‍👧", 5, 2},
+		{"🇩🇪", 2, 2},
+		{"cafe\u0301", 5, 4},
+		{"abc", 9, 3},
+	}
+	for _, tt := range tests {
+		if got := visualWidth([]rune(tt.input), tt.pos); got != tt.want {
+			t.Errorf("visualWidth(%q, %d) = %d, want %d", tt.input, tt.pos, got, tt.want)
+		}
+	}
+}
 
-	f() // execute the function we want to test
+// --- grapheme clusters ---------------------------------------------
 
-	_ = w.Close()
-	os.Stdout = old // restore
+func TestGraphemeMovement(t *testing.T) {
+	line := []rune("中国是一个拥有悠久历史的文明古国。 Can you translate this into English? 😊👨‍👩‍👧e\u0301")
+	var stops []int
+	for pos := 0; pos < len(line); pos = nextGrapheme(line, pos) {
+		stops = append(stops, pos)
+	}
+	n := len(line)
+	wantTail := []int{n - 8, n - 7, n - 2} // 😊, the family sequence, e with accent
+	if got := stops[len(stops)-3:]; !slices.Equal(got, wantTail) {
+		t.Fatalf("last stops = %v, want %v", got, wantTail)
+	}
+	if len(stops) != len(line)-5 {
+		t.Fatalf("got %d characters for %d runes", len(stops), len(line))
+	}
 
-	var buf strings.Builder
-	_, _ = io.Copy(&buf, r)
-	_ = r.Close()
+	for i, want := range slices.Backward(stops) {
+		if i == len(stops)-1 {
+			if got := prevGrapheme(line, n); got != want {
+				t.Fatalf("prevGrapheme(end) = %d, want %d", got, want)
+			}
+			continue
+		}
+		if got := prevGrapheme(line, stops[i+1]); got != want {
+			t.Fatalf("prevGrapheme(%d) = %d, want %d", stops[i+1], got, want)
+		}
+	}
 
-	return buf.String()
+	if got := graphemeStart(line, n-5); got != n-7 {
+		t.Errorf("graphemeStart inside sequence = %d, want %d", got, n-7)
+	}
+	if got := graphemeEnd(line, n-5); got != n-2 {
+		t.Errorf("graphemeEnd inside sequence = %d, want %d", got, n-2)
+	}
+	if got := graphemeEnd(line, n-2); got != n-2 {
+		t.Errorf("graphemeEnd on boundary = %d, want %d", got, n-2)
+	}
 }
 
-func TestUpdateCurrentLine(t *testing.T) {
-	out := captureOutput(func() {
-		updateCurrentLine([]rune("Hello"), true, 5)
-	})
-	if !strings.Contains(out, ">>> Hello") {
-		t.Errorf("expected prompt + text, got %q", out)
+// --- readPipedInput -------------------------------------------------
+
+func TestReadPipedInput(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		command bool
+	}{
+		{"  /models\n", "/models", true},
+		{"!git status\n", "!git status", true},
+		{"What is Go?\n\n", "What is Go?", false},
+		{"", "", false},
 	}
 
-	out = captureOutput(func() {
-		updateCurrentLine([]rune("World"), false, 3)
-	})
-	if !strings.Contains(out, "World") {
-		t.Errorf("expected text without prompt, got %q", out)
+	for _, tt := range tests {
+		got := readPipedInput(strings.NewReader(tt.input))
+		if got.Text != tt.want || got.IsCommand != tt.command || !got.EOF || got.Error != nil {
+			t.Errorf("readPipedInput(%q) = %+v, want text %q command %v", tt.input, got, tt.want, tt.command)
+		}
 	}
 }
@@ -0,0 +1,45 @@
+//go:build !windows
+
+package console
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// watchResize calls a function whenever the terminal is resized.
+//
+// Parameters:
+//
+//	onResize (func()) - called after each resize
+//
+// Returns:
+//
+//	func() - stops watching and waits for a running call to finish
+func watchResize(onResize func()) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGWINCH)
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-signals:
+				onResize()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+		wg.Wait()
+	}
+}
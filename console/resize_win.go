@@ -0,0 +1,9 @@
+//go:build windows
+
+package console
+
+// watchResize is a no-op on Windows, where the console reports no resize
+// signal. The input is laid out for the new width with the next key.
+func watchResize(_ func()) func() {
+	return func() {}
+}
@@ -6,7 +6,8 @@ import (
 )
 
 // StartSpinner starts a spinner animation until a signal is
-// received on the stop channel.
+// received on the stop channel. There is no spinner if stdout is no
+// terminal.
 //
 // Parameters:
 //
@@ -17,7 +18,7 @@ import (
 //
 //	none
 func StartSpinner(quiet bool, stop <-chan struct{}) {
-	if quiet {
+	if quiet || !stdoutTerminal {
 		return
 	}
 
@@ -27,7 +28,10 @@ func StartSpinner(quiet bool, stop <-chan struct{}) {
 	fmt.Print(DisableCursor)
 	defer fmt.Print(EnableCursor)
 
-	fmt.Print(ClearLine + Blue)
+	fmt.Print(ClearLine)
+	if stdoutColors {
+		fmt.Print(Blue)
+	}
 
 	for {
 		select {
@@ -61,6 +65,8 @@ func StopSpinner(quiet bool, stop chan struct{}) {
 		return //channel already closed, do nothing
 	default:
 		close(stop)
-		fmt.Print(ClearLine + ColorReset)
+		if stdoutTerminal {
+			fmt.Print(ClearLine + ColorReset)
+		}
 	}
 }
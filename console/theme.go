@@ -0,0 +1,297 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Theme holds the styles of the UI elements as Esc sequences.
+type Theme struct {
+	Prompt    string
+	Info      string
+	Warn      string
+	Error     string
+	Reasoning string
+	Status    string
+	Hint      string // input hint and the count of hidden completions
+	Heading   string // Markdown headings
+	Marker    string // list markers
+	Quote     string // text of block quotes
+	Code      string // code spans
+	Border    string // quote bars, code fences, rules and table separators
+	Label     string // language of code fences
+	Added     string // added lines in the /apply summary
+	Removed   string // removed lines in the /apply summary
+}
+
+// Themes are the named color themes.
+var Themes = map[string]Theme{
+	"default": {
+		Info:      Green + Bold,
+		Warn:      BrightYellow + Bold,
+		Error:     Red,
+		Reasoning: Gray256,
+		Status:    Yellow,
+		Hint:      Gray256,
+		Heading:   Bold + BrightBlue,
+		Marker:    Yellow,
+		Quote:     Gray256 + Italics,
+		Code:      Cyan,
+		Border:    Gray256,
+		Label:     Bold,
+		Added:     Green,
+		Removed:   Red,
+	},
+	"light": {
+		Prompt:    Blue,
+		Info:      Green + Bold,
+		Warn:      Magenta + Bold,
+		Error:     Red,
+		Reasoning: DarkGray256,
+		Status:    Blue,
+		Hint:      DarkGray256,
+		Heading:   Bold + Blue,
+		Marker:    Blue,
+		Quote:     DarkGray256 + Italics,
+		Code:      Magenta,
+		Border:    DarkGray256,
+		Label:     Bold,
+		Added:     Green,
+		Removed:   Red,
+	},
+	"mono": {
+		Prompt:    Bold,
+		Info:      Bold,
+		Warn:      Bold,
+		Error:     Bold,
+		Reasoning: Italics,
+		Hint:      Dim,
+		Heading:   Bold,
+		Marker:    Bold,
+		Quote:     Italics,
+		Code:      Underline,
+		Label:     Bold,
+	},
+}
+
+// Active theme and color output of stdout and stderr. Colors are on until
+// DetectColors checks the terminals.
+var (
+	theme          = Themes["default"]
+	stdoutTerminal = true
+	stdoutColors   = true
+	stderrColors   = true
+)
+
+// styleNames maps the names of colors and font styles to Esc sequences.
+var styleNames = map[string]string{
+	"black":          Black,
+	"red":            Red,
+	"green":          Green,
+	"yellow":         Yellow,
+	"blue":           Blue,
+	"magenta":        Magenta,
+	"cyan":           Cyan,
+	"white":          White,
+	"bright-black":   BrightBlack,
+	"bright-red":     BrightRed,
+	"bright-green":   BrightGreen,
+	"bright-yellow":  BrightYellow,
+	"bright-blue":    BrightBlue,
+	"bright-magenta": BrightMagenta,
+	"bright-cyan":    BrightCyan,
+	"bright-white":   BrightWhite,
+	"gray":           Gray256,
+	"grey":           Gray256,
+	"dark-gray":      DarkGray256,
+	"dark-grey":      DarkGray256,
+	"bold":           Bold,
+	"dim":            Dim,
+	"italic":         Italics,
+	"underline":      Underline,
+	"reverse":        Reverse,
+	"none":           "",
+}
+
+// ParseStyle converts a style spec of the config file into Esc sequences.
+// A spec is a list of color names, font styles, 256 color palette indexes
+// and #rrggbb truecolors, e.g. "bold yellow", "244" or "italic #808080".
+//
+// Parameters:
+//
+//	spec (string) - the style spec
+//
+// Returns:
+//
+//	string - the Esc sequences
+//	error  - error if a part of the spec is unknown
+func ParseStyle(spec string) (string, error) {
+	var style strings.Builder
+	parts := strings.FieldsFunc(strings.ToLower(spec), func(r rune) bool {
+		return r == ' ' || r == '+' || r == ','
+	})
+	for _, part := range parts {
+		if seq, ok := styleNames[strings.ReplaceAll(part, "_", "-")]; ok {
+			style.WriteString(seq)
+			continue
+		}
+		if n, err := strconv.ParseUint(part, 10, 8); err == nil {
+			fmt.Fprintf(&style, "\033[38;5;%dm", n)
+			continue
+		}
+		if hex, ok := strings.CutPrefix(part, "#"); ok && len(hex) == 6 {
+			if rgb, err := strconv.ParseUint(hex, 16, 32); err == nil {
+				style.WriteString(RGB(uint8(rgb>>16), uint8(rgb>>8), uint8(rgb)))
+				continue
+			}
+		}
+		return "", fmt.Errorf("unknown color or style %q", part)
+	}
+	return style.String(), nil
+}
+
+// SetTheme activates a named theme with the styles of single elements
+// replaced. Elements with an invalid style keep the style of the theme.
+//
+// Parameters:
+//
+//	name (string)                  - the name of the theme, empty for "default"
+//	overrides (map[string]string) - style specs by element, e.g. prompt, info, code or added
+//
+// Returns:
+//
+//	error - error if the theme, an element or a style is unknown
+func SetTheme(name string, overrides map[string]string) error {
+	if name == "" {
+		name = "default"
+	}
+	t, ok := Themes[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("unknown color theme %q", name)
+	}
+
+	elements := map[string]*string{
+		"prompt":    &t.Prompt,
+		"info":      &t.Info,
+		"warn":      &t.Warn,
+		"error":     &t.Error,
+		"reasoning": &t.Reasoning,
+		"status":    &t.Status,
+		"hint":      &t.Hint,
+		"heading":   &t.Heading,
+		"marker":    &t.Marker,
+		"quote":     &t.Quote,
+		"code":      &t.Code,
+		"border":    &t.Border,
+		"label":     &t.Label,
+		"added":     &t.Added,
+		"removed":   &t.Removed,
+	}
+	var errs []error
+	for _, element := range slices.Sorted(maps.Keys(overrides)) {
+		spec := overrides[element]
+		field, ok := elements[element]
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown color element %q", element))
+			continue
+		}
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		style, err := ParseStyle(spec)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("color of %s: %w", element, err))
+			continue
+		}
+		*field = style
+	}
+	theme = t
+	return errors.Join(errs...)
+}
+
+// ActiveTheme returns the styles of the active theme.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	Theme - the active theme
+func ActiveTheme() Theme {
+	return theme
+}
+
+// DetectColors checks stdout and stderr separately: only terminals get
+// colors and cursor controls. NO_COLOR turns colors off, CLICOLOR_FORCE
+// turns them on for files and pipes.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func DetectColors() {
+	stdoutTerminal = term.IsTerminal(int(os.Stdout.Fd()))
+	stdoutColors = colorsEnabled(stdoutTerminal)
+	stderrColors = colorsEnabled(term.IsTerminal(int(os.Stderr.Fd())))
+}
+
+// colorsEnabled decides whether an output stream gets colors.
+//
+// Parameters:
+//
+//	terminal (bool) - the stream is a terminal
+//
+// Returns:
+//
+//	bool - true if colors are used
+func colorsEnabled(terminal bool) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	return terminal
+}
+
+// StdoutColors reports whether stdout gets colors.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	bool - true if colors are written to stdout
+func StdoutColors() bool {
+	return stdoutColors
+}
+
+// paint encloses text in a style if the output stream gets colors.
+//
+// Parameters:
+//
+//	enabled (bool) - the output stream gets colors
+//	style (string) - esc sequences of the style
+//	text (string)  - the text
+//
+// Returns:
+//
+//	string - the styled text
+func paint(enabled bool, style, text string) string {
+	if !enabled || style == "" {
+		return text
+	}
+	return style + text + ColorReset
+}
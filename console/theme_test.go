@@ -0,0 +1,136 @@
+package console
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseStyle(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{spec: "", want: ""},
+		{spec: "none", want: ""},
+		{spec: "red", want: Red},
+		{spec: "Bold Yellow", want: Bold + Yellow},
+		{spec: "bold+bright_blue", want: Bold + BrightBlue},
+		{spec: "italic, gray", want: Italics + Gray256},
+		{spec: "244", want: "\033[38;5;244m"},
+		{spec: "#ff8000", want: "\033[38;2;255;128;0m"},
+		{spec: "256", wantErr: true},
+		{spec: "#ff80", wantErr: true},
+		{spec: "bold purple", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseStyle(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStyle(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseStyle(%q) = %q, want %q", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetTheme(t *testing.T) {
+	t.Cleanup(func() { theme = Themes["default"] })
+
+	if err := SetTheme("light", map[string]string{"reasoning": "245", "status": ""}); err != nil {
+		t.Fatalf("SetTheme returned error: %v", err)
+	}
+	if got := ActiveTheme(); got.Reasoning != "\033[38;5;245m" || got.Status != Themes["light"].Status || got.Prompt != Themes["light"].Prompt {
+		t.Errorf("ActiveTheme() = %q, want light theme with reasoning 245", got)
+	}
+
+	err := SetTheme("", map[string]string{"info": "blue", "warn": "purple", "title": "red"})
+	if err == nil || !strings.Contains(err.Error(), `color of warn: unknown color or style "purple"`) || !strings.Contains(err.Error(), `unknown color element "title"`) {
+		t.Fatalf("SetTheme error = %v, want errors of warn and title", err)
+	}
+	if got := ActiveTheme(); got.Info != Blue || got.Warn != Themes["default"].Warn {
+		t.Errorf("ActiveTheme() = %q, want default theme with blue info", got)
+	}
+
+	if err := SetTheme("neon", nil); err == nil {
+		t.Error("SetTheme(neon) returned no error")
+	}
+}
+
+func TestTheme_Elements(t *testing.T) {
+	t.Cleanup(func() { theme = Themes["default"] })
+
+	if err := SetTheme("mono", map[string]string{"code": "reverse", "removed": "italic"}); err != nil {
+		t.Fatalf("SetTheme returned error: %v", err)
+	}
+	if got := ActiveTheme(); got.Code != Reverse || got.Removed != Italics || got.Border != "" {
+		t.Errorf("ActiveTheme() = %q, want mono theme with reverse code", got)
+	}
+	if got := shadowText(); got != Dim+ShadowText+ColorReset {
+		t.Errorf("shadowText() = %q, want the dim hint", got)
+	}
+	many := slices.Repeat([]string{strings.Repeat("x", 30)}, 40)
+	if rows := formatCandidates(many, -1, 40); !strings.HasSuffix(rows[len(rows)-1], Dim+"(33 more)"+ColorReset) {
+		t.Errorf("overflow line = %q, want the dim hint style", rows[len(rows)-1])
+	}
+
+	if err := SetTheme("default", map[string]string{"hint": "none"}); err != nil {
+		t.Fatalf("SetTheme returned error: %v", err)
+	}
+	if got := shadowText(); got != "" {
+		t.Errorf("shadowText() = %q, want no hint without a style", got)
+	}
+}
+
+func TestColorsEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		noColor  string
+		force    string
+		terminal bool
+		want     bool
+	}{
+		{name: "terminal", terminal: true, want: true},
+		{name: "file", terminal: false, want: false},
+		{name: "NO_COLOR", noColor: "1", terminal: true, want: false},
+		{name: "CLICOLOR_FORCE", force: "1", terminal: false, want: true},
+		{name: "CLICOLOR_FORCE=0", force: "0", terminal: false, want: false},
+		{name: "NO_COLOR wins", noColor: "1", force: "1", terminal: true, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			t.Setenv("CLICOLOR_FORCE", tt.force)
+			if got := colorsEnabled(tt.terminal); got != tt.want {
+				t.Errorf("colorsEnabled(%v) = %v, want %v", tt.terminal, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColorize_NoColors(t *testing.T) {
+	t.Cleanup(func() { stdoutColors = true })
+
+	if got := Colorize(Red, "text"); got != Red+"text"+ColorReset {
+		t.Errorf("Colorize() = %q, want colored text", got)
+	}
+	if got := Colorize("", "text"); got != "text" {
+		t.Errorf("Colorize() without color = %q, want %q", got, "text")
+	}
+
+	stdoutColors = false
+	if got := Colorize(Red, "text"); got != "text" {
+		t.Errorf("Colorize() = %q, want %q", got, "text")
+	}
+	if got := Style(Bold, "text"); got != "text" {
+		t.Errorf("Style() = %q, want %q", got, "text")
+	}
+	if got := StyledPrompt(); got != Prompt {
+		t.Errorf("StyledPrompt() = %q, want %q", got, Prompt)
+	}
+}
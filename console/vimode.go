@@ -0,0 +1,570 @@
+package console
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Prompts of the vi mode show the editing state like the readline option
+// show-mode-in-prompt.
+const (
+	viInsertPrompt = "(ins) " + Prompt
+	viNormalPrompt = "(cmd) " + Prompt
+)
+
+var (
+	viMode    bool
+	cancelKey key = 3 // Ctrl+C
+
+	// viRegister holds the text of the last d, c, y or x command. It is
+	// shared by all inputs like the kill ring.
+	viRegister struct {
+		text     string
+		linewise bool
+	}
+)
+
+// viState is the vi mode state of an input.
+type viState struct {
+	normal  bool
+	pending key // operator (d, c or y) waiting for its motion
+	undo    []editorSnapshot
+}
+
+// editorSnapshot is an input state restored by u.
+type editorSnapshot struct {
+	lines [][]rune
+	row   int
+	col   int
+}
+
+// SetViMode enables the vi editing mode. Escape then switches to the normal
+// mode and the input is canceled with the cancel key.
+//
+// Parameters:
+//
+//	enabled (bool) - true for vi mode, false for the default Emacs bindings
+//
+// Returns:
+//
+//	none
+func SetViMode(enabled bool) {
+	viMode = enabled
+}
+
+// SetCancelKey sets the key that cancels the input in vi mode.
+//
+// Parameters:
+//
+//	name (string) - a control key such as "ctrl+c" or "ctrl+g"
+//
+// Returns:
+//
+//	error - error if the name is not a control key
+func SetCancelKey(name string) error {
+	letter, ok := strings.CutPrefix(strings.ToLower(strings.TrimSpace(name)), "ctrl+")
+	if !ok || len(letter) != 1 || letter[0] < 'a' || letter[0] > 'z' {
+		return fmt.Errorf("invalid cancel key %q, expected ctrl+<letter>", name)
+	}
+	cancelKey = key(letter[0]-'a') + 1
+	return nil
+}
+
+// InputPrompt returns the prompt shown at the start of an input.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - the prompt, with the insert mode indicator in vi mode
+func InputPrompt() string {
+	if viMode {
+		return viInsertPrompt
+	}
+	return Prompt
+}
+
+// StyledPrompt returns the input prompt in the color of the theme with the
+// hint of an empty input.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - the prompt for the terminal
+func StyledPrompt() string {
+	return Colorize(theme.Prompt, InputPrompt()) + shadowText()
+}
+
+// shadowText returns the hint shown in an empty input. Without colors the
+// hint could not be told apart from typed text, so it is left out.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - the hint for the terminal
+func shadowText() string {
+	if !stdoutColors || theme.Hint == "" {
+		return ""
+	}
+	return theme.Hint + ShadowText + ColorReset
+}
+
+// isCancelKey reports whether a key cancels the input. In vi mode Escape
+// is needed for the normal mode, so only the cancel key is used.
+//
+// Parameters:
+//
+//	k (key) - the pressed key
+//
+// Returns:
+//
+//	bool - true if the input is canceled
+func isCancelKey(k key) bool {
+	if viMode {
+		return k == cancelKey
+	}
+	return k == 3 || k == keyEscape
+}
+
+// prompt returns the prompt of the first input line.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - the prompt for the current editing state
+func (e *editor) prompt() string {
+	switch {
+	case e.vi == nil:
+		return Prompt
+	case e.vi.normal:
+		return viNormalPrompt
+	}
+	return viInsertPrompt
+}
+
+// viKey handles a key in vi normal mode and Escape in insert mode.
+//
+// Parameters:
+//
+//	k (key) - the pressed key
+//
+// Returns:
+//
+//	bool - true if the input is submitted (Enter)
+func (e *editor) viKey(k key) bool {
+	v := e.vi
+	if !v.normal {
+		e.viNormal()
+		return false
+	}
+	if op := v.pending; op != 0 {
+		v.pending = 0
+		e.viOperator(op, k)
+		e.viClamp()
+		return false
+	}
+
+	line := e.lines[e.row]
+	switch k {
+	case 'h', keyLeft:
+		e.col = prevGrapheme(line, e.col)
+	case 'l', keyRight:
+		e.col = nextGrapheme(line, e.col)
+	case 'j': // lines of the input, not terminal rows
+		if e.row < len(e.lines)-1 {
+			e.row++
+		} else {
+			e.edit(keyDown)
+		}
+	case 'k':
+		if e.row > 0 {
+			e.row--
+		} else {
+			e.edit(keyUp)
+		}
+	case keyDown, keyUp:
+		e.edit(k)
+	case 'w':
+		e.moveTo(e.viWordForward(position{e.row, e.col}))
+	case 'b':
+		e.moveTo(e.viWordBackward(position{e.row, e.col}))
+	case 'e':
+		e.moveTo(e.viWordEnd(position{e.row, e.col}))
+	case '0', keyHome:
+		e.col = 0
+	case '$', keyEnd:
+		e.col = len(line)
+	case 'x', keyDelete:
+		if e.col < len(line) {
+			e.pushUndo()
+			end := nextGrapheme(line, e.col)
+			viRegister.text, viRegister.linewise = string(line[e.col:end]), false
+			e.deleteRange(position{e.row, e.col}, position{e.row, end})
+		}
+	case 'p', 'P':
+		e.viPaste(k == 'p')
+	case 'u':
+		e.popUndo()
+	case 'i':
+		e.viInsert()
+	case 'a':
+		e.col = nextGrapheme(line, e.col)
+		e.viInsert()
+	case 'I':
+		e.col = 0
+		e.viInsert()
+	case 'A':
+		e.col = len(line)
+		e.viInsert()
+	case 'd', 'c', 'y':
+		v.pending = k
+	case 13, 10: // Enter
+		return true
+	}
+	e.viClamp()
+	return false
+}
+
+// viOperator applies d, c or y with a motion. Doubling the operator (dd,
+// cc, yy) works on the whole line. Word motions stop at the end of the
+// line.
+//
+// Parameters:
+//
+//	op (key)     - the operator: d, c or y
+//	motion (key) - the motion key
+//
+// Returns:
+//
+//	none
+func (e *editor) viOperator(op, motion key) {
+	line := e.lines[e.row]
+	cursor := position{e.row, e.col}
+	from, to := e.col, e.col
+
+	switch motion {
+	case op:
+		e.viLineOperator(op)
+		return
+	case 'h', keyLeft:
+		from = prevGrapheme(line, e.col)
+	case 'l', keyRight:
+		to = nextGrapheme(line, e.col)
+	case 'w':
+		if op == 'c' && e.col < len(line) && viClass(line[e.col]) != 0 {
+			to = nextGrapheme(line, e.viWordEnd(position{e.row, e.col - 1}).col) // cw changes to the end of the word
+		} else if p := e.viWordForward(cursor); p.row == e.row {
+			to = p.col
+		} else {
+			to = len(line)
+		}
+	case 'e':
+		if p := e.viWordEnd(cursor); p.row == e.row {
+			to = nextGrapheme(line, p.col)
+		} else {
+			to = len(line)
+		}
+	case 'b':
+		if p := e.viWordBackward(cursor); p.row == e.row {
+			from = p.col
+		} else {
+			from = 0
+		}
+	case '0', keyHome:
+		from = 0
+	case '$', keyEnd:
+		to = len(line)
+	default:
+		return // unknown motion cancels the operator
+	}
+	from, to = graphemeStart(line, from), graphemeEnd(line, to)
+
+	viRegister.text, viRegister.linewise = string(line[from:to]), false
+	switch op {
+	case 'y':
+		e.col = from
+	case 'd':
+		e.pushUndo()
+		e.deleteRange(position{e.row, from}, position{e.row, to})
+	case 'c':
+		e.pushUndo()
+		e.deleteRange(position{e.row, from}, position{e.row, to})
+		e.vi.normal = false
+	}
+}
+
+// viLineOperator applies dd, cc or yy to the line of the cursor.
+//
+// Parameters:
+//
+//	op (key) - the operator: d, c or y
+//
+// Returns:
+//
+//	none
+func (e *editor) viLineOperator(op key) {
+	viRegister.text, viRegister.linewise = string(e.lines[e.row]), true
+	switch op {
+	case 'd':
+		e.pushUndo()
+		if len(e.lines) == 1 {
+			e.lines[0] = nil
+		} else {
+			e.lines = slices.Delete(e.lines, e.row, e.row+1)
+			e.row = min(e.row, len(e.lines)-1)
+		}
+		e.col = 0
+	case 'c':
+		e.pushUndo()
+		e.lines[e.row] = nil
+		e.col = 0
+		e.vi.normal = false
+	}
+}
+
+// viPaste inserts the register after (p) or before (P) the cursor. Lines
+// of dd and yy are inserted below or above the cursor line.
+//
+// Parameters:
+//
+//	after (bool) - true for p, false for P
+//
+// Returns:
+//
+//	none
+func (e *editor) viPaste(after bool) {
+	if viRegister.text == "" && !viRegister.linewise {
+		return
+	}
+	e.pushUndo()
+
+	if viRegister.linewise {
+		row := e.row
+		if after {
+			row++
+		}
+		var lines [][]rune
+		for line := range strings.SplitSeq(viRegister.text, "\n") {
+			lines = append(lines, []rune(line))
+		}
+		e.lines = slices.Insert(e.lines, row, lines...)
+		e.row, e.col = row, 0
+		return
+	}
+
+	if after {
+		e.col = nextGrapheme(e.lines[e.row], e.col)
+	}
+	e.insertText(viRegister.text)
+	e.col = prevGrapheme(e.lines[e.row], e.col) // on the last pasted character
+}
+
+// viInsert switches to insert mode. The input before the insert can be
+// restored with u.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (e *editor) viInsert() {
+	e.pushUndo()
+	e.vi.normal = false
+}
+
+// viNormal switches to normal mode and moves the cursor onto the last
+// inserted character like vi. An insert without changes leaves no undo
+// step.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (e *editor) viNormal() {
+	v := e.vi
+	if n := len(v.undo); n > 0 && slices.EqualFunc(v.undo[n-1].lines, e.lines, slices.Equal) {
+		v.undo = v.undo[:n-1]
+	}
+	v.normal = true
+	e.col = prevGrapheme(e.lines[e.row], e.col)
+}
+
+// viClamp keeps the cursor on a character in normal mode.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (e *editor) viClamp() {
+	if e.vi.normal {
+		line := e.lines[e.row]
+		e.col = graphemeStart(line, min(e.col, prevGrapheme(line, len(line))))
+	}
+}
+
+// pushUndo records the input for u.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (e *editor) pushUndo() {
+	lines := make([][]rune, len(e.lines))
+	for i, line := range e.lines {
+		lines[i] = slices.Clone(line)
+	}
+	e.vi.undo = append(e.vi.undo, editorSnapshot{lines: lines, row: e.row, col: e.col})
+}
+
+// popUndo restores the input before the last change.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (e *editor) popUndo() {
+	n := len(e.vi.undo)
+	if n == 0 {
+		return
+	}
+	s := e.vi.undo[n-1]
+	e.vi.undo = e.vi.undo[:n-1]
+	e.lines, e.row, e.col = s.lines, s.row, s.col
+}
+
+// viWordForward returns the start of the next word (w). Words are runs of
+// letters, digits and underscores or runs of other non-blank characters.
+//
+// Parameters:
+//
+//	p (position) - the cursor position
+//
+// Returns:
+//
+//	position - the start of the next word
+func (e *editor) viWordForward(p position) position {
+	line := e.lines[p.row]
+	if p.col < len(line) {
+		if c := viClass(line[p.col]); c != 0 {
+			for p.col < len(line) && viClass(line[p.col]) == c {
+				p.col++
+			}
+		}
+	}
+	for {
+		line = e.lines[p.row]
+		for p.col < len(line) && viClass(line[p.col]) == 0 {
+			p.col++
+		}
+		if p.col < len(line) || p.row == len(e.lines)-1 {
+			return p
+		}
+		p = position{p.row + 1, 0}
+		if len(e.lines[p.row]) == 0 {
+			return p // an empty line counts as a word
+		}
+	}
+}
+
+// viWordBackward returns the start of the word before the cursor (b).
+//
+// Parameters:
+//
+//	p (position) - the cursor position
+//
+// Returns:
+//
+//	position - the start of the previous word
+func (e *editor) viWordBackward(p position) position {
+	for {
+		line := e.lines[p.row]
+		for p.col > 0 && viClass(line[p.col-1]) == 0 {
+			p.col--
+		}
+		if p.col > 0 {
+			c := viClass(line[p.col-1])
+			for p.col > 0 && viClass(line[p.col-1]) == c {
+				p.col--
+			}
+			return p
+		}
+		if p.row == 0 {
+			return p
+		}
+		p = position{p.row - 1, len(e.lines[p.row-1])}
+		if len(e.lines[p.row]) == 0 {
+			return p
+		}
+	}
+}
+
+// viWordEnd returns the last character of the next word end (e).
+//
+// Parameters:
+//
+//	p (position) - the cursor position
+//
+// Returns:
+//
+//	position - the end of the word
+func (e *editor) viWordEnd(p position) position {
+	p.col++
+	for {
+		line := e.lines[p.row]
+		for p.col < len(line) && viClass(line[p.col]) == 0 {
+			p.col++
+		}
+		if p.col < len(line) {
+			break
+		}
+		if p.row == len(e.lines)-1 {
+			return position{p.row, max(len(line)-1, 0)}
+		}
+		p = position{p.row + 1, 0}
+	}
+	line := e.lines[p.row]
+	c := viClass(line[p.col])
+	for p.col+1 < len(line) && viClass(line[p.col+1]) == c {
+		p.col++
+	}
+	return p
+}
+
+// viClass returns the character class for vi word motions: 0 for blanks,
+// 1 for word characters and 2 for other characters.
+func viClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case isWordRune(r):
+		return 1
+	}
+	return 2
+}
@@ -0,0 +1,189 @@
+package console
+
+import "testing"
+
+func newViEditor(text string, col int) *editor {
+	e := newTestEditor(text, 0, col)
+	e.vi = &viState{normal: true}
+	return e
+}
+
+func viKeys(e *editor, keys string) {
+	for _, r := range keys {
+		k := key(r)
+		if r == '\x1b' {
+			k = keyEscape
+		}
+		if e.vi.normal || k == keyEscape {
+			e.viKey(k)
+		} else {
+			e.edit(k)
+		}
+	}
+}
+
+func TestViMode_Motions(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		col     int
+		keys    string
+		wantRow int
+		wantCol int
+	}{
+		{"h and l", "hello", 2, "hhl", 0, 1},
+		{"l stops on last char", "abc", 1, "lll", 0, 2},
+		{"w", "foo.bar baz", 0, "ww", 0, 4},
+		{"w to word", "foo.bar baz", 4, "w", 0, 8},
+		{"b", "foo bar baz", 10, "bb", 0, 4},
+		{"e", "foo bar", 0, "e", 0, 2},
+		{"e twice", "foo bar", 0, "ee", 0, 6},
+		{"0 and $", "hello world", 4, "$", 0, 10},
+		{"0", "hello world", 4, "0", 0, 0},
+		{"w across lines", "foo\n  bar", 0, "w", 1, 2},
+		{"b across lines", "foo\nbar", 0, "j0b", 0, 0},
+		{"j and k", "first\nsecond", 4, "jk", 0, 4},
+		{"l over emoji", "a😊👍🏽b", 1, "ll", 0, 4},
+		{"$ on emoji", "ab👍🏽", 0, "$", 0, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newViEditor(tt.text, tt.col)
+			viKeys(e, tt.keys)
+			if e.row != tt.wantRow || e.col != tt.wantCol {
+				t.Fatalf("cursor at %d:%d, want %d:%d", e.row, e.col, tt.wantRow, tt.wantCol)
+			}
+		})
+	}
+}
+
+func TestViMode_Operators(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		col      int
+		keys     string
+		wantText string
+		wantCol  int
+		wantReg  string
+	}{
+		{"x", "abc", 1, "x", "ac", 1, "b"},
+		{"x at end", "abc", 2, "x", "ab", 1, "c"},
+		{"dw", "foo bar baz", 0, "dw", "bar baz", 0, "foo "},
+		{"dw last word", "foo bar", 4, "dw", "foo ", 3, "bar"},
+		{"de", "foo bar", 0, "de", " bar", 0, "foo"},
+		{"db", "foo bar", 4, "db", "bar", 0, "foo "},
+		{"d$", "foo bar", 3, "d$", "foo", 2, " bar"},
+		{"d0", "foo bar", 4, "d0", "bar", 0, "foo "},
+		{"dd", "foo bar", 4, "dd", "", 0, "foo bar"},
+		{"cw", "foo bar", 0, "cwxyz\x1b", "xyz bar", 2, "foo"},
+		{"cc", "foo bar", 2, "ccnew\x1b", "new", 2, "foo bar"},
+		{"yw and p", "foo bar", 0, "ywP", "foo foo bar", 3, "foo "},
+		{"x and p", "abc", 0, "xp", "bac", 1, "a"},
+		{"unknown motion", "abc", 0, "dz", "abc", 0, ""},
+		{"x on emoji", "👍🏽!", 0, "x", "!", 0, "👍🏽"},
+		{"dl on accent", "e\u0301x", 0, "dl", "x", 0, "e\u0301"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viRegister.text, viRegister.linewise = "", false
+			e := newViEditor(tt.text, tt.col)
+			viKeys(e, tt.keys)
+			if e.text() != tt.wantText || e.col != tt.wantCol {
+				t.Fatalf("text = %q at %d, want %q at %d", e.text(), e.col, tt.wantText, tt.wantCol)
+			}
+			if viRegister.text != tt.wantReg {
+				t.Fatalf("register = %q, want %q", viRegister.text, tt.wantReg)
+			}
+			if !e.vi.normal {
+				t.Fatal("expected normal mode")
+			}
+		})
+	}
+}
+
+func TestViMode_LinewisePaste(t *testing.T) {
+	e := newViEditor("one\ntwo", 0)
+	viKeys(e, "yyjp")
+	if e.text() != "one\ntwo\none" || e.row != 2 {
+		t.Fatalf("p = %q at row %d", e.text(), e.row)
+	}
+	viKeys(e, "ddP")
+	if e.text() != "one\none\ntwo" || e.row != 1 {
+		t.Fatalf("ddP = %q at row %d", e.text(), e.row)
+	}
+}
+
+func TestViMode_InsertAndUndo(t *testing.T) {
+	e := newEditor()
+	e.vi = &viState{}
+	e.pushUndo()
+
+	viKeys(e, "hello\x1b")
+	if e.text() != "hello" || e.col != 4 || e.prompt() != viNormalPrompt {
+		t.Fatalf("after insert: %q at %d, prompt %q", e.text(), e.col, e.prompt())
+	}
+	viKeys(e, "A world\x1b")
+	viKeys(e, "0x")
+	if e.text() != "ello world" {
+		t.Fatalf("after edits: %q", e.text())
+	}
+
+	viKeys(e, "u")
+	if e.text() != "hello world" {
+		t.Fatalf("undo x: %q", e.text())
+	}
+	viKeys(e, "u")
+	if e.text() != "hello" {
+		t.Fatalf("undo append: %q", e.text())
+	}
+	viKeys(e, "i\x1bu") // an insert without changes is no undo step
+	if e.text() != "" {
+		t.Fatalf("undo insert: %q", e.text())
+	}
+	viKeys(e, "u")
+	if e.text() != "" {
+		t.Fatalf("undo without steps: %q", e.text())
+	}
+
+	viKeys(e, "i")
+	if e.prompt() != viInsertPrompt {
+		t.Fatalf("prompt = %q, want insert indicator", e.prompt())
+	}
+}
+
+func TestViMode_EnterSubmits(t *testing.T) {
+	e := newViEditor("hello", 0)
+	if !e.viKey(13) {
+		t.Fatal("Enter in normal mode did not submit")
+	}
+}
+
+func TestCancelKey(t *testing.T) {
+	prevMode, prevKey := viMode, cancelKey
+	t.Cleanup(func() { viMode, cancelKey = prevMode, prevKey })
+
+	viMode = false
+	if !isCancelKey(keyEscape) || !isCancelKey(3) {
+		t.Fatal("Escape and Ctrl+C must cancel without vi mode")
+	}
+
+	viMode = true
+	if err := SetCancelKey("Ctrl+G"); err != nil {
+		t.Fatalf("SetCancelKey() error: %v", err)
+	}
+	if isCancelKey(keyEscape) || isCancelKey(3) || !isCancelKey(7) {
+		t.Fatal("only Ctrl+G must cancel in vi mode")
+	}
+	if InputPrompt() != viInsertPrompt {
+		t.Fatalf("InputPrompt() = %q", InputPrompt())
+	}
+
+	for _, name := range []string{"esc", "ctrl+", "ctrl+1", "alt+x"} {
+		if err := SetCancelKey(name); err == nil {
+			t.Errorf("SetCancelKey(%q) succeeded", name)
+		}
+	}
+}
@@ -30,6 +30,8 @@ var ConfigEnvVars = []EnvSpec{
 	{Env: "PICOCHAT_REASONING", Type: vartypes.VarBool, Field: "Reasoning", JsonField: "reasoning", Runtime: true},
 	{Env: "PICOCHAT_EFFORT", Type: vartypes.VarString, Field: "Effort", JsonField: "effort", Runtime: true},
 	{Env: "PICOCHAT_VALIDATE", Type: vartypes.VarBool, Field: "Validate", JsonField: "validate", Runtime: true},
+	{Env: "PICOCHAT_MARKDOWN", Type: vartypes.VarBool, Field: "Markdown", JsonField: "markdown", Runtime: true},
+	{Env: "PICOCHAT_HIGHLIGHT", Type: vartypes.VarString, Field: "Highlight", JsonField: "highlight", Runtime: true},
 	{Env: "PICOCHAT_QUIET", Type: vartypes.VarBool, Field: "Quiet", JsonField: "quiet"},
 }
 
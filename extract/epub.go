@@ -0,0 +1,102 @@
+package extract
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type epubPackage struct {
+	Items []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// EPUB extracts the text of an e-book in reading order. Each document of
+// the spine starts with a "--- Section <n> ---" marker.
+//
+// Parameters:
+//
+//	data ([]byte) - the EPUB file content
+//
+// Returns:
+//
+//	string - the extracted text
+//	error  - error if the file is invalid or contains no text
+func EPUB(data []byte) (string, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := readZipEntry(zr, "META-INF/container.xml")
+	if err != nil {
+		return "", err
+	}
+	var container epubContainer
+	if err := xml.Unmarshal(raw, &container); err != nil {
+		return "", fmt.Errorf("parse container failed: %w", err)
+	}
+	if len(container.Rootfiles) == 0 {
+		return "", fmt.Errorf("no package document found")
+	}
+
+	opfPath := container.Rootfiles[0].FullPath
+	raw, err = readZipEntry(zr, opfPath)
+	if err != nil {
+		return "", err
+	}
+	var pkg epubPackage
+	if err := xml.Unmarshal(raw, &pkg); err != nil {
+		return "", fmt.Errorf("parse package document failed: %w", err)
+	}
+
+	hrefs := make(map[string]string, len(pkg.Items))
+	for _, item := range pkg.Items {
+		hrefs[item.ID] = item.Href
+	}
+
+	var (
+		sb      strings.Builder
+		section int
+	)
+	for _, ref := range pkg.Spine {
+		href, ok := hrefs[ref.IDRef]
+		if !ok {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(href); err == nil {
+			href = unescaped
+		}
+
+		doc, err := readZipEntry(zr, path.Join(path.Dir(opfPath), href))
+		if err != nil {
+			return "", err
+		}
+		text := cleanText(htmlToText(string(doc)))
+		if text == "" {
+			continue // cover pages and other image-only documents
+		}
+
+		section++
+		fmt.Fprintf(&sb, "--- Section %d ---\n%s\n\n", section, text)
+	}
+
+	if section == 0 {
+		return "", fmt.Errorf("no text found")
+	}
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
@@ -0,0 +1,131 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxEntrySize limits the uncompressed size of a single archive entry.
+const maxEntrySize = 64 << 20
+
+var extractors = map[string]func([]byte) (string, error){
+	".pdf":   PDF,
+	".docx":  DOCX,
+	".odt":   ODT,
+	".html":  HTML,
+	".htm":   HTML,
+	".xhtml": HTML,
+	".epub":  EPUB,
+}
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// IsDocument checks if a file is a document with extractable text.
+//
+// Parameters:
+//
+//	path (string) - the file path
+//
+// Returns:
+//
+//	bool - true for PDF, DOCX, ODT, HTML and EPUB files
+func IsDocument(path string) bool {
+	_, ok := extractors[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// Text extracts the plain text of a document. The format is selected by
+// the file extension.
+//
+// Parameters:
+//
+//	path (string) - the file path (used for the format only)
+//	data ([]byte) - the file content
+//
+// Returns:
+//
+//	string - the extracted text
+//	error  - error if the format is unsupported or the file is invalid
+func Text(path string, data []byte) (string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	fn, ok := extractors[ext]
+	if !ok {
+		return "", fmt.Errorf("unsupported document type %q", ext)
+	}
+
+	text, err := fn(data)
+	if err != nil {
+		return "", fmt.Errorf("extract %s text failed: %w", strings.TrimPrefix(ext, "."), err)
+	}
+	return text, nil
+}
+
+// cleanText trims trailing spaces of each line and collapses runs of
+// blank lines.
+//
+// Parameters:
+//
+//	s (string) - the raw text
+//
+// Returns:
+//
+//	string - the cleaned text
+func cleanText(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\u00a0")
+	}
+	s = blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.Trim(s, "\n")
+}
+
+// openZip opens an in-memory zip archive.
+//
+// Parameters:
+//
+//	data ([]byte) - the archive content
+//
+// Returns:
+//
+//	*zip.Reader - the archive reader
+//	error       - error if the data is not a zip archive
+func openZip(data []byte) (*zip.Reader, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open archive failed: %w", err)
+	}
+	return zr, nil
+}
+
+// readZipEntry reads a file of a zip archive with a size limit.
+//
+// Parameters:
+//
+//	zr (*zip.Reader) - the archive
+//	name (string)    - the entry name
+//
+// Returns:
+//
+//	[]byte - the entry content
+//	error  - error if the entry is missing or too large
+func readZipEntry(zr *zip.Reader, name string) ([]byte, error) {
+	f, err := zr.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("archive entry %q not found", name)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxEntrySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read archive entry %q failed: %w", name, err)
+	}
+	if len(data) > maxEntrySize {
+		return nil, fmt.Errorf("archive entry %q too large", name)
+	}
+	return data, nil
+}
@@ -0,0 +1,171 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// buildZip creates an in-memory zip archive.
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create zip entry failed: %v", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("write zip entry failed: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestIsDocument(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"report.pdf", true},
+		{"Report.PDF", true},
+		{"letter.docx", true},
+		{"notes.odt", true},
+		{"page.htm", true},
+		{"book.epub", true},
+		{"main.go", false},
+		{"letter.doc", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsDocument(tt.path); got != tt.want {
+			t.Errorf("IsDocument(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestText_Unsupported(t *testing.T) {
+	if _, err := Text("x.doc", []byte("data")); err == nil {
+		t.Fatal("expected error for unsupported type")
+	}
+	if _, err := Text("x.docx", []byte("not a zip")); err == nil || !strings.Contains(err.Error(), "docx") {
+		t.Fatalf("expected docx error, got %v", err)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			"structure",
+			`<html><head><title>T</title><style>p{}</style></head><body>
+<h1>Title</h1><p>Hello <b>big</b>
+  world &amp; more</p><ul><li>one</li><li>two</li></ul>
+<script>var x = "<p>";</script><p>Line<br>break</p></body></html>`,
+			"# Title\n\nHello big world & more\n\n- one\n- two\n\nLine\nbreak",
+		},
+		{
+			"pre keeps white space",
+			"<p>code:</p><pre>a  b\n  c</pre>",
+			"code:\n\na  b\n  c",
+		},
+		{
+			"comments and attributes",
+			`<div data-x="a>b"><!-- <p>hidden</p> -->shown</div>`,
+			"shown",
+		},
+		{
+			"table cells",
+			"<table><tr><td>a</td><td>b</td></tr><tr><td>c</td></tr></table>",
+			"a\tb\nc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HTML([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("HTML failed: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("HTML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDOCX(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:body>
+<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Intro</w:t></w:r></w:p>
+<w:p><w:r><w:t xml:space="preserve">Hello </w:t></w:r><w:r><w:t>world</w:t><w:tab/><w:t>!</w:t></w:r></w:p>
+<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/></w:numPr></w:pPr><w:r><w:t>item</w:t></w:r></w:p>
+<w:p><w:r><w:br w:type="page"/><w:t>Next page</w:t></w:r></w:p>
+</w:body></w:document>`
+	data := buildZip(t, map[string]string{"word/document.xml": doc})
+
+	got, err := DOCX(data)
+	if err != nil {
+		t.Fatalf("DOCX failed: %v", err)
+	}
+	want := "# Intro\n\nHello world\t!\n\n- item\n\n--- Page break ---\n\nNext page"
+	if got != want {
+		t.Fatalf("DOCX() = %q, want %q", got, want)
+	}
+}
+
+func TestODT(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+<office:body><office:text>
+<text:h text:outline-level="2">Section</text:h>
+<text:p>a<text:s text:c="3"/>b<text:line-break/>c</text:p>
+<text:list><text:list-item><text:p>point</text:p></text:list-item></text:list>
+</office:text></office:body></office:document-content>`
+	data := buildZip(t, map[string]string{"content.xml": content})
+
+	got, err := ODT(data)
+	if err != nil {
+		t.Fatalf("ODT failed: %v", err)
+	}
+	want := "## Section\n\na   b\nc\n\n- point"
+	if got != want {
+		t.Fatalf("ODT() = %q, want %q", got, want)
+	}
+}
+
+func TestEPUB(t *testing.T) {
+	data := buildZip(t, map[string]string{
+		"META-INF/container.xml": `<?xml version="1.0"?>
+<container xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`,
+		"OEBPS/content.opf": `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf"><manifest>
+<item id="cover" href="cover.xhtml" media-type="application/xhtml+xml"/>
+<item id="c2" href="text/chapter%202.xhtml" media-type="application/xhtml+xml"/>
+<item id="c1" href="text/ch1.xhtml" media-type="application/xhtml+xml"/>
+</manifest><spine><itemref idref="cover"/><itemref idref="c1"/><itemref idref="c2"/></spine></package>`,
+		"OEBPS/cover.xhtml":          `<html><body><img src="cover.jpg"/></body></html>`,
+		"OEBPS/text/ch1.xhtml":       `<html><body><h1>One</h1><p>First.</p></body></html>`,
+		"OEBPS/text/chapter 2.xhtml": `<html><body><p>Second.</p></body></html>`,
+	})
+
+	got, err := EPUB(data)
+	if err != nil {
+		t.Fatalf("EPUB failed: %v", err)
+	}
+	want := "--- Section 1 ---\n# One\n\nFirst.\n\n--- Section 2 ---\nSecond."
+	if got != want {
+		t.Fatalf("EPUB() = %q, want %q", got, want)
+	}
+}
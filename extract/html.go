@@ -0,0 +1,295 @@
+package extract
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// skippedTags have content that is not part of the readable text.
+var skippedTags = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true,
+	"template": true, "svg": true, "math": true, "iframe": true,
+}
+
+// blockTags start a new line.
+var blockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figcaption": true,
+	"figure": true, "footer": true, "form": true, "header": true, "hr": true,
+	"main": true, "nav": true, "ol": true, "p": true, "section": true,
+	"table": true, "tbody": true, "thead": true, "tr": true, "ul": true,
+}
+
+// htmlText collects the text of an HTML document.
+type htmlText struct {
+	sb  strings.Builder
+	pre int // depth of <pre> elements
+}
+
+// HTML extracts the readable text of an HTML document. Headings are
+// marked with "#" and list items with "-".
+//
+// Parameters:
+//
+//	data ([]byte) - the HTML content
+//
+// Returns:
+//
+//	string - the extracted text
+//	error  - error if the document contains no text
+func HTML(data []byte) (string, error) {
+	text := cleanText(htmlToText(string(data)))
+	if text == "" {
+		return "", fmt.Errorf("no text found")
+	}
+	return text, nil
+}
+
+// htmlToText converts HTML markup into plain text.
+//
+// Parameters:
+//
+//	s (string) - the HTML content
+//
+// Returns:
+//
+//	string - the raw text
+func htmlToText(s string) string {
+	var t htmlText
+	for len(s) > 0 {
+		lt := strings.IndexByte(s, '<')
+		if lt < 0 {
+			t.text(s)
+			break
+		}
+		t.text(s[:lt])
+		s = s[lt:]
+
+		switch {
+		case strings.HasPrefix(s, "<!--"):
+			s = skipPast(s, "-->")
+			continue
+		case strings.HasPrefix(s, "<!"), strings.HasPrefix(s, "<?"):
+			s = skipPast(s, ">")
+			continue
+		}
+
+		name, closing, selfClosing, rest, ok := parseTag(s)
+		if !ok {
+			t.text("<")
+			s = s[1:]
+			continue
+		}
+		s = rest
+
+		if skippedTags[name] && !closing && !selfClosing {
+			s = skipElement(s, name)
+			continue
+		}
+		t.tag(name, closing)
+	}
+	return t.sb.String()
+}
+
+// parseTag reads a start or end tag at the beginning of s.
+//
+// Parameters:
+//
+//	s (string) - input starting with "<"
+//
+// Returns:
+//
+//	string - lower-case tag name
+//	bool   - true for an end tag
+//	bool   - true for a self-closing tag
+//	string - the input after the tag
+//	bool   - false if s does not start with a tag
+func parseTag(s string) (string, bool, bool, string, bool) {
+	i := 1
+	closing := i < len(s) && s[i] == '/'
+	if closing {
+		i++
+	}
+	start := i
+	for i < len(s) && (isASCIILetter(s[i]) || (i > start && s[i] >= '0' && s[i] <= '9') || s[i] == ':' || s[i] == '-') {
+		i++
+	}
+	if i == start {
+		return "", false, false, s, false
+	}
+	name := strings.ToLower(s[start:i])
+	if j := strings.LastIndexByte(name, ':'); j >= 0 {
+		name = name[j+1:] // XHTML namespace prefix
+	}
+
+	// find the end of the tag outside of quoted attribute values
+	var quote byte
+	for ; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			selfClosing := s[i-1] == '/'
+			return name, closing, selfClosing, s[i+1:], true
+		}
+	}
+	return name, closing, false, "", true
+}
+
+// tag writes the line structure for an element boundary.
+//
+// Parameters:
+//
+//	name (string)  - lower-case tag name
+//	closing (bool) - true for an end tag
+//
+// Returns:
+//
+//	none
+func (t *htmlText) tag(name string, closing bool) {
+	switch {
+	case name == "br":
+		t.sb.WriteString("\n")
+	case name == "pre":
+		t.sb.WriteString("\n")
+		if closing {
+			t.pre = max(0, t.pre-1)
+		} else {
+			t.pre++
+		}
+	case len(name) == 2 && name[0] == 'h' && name[1] >= '1' && name[1] <= '6':
+		t.sb.WriteString("\n\n")
+		if !closing {
+			t.sb.WriteString(strings.Repeat("#", int(name[1]-'0')) + " ")
+		}
+	case name == "li":
+		if !closing {
+			t.sb.WriteString("\n- ")
+		}
+	case name == "td" || name == "th":
+		if closing {
+			t.sb.WriteString("\t")
+		}
+	case name == "p":
+		t.sb.WriteString("\n\n")
+	case blockTags[name]:
+		if !strings.HasSuffix(t.sb.String(), "\n") {
+			t.sb.WriteString("\n")
+		}
+	}
+}
+
+// text writes a text node. White space is collapsed outside of <pre>.
+//
+// Parameters:
+//
+//	s (string) - the raw text node
+//
+// Returns:
+//
+//	none
+func (t *htmlText) text(s string) {
+	if s == "" {
+		return
+	}
+	s = html.UnescapeString(s)
+	if t.pre > 0 {
+		t.sb.WriteString(s)
+		return
+	}
+
+	body := strings.Join(strings.Fields(s), " ")
+	if body == "" || isHTMLSpace(s[0]) {
+		t.separate()
+	}
+	t.sb.WriteString(body)
+	if body != "" && isHTMLSpace(s[len(s)-1]) {
+		t.sb.WriteByte(' ')
+	}
+}
+
+// separate writes a space unless the text ends with white space.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (t *htmlText) separate() {
+	if out := t.sb.String(); out != "" && !strings.HasSuffix(out, " ") && !strings.HasSuffix(out, "\n") {
+		t.sb.WriteByte(' ')
+	}
+}
+
+// skipPast returns the input after the next occurrence of end.
+//
+// Parameters:
+//
+//	s (string)   - the input
+//	end (string) - the terminator
+//
+// Returns:
+//
+//	string - the remaining input (empty if end is missing)
+func skipPast(s, end string) string {
+	if i := strings.Index(s, end); i >= 0 {
+		return s[i+len(end):]
+	}
+	return ""
+}
+
+// skipElement skips the content of an element up to its end tag.
+//
+// Parameters:
+//
+//	s (string)    - the input after the start tag
+//	name (string) - lower-case tag name
+//
+// Returns:
+//
+//	string - the input after the end tag
+func skipElement(s, name string) string {
+	i := strings.Index(strings.ToLower(s), "</"+name)
+	if i < 0 {
+		return ""
+	}
+	return skipPast(s[i:], ">")
+}
+
+// isASCIILetter reports whether c is an ASCII letter.
+//
+// Parameters:
+//
+//	c (byte) - the character
+//
+// Returns:
+//
+//	bool
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isHTMLSpace reports whether c is HTML white space.
+//
+// Parameters:
+//
+//	c (byte) - the character
+//
+// Returns:
+//
+//	bool
+func isHTMLSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', '\f':
+		return true
+	}
+	return false
+}
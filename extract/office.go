@@ -0,0 +1,374 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// paragraph is an open paragraph while walking an office document.
+type paragraph struct {
+	sb      strings.Builder
+	heading int
+	list    bool
+}
+
+// officeText collects paragraphs of an office document. Paragraphs can be
+// nested (e.g. footnotes inside a paragraph), so open paragraphs form a
+// stack.
+type officeText struct {
+	out   strings.Builder
+	stack []*paragraph
+}
+
+// DOCX extracts the text of a Word document. Headings are marked with
+// "#", list paragraphs with "-" and page breaks with a marker line.
+//
+// Parameters:
+//
+//	data ([]byte) - the DOCX file content
+//
+// Returns:
+//
+//	string - the extracted text
+//	error  - error if the file is invalid or contains no text
+func DOCX(data []byte) (string, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return "", err
+	}
+	doc, err := readZipEntry(zr, "word/document.xml")
+	if err != nil {
+		return "", err
+	}
+
+	var t officeText
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("parse document failed: %w", err)
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "p":
+				t.open()
+			case "pStyle":
+				t.setHeading(docxHeadingLevel(xmlAttr(el, "val")))
+			case "numPr":
+				t.setList()
+			case "t":
+				inText = true
+			case "tab":
+				t.write("\t")
+			case "br", "cr":
+				if xmlAttr(el, "type") == "page" {
+					t.pageBreak()
+				} else {
+					t.write("\n")
+				}
+			}
+		case xml.EndElement:
+			switch el.Name.Local {
+			case "p":
+				t.close()
+			case "t":
+				inText = false
+			case "tc":
+				t.write("\t")
+			}
+		case xml.CharData:
+			if inText {
+				t.write(string(el))
+			}
+		}
+	}
+
+	return t.result()
+}
+
+// ODT extracts the text of an OpenDocument text file. Headings are marked
+// with "#" and list paragraphs with "-".
+//
+// Parameters:
+//
+//	data ([]byte) - the ODT file content
+//
+// Returns:
+//
+//	string - the extracted text
+//	error  - error if the file is invalid or contains no text
+func ODT(data []byte) (string, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return "", err
+	}
+	content, err := readZipEntry(zr, "content.xml")
+	if err != nil {
+		return "", err
+	}
+
+	var t officeText
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	listDepth := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("parse document failed: %w", err)
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "p":
+				t.open()
+				if listDepth > 0 {
+					t.setList()
+				}
+			case "h":
+				t.open()
+				level, err := strconv.Atoi(xmlAttr(el, "outline-level"))
+				if err != nil || level < 1 {
+					level = 1
+				}
+				t.setHeading(min(level, 6))
+			case "list-item":
+				listDepth++
+			case "s":
+				count, err := strconv.Atoi(xmlAttr(el, "c"))
+				if err != nil || count < 1 {
+					count = 1
+				}
+				t.write(strings.Repeat(" ", min(count, 80)))
+			case "tab":
+				t.write("\t")
+			case "line-break":
+				t.write("\n")
+			}
+		case xml.EndElement:
+			switch el.Name.Local {
+			case "p", "h":
+				t.close()
+			case "list-item":
+				listDepth--
+			case "table-cell":
+				t.write("\t")
+			}
+		case xml.CharData:
+			t.write(string(el))
+		}
+	}
+
+	return t.result()
+}
+
+// open starts a new paragraph.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (t *officeText) open() {
+	t.stack = append(t.stack, &paragraph{})
+}
+
+// current returns the innermost open paragraph or nil.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	*paragraph
+func (t *officeText) current() *paragraph {
+	if len(t.stack) == 0 {
+		return nil
+	}
+	return t.stack[len(t.stack)-1]
+}
+
+// write appends text to the open paragraph. Text outside of paragraphs
+// (e.g. white space between elements) is ignored.
+//
+// Parameters:
+//
+//	s (string) - the text
+//
+// Returns:
+//
+//	none
+func (t *officeText) write(s string) {
+	if p := t.current(); p != nil {
+		p.sb.WriteString(s)
+	}
+}
+
+// setHeading marks the open paragraph as heading.
+//
+// Parameters:
+//
+//	level (int) - heading level (0 for none)
+//
+// Returns:
+//
+//	none
+func (t *officeText) setHeading(level int) {
+	if p := t.current(); p != nil {
+		p.heading = level
+	}
+}
+
+// setList marks the open paragraph as list item.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (t *officeText) setList() {
+	if p := t.current(); p != nil {
+		p.list = true
+	}
+}
+
+// pageBreak writes a page break marker between paragraphs.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (t *officeText) pageBreak() {
+	p := t.current()
+	if p == nil {
+		return
+	}
+	text := p.sb.String()
+	p.sb.Reset()
+	if strings.TrimSpace(text) != "" {
+		t.emit(p, text)
+	}
+	t.out.WriteString("--- Page break ---\n\n")
+}
+
+// close ends the open paragraph and writes it to the output.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (t *officeText) close() {
+	p := t.current()
+	if p == nil {
+		return
+	}
+	t.stack = t.stack[:len(t.stack)-1]
+	t.emit(p, p.sb.String())
+}
+
+// emit writes paragraph text with its heading or list prefix.
+//
+// Parameters:
+//
+//	p (*paragraph) - the paragraph
+//	text (string)  - the paragraph text
+//
+// Returns:
+//
+//	none
+func (t *officeText) emit(p *paragraph, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	switch {
+	case p.heading > 0:
+		t.out.WriteString(strings.Repeat("#", p.heading) + " ")
+	case p.list:
+		t.out.WriteString("- ")
+	}
+	t.out.WriteString(text)
+	t.out.WriteString("\n\n")
+}
+
+// result returns the cleaned output.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - the extracted text
+//	error  - error if the document contains no text
+func (t *officeText) result() (string, error) {
+	text := cleanText(t.out.String())
+	if text == "" {
+		return "", fmt.Errorf("no text found")
+	}
+	return text, nil
+}
+
+// docxHeadingLevel maps a paragraph style to a heading level.
+//
+// Parameters:
+//
+//	style (string) - the style ID (e.g. "Heading2", "Title")
+//
+// Returns:
+//
+//	int - heading level or 0
+func docxHeadingLevel(style string) int {
+	style = strings.ToLower(style)
+	if style == "title" {
+		return 1
+	}
+	if rest, ok := strings.CutPrefix(style, "heading"); ok {
+		if level, err := strconv.Atoi(rest); err == nil && level >= 1 {
+			return min(level, 6)
+		}
+	}
+	return 0
+}
+
+// xmlAttr returns an attribute value by local name.
+//
+// Parameters:
+//
+//	el (xml.StartElement) - the element
+//	name (string)         - local attribute name
+//
+// Returns:
+//
+//	string - the value or empty string
+func xmlAttr(el xml.StartElement, name string) string {
+	for _, attr := range el.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
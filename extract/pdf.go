@@ -0,0 +1,696 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrEncrypted is returned for password protected PDF files.
+var ErrEncrypted = errors.New("encrypted PDF files are not supported")
+
+const (
+	pdfMaxRefDepth  = 32
+	pdfMaxFormDepth = 8
+	pdfKerningSpace = -250 // TJ offset (1/1000 em) treated as word gap
+)
+
+var pdfObjectHeader = regexp.MustCompile(`\b(\d+)\s+(\d+)\s+obj\b`)
+
+type pdfFile struct {
+	objects map[int]any
+}
+
+// pdfFont holds the ToUnicode mapping of a font, if any.
+type pdfFont struct {
+	toUnicode map[int]string
+	codeLen   int
+}
+
+// pdfText collects extracted page text.
+type pdfText struct {
+	sb strings.Builder
+}
+
+// PDF extracts the text of a PDF file page by page. Each page starts with
+// a "--- Page <n> ---" marker.
+//
+// Parameters:
+//
+//	data ([]byte) - the PDF file content
+//
+// Returns:
+//
+//	string - the extracted text
+//	error  - error if the file cannot be parsed or contains no text
+func PDF(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\n\f\r "), []byte("%PDF-")) {
+		return "", fmt.Errorf("not a PDF file")
+	}
+
+	f, err := parsePDF(data)
+	if err != nil {
+		return "", err
+	}
+
+	pages := f.pages()
+	if len(pages) == 0 {
+		return "", fmt.Errorf("no pages found")
+	}
+
+	var (
+		sb      strings.Builder
+		hasText bool
+	)
+	for i, page := range pages {
+		text := cleanText(f.pageText(page))
+		hasText = hasText || text != ""
+		fmt.Fprintf(&sb, "--- Page %d ---\n", i+1)
+		if text != "" {
+			sb.WriteString(text)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	if !hasText {
+		return "", fmt.Errorf("no extractable text (scanned PDF?)")
+	}
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
+
+// parsePDF collects all indirect objects, including objects stored in
+// object streams. Later definitions replace earlier ones (incremental
+// updates).
+//
+// Parameters:
+//
+//	data ([]byte) - the PDF file content
+//
+// Returns:
+//
+//	*pdfFile - the parsed objects
+//	error    - error if the file is encrypted or has no objects
+func parsePDF(data []byte) (*pdfFile, error) {
+	f := &pdfFile{objects: map[int]any{}}
+
+	end := 0
+	for _, m := range pdfObjectHeader.FindAllSubmatchIndex(data, -1) {
+		if m[0] < end {
+			continue // match inside a previous object or stream
+		}
+		num, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+
+		l := &pdfLexer{data: data, pos: m[1]}
+		obj, err := l.next()
+		if err != nil {
+			continue
+		}
+
+		l.skipSpace()
+		if dict, ok := obj.(pdfDict); ok && l.hasPrefix("stream") {
+			l.pos += len("stream")
+			if l.hasPrefix("\r\n") {
+				l.pos += 2
+			} else if l.hasPrefix("\n") || l.hasPrefix("\r") {
+				l.pos++
+			}
+			raw := streamData(data, l.pos, dict["Length"])
+			l.pos += len(raw)
+			obj = &pdfStream{dict: dict, raw: raw}
+		}
+
+		f.objects[num] = obj
+		end = l.pos
+	}
+
+	if len(f.objects) == 0 {
+		return nil, fmt.Errorf("no PDF objects found")
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return nil, ErrEncrypted
+	}
+
+	f.loadObjectStreams()
+	return f, nil
+}
+
+// streamData returns the raw stream bytes starting at pos. A direct
+// /Length is used if it is consistent, otherwise the data is cut at the
+// next "endstream" keyword.
+//
+// Parameters:
+//
+//	data ([]byte) - the PDF file content
+//	pos (int)     - start of the stream data
+//	length (any)  - value of the /Length entry
+//
+// Returns:
+//
+//	[]byte - the raw stream data
+func streamData(data []byte, pos int, length any) []byte {
+	if n, ok := length.(float64); ok && n >= 0 && pos+int(n) <= len(data) {
+		rest := bytes.TrimLeft(data[pos+int(n):], "\x00\t\n\f\r ")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			return data[pos : pos+int(n)]
+		}
+	}
+
+	end := bytes.Index(data[pos:], []byte("endstream"))
+	if end < 0 {
+		return data[pos:]
+	}
+	return bytes.TrimRight(data[pos:pos+end], "\r\n")
+}
+
+// loadObjectStreams adds the objects of all object streams (/ObjStm).
+// Objects defined outside of object streams take precedence.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (f *pdfFile) loadObjectStreams() {
+	for _, obj := range f.objects {
+		s, ok := obj.(*pdfStream)
+		if !ok || s.dict["Type"] != pdfName("ObjStm") {
+			continue
+		}
+		data, err := f.decodeStream(s)
+		if err != nil {
+			continue
+		}
+
+		count := f.intValue(s.dict["N"])
+		first := f.intValue(s.dict["First"])
+		l := &pdfLexer{data: data}
+		for range count {
+			numObj, _ := l.next()
+			offObj, _ := l.next()
+			num, ok1 := numObj.(float64)
+			off, ok2 := offObj.(float64)
+			if !ok1 || !ok2 {
+				break
+			}
+			if _, exists := f.objects[int(num)]; exists {
+				continue
+			}
+			pos := first + int(off)
+			if pos < 0 || pos >= len(data) {
+				continue
+			}
+			inner := &pdfLexer{data: data, pos: pos}
+			if value, err := inner.next(); err == nil {
+				f.objects[int(num)] = value
+			}
+		}
+	}
+}
+
+// resolve follows indirect references.
+//
+// Parameters:
+//
+//	v (any) - an object or reference
+//
+// Returns:
+//
+//	any - the referenced object
+func (f *pdfFile) resolve(v any) any {
+	for range pdfMaxRefDepth {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		v = f.objects[ref.num]
+	}
+	return nil
+}
+
+// dict resolves v and returns it as dictionary. Stream dictionaries are
+// returned as well.
+//
+// Parameters:
+//
+//	v (any) - an object or reference
+//
+// Returns:
+//
+//	pdfDict - the dictionary or nil
+func (f *pdfFile) dict(v any) pdfDict {
+	switch obj := f.resolve(v).(type) {
+	case pdfDict:
+		return obj
+	case *pdfStream:
+		return obj.dict
+	}
+	return nil
+}
+
+// intValue resolves v and returns it as integer.
+//
+// Parameters:
+//
+//	v (any) - an object or reference
+//
+// Returns:
+//
+//	int - the value or 0
+func (f *pdfFile) intValue(v any) int {
+	if n, ok := f.resolve(v).(float64); ok {
+		return int(n)
+	}
+	return 0
+}
+
+// decodeStream applies the stream filters.
+//
+// Parameters:
+//
+//	s (*pdfStream) - the stream
+//
+// Returns:
+//
+//	[]byte - the decoded data
+//	error  - error if a filter is unsupported or fails
+func (f *pdfFile) decodeStream(s *pdfStream) ([]byte, error) {
+	var filters []any
+	switch v := f.resolve(s.dict["Filter"]).(type) {
+	case pdfName:
+		filters = []any{v}
+	case []any:
+		filters = v
+	}
+
+	data := s.raw
+	for _, filter := range filters {
+		name, _ := f.resolve(filter).(pdfName)
+		var err error
+		switch name {
+		case "FlateDecode", "Fl":
+			data, err = inflate(data)
+		case "ASCIIHexDecode", "AHx":
+			data, err = decodeASCIIHex(data)
+		case "ASCII85Decode", "A85":
+			data, err = decodeASCII85(data)
+		default:
+			return nil, fmt.Errorf("unsupported stream filter %q", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode stream failed: %w", err)
+		}
+	}
+	return data, nil
+}
+
+// inflate decompresses zlib data up to maxEntrySize. Truncated streams
+// return the data decoded so far.
+//
+// Parameters:
+//
+//	data ([]byte) - compressed data
+//
+// Returns:
+//
+//	[]byte - decompressed data
+//	error  - error if the header is invalid
+func inflate(data []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	out, err := io.ReadAll(io.LimitReader(r, maxEntrySize))
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+// decodeASCIIHex decodes ASCIIHexDecode data.
+//
+// Parameters:
+//
+//	data ([]byte) - encoded data
+//
+// Returns:
+//
+//	[]byte - decoded data
+//	error  - error if any
+func decodeASCIIHex(data []byte) ([]byte, error) {
+	if i := bytes.IndexByte(data, '>'); i >= 0 {
+		data = data[:i]
+	}
+	digits := bytes.Map(func(r rune) rune {
+		if isPDFSpace(byte(r)) {
+			return -1
+		}
+		return r
+	}, data)
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	_, err := hex.Decode(out, digits)
+	return out, err
+}
+
+// decodeASCII85 decodes ASCII85Decode data.
+//
+// Parameters:
+//
+//	data ([]byte) - encoded data
+//
+// Returns:
+//
+//	[]byte - decoded data
+//	error  - error if any
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	out := make([]byte, len(data)*4/5+4)
+	n, _, err := ascii85.Decode(out, data, true)
+	return out[:n], err
+}
+
+// pageInfo is a page dictionary with its inherited resources.
+type pageInfo struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+// pages returns the pages in document order. If the page tree cannot be
+// found, all page objects are returned in object number order.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	[]pageInfo - the pages
+func (f *pdfFile) pages() []pageInfo {
+	var out []pageInfo
+	for _, obj := range f.objects {
+		if d := f.dict(obj); d != nil && d["Type"] == pdfName("Catalog") {
+			f.walkPages(d["Pages"], nil, &out, 0)
+			if len(out) > 0 {
+				return out
+			}
+		}
+	}
+
+	nums := make([]int, 0, len(f.objects))
+	for num := range f.objects {
+		nums = append(nums, num)
+	}
+	slices.Sort(nums)
+	for _, num := range nums {
+		if d := f.dict(f.objects[num]); d != nil && d["Type"] == pdfName("Page") {
+			out = append(out, pageInfo{dict: d, resources: f.dict(d["Resources"])})
+		}
+	}
+	return out
+}
+
+// walkPages collects the leaves of the page tree.
+//
+// Parameters:
+//
+//	node (any)           - a page tree node
+//	resources (pdfDict)  - resources inherited from the parent
+//	out (*[]pageInfo)    - the collected pages
+//	depth (int)          - current tree depth
+//
+// Returns:
+//
+//	none
+func (f *pdfFile) walkPages(node any, resources pdfDict, out *[]pageInfo, depth int) {
+	d := f.dict(node)
+	if d == nil || depth > pdfMaxRefDepth {
+		return
+	}
+	if r := f.dict(d["Resources"]); r != nil {
+		resources = r
+	}
+
+	if d["Type"] == pdfName("Page") {
+		*out = append(*out, pageInfo{dict: d, resources: resources})
+		return
+	}
+	kids, _ := f.resolve(d["Kids"]).([]any)
+	for _, kid := range kids {
+		f.walkPages(kid, resources, out, depth+1)
+	}
+}
+
+// pageText extracts the text of a page.
+//
+// Parameters:
+//
+//	page (pageInfo) - the page
+//
+// Returns:
+//
+//	string - the raw page text
+func (f *pdfFile) pageText(page pageInfo) string {
+	var content []byte
+	switch c := f.resolve(page.dict["Contents"]).(type) {
+	case *pdfStream:
+		content, _ = f.decodeStream(c)
+	case []any:
+		for _, part := range c {
+			if s, ok := f.resolve(part).(*pdfStream); ok {
+				data, err := f.decodeStream(s)
+				if err == nil {
+					content = append(append(content, data...), '\n')
+				}
+			}
+		}
+	}
+
+	var t pdfText
+	f.runContent(content, page.resources, &t, 0)
+	return t.sb.String()
+}
+
+// runContent interprets the text operators of a content stream.
+//
+// Parameters:
+//
+//	content ([]byte)    - decoded content stream
+//	resources (pdfDict) - resources for fonts and forms
+//	t (*pdfText)        - the text collector
+//	depth (int)         - form nesting depth
+//
+// Returns:
+//
+//	none
+func (f *pdfFile) runContent(content []byte, resources pdfDict, t *pdfText, depth int) {
+	fonts := map[pdfName]*pdfFont{}
+	var (
+		font     *pdfFont
+		operands []any
+		lastY    float64
+		hasY     bool
+	)
+
+	l := &pdfLexer{data: content}
+	for l.pos < len(content) {
+		obj, err := l.next()
+		if err != nil {
+			return
+		}
+		op, ok := obj.(pdfKeyword)
+		if !ok {
+			if obj != nil {
+				operands = append(operands, obj)
+			}
+			continue
+		}
+
+		switch op {
+		case "Tf":
+			if len(operands) >= 1 {
+				if name, ok := operands[0].(pdfName); ok {
+					font = f.font(fonts, resources, name)
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				if ty, _ := operands[1].(float64); ty != 0 {
+					t.newline()
+				} else {
+					t.space()
+				}
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				y, _ := operands[5].(float64)
+				if hasY && y != lastY {
+					t.newline()
+				} else {
+					t.space()
+				}
+				lastY, hasY = y, true
+			}
+		case "T*":
+			t.newline()
+		case "Tj":
+			if len(operands) >= 1 {
+				t.write(decodePDFString(operands[0], font))
+			}
+		case "'":
+			t.newline()
+			if len(operands) >= 1 {
+				t.write(decodePDFString(operands[0], font))
+			}
+		case "\"":
+			t.newline()
+			if len(operands) >= 3 {
+				t.write(decodePDFString(operands[2], font))
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				items, _ := operands[0].([]any)
+				for _, item := range items {
+					if n, ok := item.(float64); ok {
+						if n < pdfKerningSpace {
+							t.space()
+						}
+						continue
+					}
+					t.write(decodePDFString(item, font))
+				}
+			}
+		case "Do":
+			if len(operands) >= 1 && depth < pdfMaxFormDepth {
+				name, _ := operands[0].(pdfName)
+				f.runForm(resources, name, t, depth)
+			}
+		case "ID":
+			l.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+}
+
+// runForm extracts the text of a form XObject.
+//
+// Parameters:
+//
+//	resources (pdfDict) - resources of the calling content stream
+//	name (pdfName)      - the XObject name
+//	t (*pdfText)        - the text collector
+//	depth (int)         - form nesting depth
+//
+// Returns:
+//
+//	none
+func (f *pdfFile) runForm(resources pdfDict, name pdfName, t *pdfText, depth int) {
+	xobjects := f.dict(resources["XObject"])
+	s, ok := f.resolve(xobjects[name]).(*pdfStream)
+	if !ok || s.dict["Subtype"] != pdfName("Form") {
+		return
+	}
+	data, err := f.decodeStream(s)
+	if err != nil {
+		return
+	}
+	formResources := f.dict(s.dict["Resources"])
+	if formResources == nil {
+		formResources = resources
+	}
+	t.newline()
+	f.runContent(data, formResources, t, depth+1)
+	t.newline()
+}
+
+// font loads a font of the resources and caches it.
+//
+// Parameters:
+//
+//	cache (map[pdfName]*pdfFont) - fonts loaded so far
+//	resources (pdfDict)          - resources with the font dictionary
+//	name (pdfName)               - the font resource name
+//
+// Returns:
+//
+//	*pdfFont - the font (never nil)
+func (f *pdfFile) font(cache map[pdfName]*pdfFont, resources pdfDict, name pdfName) *pdfFont {
+	if font, ok := cache[name]; ok {
+		return font
+	}
+
+	font := &pdfFont{codeLen: 1}
+	fontDict := f.dict(f.dict(resources["Font"])[name])
+	if s, ok := f.resolve(fontDict["ToUnicode"]).(*pdfStream); ok {
+		if data, err := f.decodeStream(s); err == nil {
+			font.toUnicode, font.codeLen = parseCMap(data)
+		}
+	}
+	if font.toUnicode == nil && fontDict["Subtype"] == pdfName("Type0") {
+		font.codeLen = 2
+	}
+	cache[name] = font
+	return font
+}
+
+// write appends shown text.
+//
+// Parameters:
+//
+//	s (string) - the text
+//
+// Returns:
+//
+//	none
+func (t *pdfText) write(s string) {
+	t.sb.WriteString(s)
+}
+
+// space appends a word gap unless the text already ends with one.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (t *pdfText) space() {
+	s := t.sb.String()
+	if s != "" && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+		t.sb.WriteByte(' ')
+	}
+}
+
+// newline starts a new line unless the text already ends with one.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (t *pdfText) newline() {
+	s := t.sb.String()
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		t.sb.WriteByte('\n')
+	}
+}
@@ -0,0 +1,151 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// buildPDF writes a minimal PDF file from object bodies. Objects are
+// numbered from 1. Streams are given as "<<dict>>" plus content.
+func buildPDF(objects ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	for i, obj := range objects {
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func stream(dict, content string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(content), content)
+}
+
+func flateStream(dict, content string) string {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(content))
+	w.Close()
+	return stream(dict+" /Filter /FlateDecode", buf.String())
+}
+
+func TestPDF(t *testing.T) {
+	cmap := `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+1 begincodespacerange <0000> <FFFF> endcodespacerange
+2 beginbfchar
+<0001> <0048>
+<0002> <00E9>
+endbfchar
+1 beginbfrange
+<0010> <0012> <0061>
+endbfrange
+endcmap`
+
+	data := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 7 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Contents [8 0 R 9 0 R] >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Font /Subtype /Type0 /ToUnicode 10 0 R >>",
+		stream("", "BT /F1 12 Tf 72 720 Td (Hello \\(PDF\\)) Tj 0 -14 Td [(Wor) -20 (ld) -400 (again)] TJ ET"),
+		flateStream("", "BT /F2 12 Tf 1 0 0 1 72 700 Tm <00010002> Tj 1 0 0 1 72 680 Tm <001000110012> Tj ET"),
+		stream("", "BT /F1 12 Tf T* (\\223quoted\\224) Tj ET"),
+		flateStream("", cmap),
+	)
+
+	got, err := PDF(data)
+	if err != nil {
+		t.Fatalf("PDF failed: %v", err)
+	}
+	want := "--- Page 1 ---\nHello (PDF)\nWorld again\n\n--- Page 2 ---\nHé\nabc\n“quoted”"
+	if got != want {
+		t.Fatalf("PDF() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestPDF_ObjectStream(t *testing.T) {
+	objStm := "3 0 4 60 "
+	catalogAndPages := "<< /Type /Pages /Kids [4 0 R] /Count 1 >>"
+	page := "<< /Type /Page /Parent 3 0 R /Contents 5 0 R >>"
+	body := catalogAndPages + strings.Repeat(" ", 60-len(catalogAndPages)) + page
+
+	data := buildPDF(
+		"<< /Type /Catalog /Pages 3 0 R >>",
+		flateStream(fmt.Sprintf("/Type /ObjStm /N 2 /First %d", len(objStm)), objStm+body),
+		"null",
+		"null",
+		stream("", "BT (From object stream) Tj ET"),
+	)
+	// objects 3 and 4 must come from the object stream
+	data = bytes.Replace(data, []byte("3 0 obj\nnull\nendobj\n"), nil, 1)
+	data = bytes.Replace(data, []byte("4 0 obj\nnull\nendobj\n"), nil, 1)
+
+	got, err := PDF(data)
+	if err != nil {
+		t.Fatalf("PDF failed: %v", err)
+	}
+	if got != "--- Page 1 ---\nFrom object stream" {
+		t.Fatalf("PDF() = %q", got)
+	}
+}
+
+func TestPDF_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"not a pdf", []byte("hello"), "not a PDF"},
+		{"encrypted", buildPDF("<< /Type /Catalog >>", "<< /Encrypt 3 0 R >>"), "encrypted"},
+		{"no text", buildPDF(
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+			"<< /Type /Page /Contents 4 0 R >>",
+			stream("", "0 0 m 10 10 l S"),
+		), "no extractable text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := PDF(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPDFLexer(t *testing.T) {
+	l := &pdfLexer{data: []byte(`/Name#20X (a\n\101(b)) <48 65 6C> [1 2 R -3.5] << /B true >> % comment
+Tj`)}
+
+	var got []any
+	for {
+		obj, err := l.next()
+		if err != nil {
+			t.Fatalf("next failed: %v", err)
+		}
+		if obj == nil {
+			break
+		}
+		got = append(got, obj)
+	}
+
+	want := []any{
+		pdfName("Name X"),
+		[]byte("a\nA(b)"),
+		[]byte("Hel"),
+		[]any{pdfRef{num: 1, gen: 2}, -3.5},
+		pdfDict{"B": true},
+		pdfKeyword("Tj"),
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("tokens = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,224 @@
+package extract
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// winAnsiHigh maps the WinAnsiEncoding bytes 0x80..0x9F that differ from
+// Latin-1. Other bytes are decoded as Latin-1.
+var winAnsiHigh = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†',
+	0x87: '‡', 0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ',
+	0x8E: 'Ž', 0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•',
+	0x96: '–', 0x97: '—', 0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›',
+	0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+// parseCMap reads the bfchar and bfrange mappings of a ToUnicode CMap.
+//
+// Parameters:
+//
+//	data ([]byte) - the decoded CMap stream
+//
+// Returns:
+//
+//	map[int]string - character code to Unicode text
+//	int            - code length in bytes
+func parseCMap(data []byte) (map[int]string, int) {
+	cmap := map[int]string{}
+	codeLen := 0
+
+	var operands []any
+	l := &pdfLexer{data: data}
+	for l.pos < len(data) {
+		obj, err := l.next()
+		if err != nil {
+			break
+		}
+		op, ok := obj.(pdfKeyword)
+		if !ok {
+			if obj != nil {
+				operands = append(operands, obj)
+			}
+			continue
+		}
+
+		switch op {
+		case "endcodespacerange":
+			if len(operands) >= 1 {
+				if lo, ok := operands[0].([]byte); ok {
+					codeLen = len(lo)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].([]byte)
+				dst, ok2 := operands[i+1].([]byte)
+				if ok1 && ok2 {
+					cmap[codeValue(src)] = decodeUTF16(dst)
+					codeLen = max(codeLen, len(src))
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].([]byte)
+				hi, ok2 := operands[i+1].([]byte)
+				if !ok1 || !ok2 {
+					continue
+				}
+				codeLen = max(codeLen, len(lo))
+				addCMapRange(cmap, codeValue(lo), codeValue(hi), operands[i+2])
+			}
+		}
+		operands = operands[:0]
+	}
+
+	if codeLen == 0 {
+		codeLen = 1
+	}
+	return cmap, codeLen
+}
+
+// addCMapRange adds a bfrange entry. The destination is either a start
+// value that is incremented for each code or an array of values.
+//
+// Parameters:
+//
+//	cmap (map[int]string) - the mapping to extend
+//	lo (int)              - first code of the range
+//	hi (int)              - last code of the range
+//	dst (any)             - destination string or array
+//
+// Returns:
+//
+//	none
+func addCMapRange(cmap map[int]string, lo, hi int, dst any) {
+	if hi < lo || hi-lo > 0xFFFF {
+		return
+	}
+
+	switch d := dst.(type) {
+	case []byte:
+		units := utf16Units(d)
+		if len(units) == 0 {
+			return
+		}
+		for code := lo; code <= hi; code++ {
+			u := append([]uint16(nil), units...)
+			u[len(u)-1] += uint16(code - lo)
+			cmap[code] = string(utf16.Decode(u))
+		}
+	case []any:
+		for i, item := range d {
+			if s, ok := item.([]byte); ok && lo+i <= hi {
+				cmap[lo+i] = decodeUTF16(s)
+			}
+		}
+	}
+}
+
+// decodePDFString decodes a shown string with the font mapping.
+//
+// Parameters:
+//
+//	v (any)         - the string operand
+//	font (*pdfFont) - the current font (may be nil)
+//
+// Returns:
+//
+//	string - the Unicode text
+func decodePDFString(v any, font *pdfFont) string {
+	s, ok := v.([]byte)
+	if !ok {
+		return ""
+	}
+	if font == nil || font.toUnicode == nil {
+		if font != nil && font.codeLen == 2 {
+			return "" // composite font without mapping
+		}
+		return decodeWinAnsi(s)
+	}
+
+	var sb strings.Builder
+	for i := 0; i+font.codeLen <= len(s); i += font.codeLen {
+		code := codeValue(s[i : i+font.codeLen])
+		if text, ok := font.toUnicode[code]; ok {
+			sb.WriteString(text)
+		} else if font.codeLen == 1 {
+			sb.WriteString(decodeWinAnsi(s[i : i+1]))
+		}
+	}
+	return sb.String()
+}
+
+// decodeWinAnsi decodes single byte text as WinAnsiEncoding.
+//
+// Parameters:
+//
+//	s ([]byte) - the raw text
+//
+// Returns:
+//
+//	string - the Unicode text
+func decodeWinAnsi(s []byte) string {
+	runes := make([]rune, 0, len(s))
+	for _, b := range s {
+		if r, ok := winAnsiHigh[b]; ok {
+			runes = append(runes, r)
+		} else {
+			runes = append(runes, rune(b))
+		}
+	}
+	return string(runes)
+}
+
+// codeValue converts big-endian code bytes into an integer.
+//
+// Parameters:
+//
+//	b ([]byte) - the code bytes
+//
+// Returns:
+//
+//	int - the code
+func codeValue(b []byte) int {
+	v := 0
+	for _, c := range b {
+		v = v<<8 | int(c)
+	}
+	return v
+}
+
+// utf16Units splits big-endian UTF-16 bytes into code units.
+//
+// Parameters:
+//
+//	b ([]byte) - UTF-16BE bytes
+//
+// Returns:
+//
+//	[]uint16 - the code units
+func utf16Units(b []byte) []uint16 {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return units
+}
+
+// decodeUTF16 decodes UTF-16BE bytes. A single byte is taken as is.
+//
+// Parameters:
+//
+//	b ([]byte) - UTF-16BE bytes
+//
+// Returns:
+//
+//	string - the Unicode text
+func decodeUTF16(b []byte) string {
+	if len(b) == 1 {
+		return string(rune(b[0]))
+	}
+	return string(utf16.Decode(utf16Units(b)))
+}
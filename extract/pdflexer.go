@@ -0,0 +1,441 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type pdfName string
+type pdfKeyword string
+type pdfDict map[pdfName]any
+
+type pdfRef struct {
+	num int
+	gen int
+}
+
+type pdfStream struct {
+	dict pdfDict
+	raw  []byte
+}
+
+// pdfLexer reads PDF objects and content stream tokens. Strings are
+// returned as []byte, numbers as float64.
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+const pdfMaxNesting = 64
+
+// isPDFSpace reports whether c is PDF white space.
+//
+// Parameters:
+//
+//	c (byte) - the character
+//
+// Returns:
+//
+//	bool
+func isPDFSpace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+// isPDFDelimiter reports whether c ends a regular token.
+//
+// Parameters:
+//
+//	c (byte) - the character
+//
+// Returns:
+//
+//	bool
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return isPDFSpace(c)
+}
+
+// skipSpace skips white space and comments.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isPDFSpace(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// readToken reads a regular token (number, keyword or name body).
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - the token
+func (l *pdfLexer) readToken() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+// hasPrefix reports whether the remaining input starts with s.
+//
+// Parameters:
+//
+//	s (string) - the expected prefix
+//
+// Returns:
+//
+//	bool
+func (l *pdfLexer) hasPrefix(s string) bool {
+	return bytes.HasPrefix(l.data[l.pos:], []byte(s))
+}
+
+// next reads the next object or keyword.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	any   - the object (nil at end of input)
+//	error - error if the input is malformed
+func (l *pdfLexer) next() (any, error) {
+	return l.readObject(0)
+}
+
+// readObject reads one object with nesting depth control.
+//
+// Parameters:
+//
+//	depth (int) - current nesting depth
+//
+// Returns:
+//
+//	any   - the object
+//	error - error if the input is malformed
+func (l *pdfLexer) readObject(depth int) (any, error) {
+	if depth > pdfMaxNesting {
+		return nil, fmt.Errorf("objects nested too deeply")
+	}
+
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, nil
+	}
+
+	switch c := l.data[l.pos]; {
+	case c == '/':
+		l.pos++
+		return pdfName(decodeName(l.readToken())), nil
+	case c == '(':
+		return l.readLiteralString()
+	case l.hasPrefix("<<"):
+		l.pos += 2
+		return l.readDict(depth)
+	case c == '<':
+		return l.readHexString()
+	case c == '[':
+		l.pos++
+		return l.readArray(depth)
+	case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+		l.pos++
+		return pdfKeyword(string(c)), nil
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return l.readNumberOrRef(), nil
+	default:
+		tok := l.readToken()
+		switch tok {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return pdfKeyword(tok), nil
+	}
+}
+
+// readDict reads dictionary entries after the opening "<<".
+//
+// Parameters:
+//
+//	depth (int) - current nesting depth
+//
+// Returns:
+//
+//	pdfDict - the dictionary
+//	error   - error if the input is malformed
+func (l *pdfLexer) readDict(depth int) (pdfDict, error) {
+	dict := pdfDict{}
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.data) {
+			return nil, fmt.Errorf("unterminated dictionary")
+		}
+		if l.hasPrefix(">>") {
+			l.pos += 2
+			return dict, nil
+		}
+
+		key, err := l.readObject(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(pdfName)
+		if !ok {
+			continue // tolerate garbage between entries
+		}
+		value, err := l.readObject(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		dict[name] = value
+	}
+}
+
+// readArray reads array elements after the opening "[".
+//
+// Parameters:
+//
+//	depth (int) - current nesting depth
+//
+// Returns:
+//
+//	[]any - the elements
+//	error - error if the input is malformed
+func (l *pdfLexer) readArray(depth int) ([]any, error) {
+	var arr []any
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.data) {
+			return nil, fmt.Errorf("unterminated array")
+		}
+		if l.data[l.pos] == ']' {
+			l.pos++
+			return arr, nil
+		}
+
+		value, err := l.readObject(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, value)
+	}
+}
+
+// readNumberOrRef reads a number or an indirect reference "num gen R".
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	any - float64 or pdfRef
+func (l *pdfLexer) readNumberOrRef() any {
+	tok := l.readToken()
+	num, err := strconv.ParseFloat(tok, 64)
+	if err != nil {
+		return pdfKeyword(tok)
+	}
+
+	// look ahead for "<gen> R"
+	if n, err := strconv.Atoi(tok); err == nil {
+		save := l.pos
+		l.skipSpace()
+		genTok := l.readToken()
+		if gen, err := strconv.Atoi(genTok); err == nil && genTok != "" {
+			l.skipSpace()
+			if l.pos < len(l.data) && l.data[l.pos] == 'R' &&
+				(l.pos+1 == len(l.data) || isPDFDelimiter(l.data[l.pos+1])) {
+				l.pos++
+				return pdfRef{num: n, gen: gen}
+			}
+		}
+		l.pos = save
+	}
+	return num
+}
+
+// readLiteralString reads a "(...)" string with escapes and nesting.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	[]byte - the decoded string
+//	error  - error if the string is unterminated
+func (l *pdfLexer) readLiteralString() ([]byte, error) {
+	l.pos++ // (
+	var out []byte
+	level := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			level++
+		case ')':
+			level--
+			if level == 0 {
+				return out, nil
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out, nil
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+				// line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+			continue
+		}
+		out = append(out, c)
+	}
+	return nil, fmt.Errorf("unterminated string")
+}
+
+// readHexString reads a "<...>" string.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	[]byte - the decoded string
+//	error  - error if the string is unterminated
+func (l *pdfLexer) readHexString() ([]byte, error) {
+	l.pos++ // <
+	end := bytes.IndexByte(l.data[l.pos:], '>')
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated hex string")
+	}
+
+	digits := make([]byte, 0, end)
+	for _, c := range l.data[l.pos : l.pos+end] {
+		if !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	l.pos += end + 1
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	out := make([]byte, len(digits)/2)
+	if _, err := hex.Decode(out, digits); err != nil {
+		return nil, fmt.Errorf("invalid hex string: %w", err)
+	}
+	return out, nil
+}
+
+// skipInlineImage skips inline image data after the "ID" operator up to
+// and including the "EI" operator.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (l *pdfLexer) skipInlineImage() {
+	for i := l.pos; i+2 <= len(l.data); i++ {
+		if l.data[i] == 'E' && l.data[i+1] == 'I' &&
+			i > 0 && isPDFSpace(l.data[i-1]) &&
+			(i+2 == len(l.data) || isPDFSpace(l.data[i+2])) {
+			l.pos = i + 2
+			return
+		}
+	}
+	l.pos = len(l.data)
+}
+
+// decodeName resolves #xx escapes in a name.
+//
+// Parameters:
+//
+//	s (string) - the raw name
+//
+// Returns:
+//
+//	string - the decoded name
+func decodeName(s string) string {
+	if !strings.Contains(s, "#") {
+		return s
+	}
+	var out []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] == '#' && i+2 < len(s) {
+			if b, err := hex.DecodeString(s[i+1 : i+3]); err == nil {
+				out = append(out, b[0])
+				i += 2
+				continue
+			}
+		}
+		out = append(out, s[i])
+	}
+	return string(out)
+}
@@ -5,13 +5,17 @@ go 1.24.2
 require (
 	github.com/BurntSushi/toml v1.6.0
 	github.com/atotto/clipboard v0.1.4
+	github.com/clipperhouse/uax29/v2 v2.2.0
+	github.com/gen2brain/heic v0.4.5
 	github.com/google/jsonschema-go v0.4.3
 	github.com/mattn/go-runewidth v0.0.28
+	golang.org/x/image v0.36.0
 	golang.org/x/term v0.35.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/clipperhouse/uax29/v2 v2.2.0 // indirect
+	github.com/ebitengine/purego v0.8.3 // indirect
+	github.com/tetratelabs/wazero v1.9.0 // indirect
 	golang.org/x/sys v0.36.0 // indirect
 )
@@ -0,0 +1,353 @@
+// Package highlight colors source code for the terminal. The lexers are
+// line based and keep strings and comments that span lines open across
+// calls, so that code can be highlighted while it is streamed.
+package highlight
+
+import (
+	"picochat/console"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// plainKey matches an unquoted YAML key, optionally behind a list marker.
+var plainKey = regexp.MustCompile(`^(\s*(?:-\s+)?)([\p{L}\p{N}_./-]+(?:[ \t]+[\p{L}\p{N}_./-]+)*)(:)(?:\s|$)`)
+
+// Highlighter highlights the lines of a code block.
+type Highlighter struct {
+	lang  *language
+	theme *Theme
+	base  string // style restored after each token
+	end   string // end of an open string or comment, empty if none
+	style string // style of the open string or comment
+	raw   bool   // the open string has no escapes
+}
+
+// New returns a highlighter for the language of a code fence label.
+//
+// Parameters:
+//
+//	label (string) - the language label, e.g. "go" or "Python"
+//	theme (*Theme) - the colors, nil disables highlighting
+//	base (string)  - the style of the surrounding text, restored after tokens
+//
+// Returns:
+//
+//	*Highlighter - the highlighter, nil if the language is not supported
+func New(label string, theme *Theme, base string) *Highlighter {
+	lang, ok := languages[strings.ToLower(strings.TrimSpace(label))]
+	if !ok || theme == nil {
+		return nil
+	}
+	return &Highlighter{lang: lang, theme: theme, base: base}
+}
+
+// Line highlights the next line of the code block.
+//
+// Parameters:
+//
+//	line (string) - the line without line break
+//
+// Returns:
+//
+//	string - the highlighted line
+func (h *Highlighter) Line(line string) string {
+	var sb strings.Builder
+	emit := func(style, text string) {
+		if style == "" || text == "" {
+			sb.WriteString(text)
+			return
+		}
+		sb.WriteString(style + text + console.ColorReset + h.base)
+	}
+
+	i := 0
+	if h.end != "" {
+		n := h.closing(line, h.end, h.raw)
+		if n < 0 {
+			emit(h.style, line)
+			return sb.String()
+		}
+		emit(h.style, line[:n])
+		h.end = ""
+		i = n
+	}
+
+	lang := h.lang
+	if lang.plainKeys {
+		if m := plainKey.FindStringSubmatchIndex(line); m != nil {
+			sb.WriteString(line[:m[3]])
+			emit(h.theme.Key, line[m[4]:m[5]])
+			i = m[5]
+		}
+	}
+
+	for i < len(line) {
+		rest := line[i:]
+		c, size := utf8.DecodeRuneInString(rest)
+		prev := rune(' ')
+		if i > 0 {
+			prev, _ = utf8.DecodeLastRuneInString(line[:i])
+		}
+
+		if h.isLineComment(rest, prev) {
+			emit(h.theme.Comment, rest)
+			break
+		}
+
+		if start := lang.blockComment[0]; start != "" && strings.HasPrefix(rest, start) {
+			end := lang.blockComment[1]
+			n := strings.Index(rest[len(start):], end)
+			if n < 0 {
+				emit(h.theme.Comment, rest)
+				h.end, h.style, h.raw = end, h.theme.Comment, true
+				break
+			}
+			n += len(start) + len(end)
+			emit(h.theme.Comment, rest[:n])
+			i += n
+			continue
+		}
+
+		if quote := h.quote(rest); quote != "" {
+			raw := containsWord(lang.raw, quote)
+			n := h.closing(rest[len(quote):], quote, raw)
+			if n < 0 {
+				emit(h.theme.String, rest)
+				if containsWord(lang.multiline, quote) {
+					h.end, h.style, h.raw = quote, h.theme.String, raw
+				}
+				break
+			}
+			n += len(quote)
+			style := h.theme.String
+			if lang.keys && strings.HasPrefix(strings.TrimLeft(rest[n:], " \t"), ":") {
+				style = h.theme.Key
+			}
+			emit(style, rest[:n])
+			i += n
+			continue
+		}
+
+		switch {
+		case lang.variables && c == '$' && len(rest) > 1:
+			n := variableLength(rest)
+			emit(h.theme.Variable, rest[:n])
+			i += n
+		case unicode.IsDigit(c) && !h.isIdentChar(prev):
+			n := h.identLength(rest)
+			emit(h.theme.Number, rest[:n])
+			i += n
+		case h.isIdentChar(c) && !unicode.IsDigit(c):
+			n := h.identLength(rest)
+			emit(h.wordStyle(rest[:n], rest[n:]), rest[:n])
+			i += n
+		default:
+			sb.WriteString(rest[:size])
+			i += size
+		}
+	}
+	return sb.String()
+}
+
+// isLineComment reports whether a line comment starts at the text.
+//
+// Parameters:
+//
+//	text (string) - the rest of the line
+//	prev (rune)   - the character in front of the text
+//
+// Returns:
+//
+//	bool - true if the rest of the line is a comment
+func (h *Highlighter) isLineComment(text string, prev rune) bool {
+	if h.lang.commentSpace && !unicode.IsSpace(prev) {
+		return false
+	}
+	for _, prefix := range h.lang.lineComments {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// quote returns the string delimiter at the start of the text.
+//
+// Parameters:
+//
+//	text (string) - the rest of the line
+//
+// Returns:
+//
+//	string - the delimiter, empty if no string starts here
+func (h *Highlighter) quote(text string) string {
+	for _, q := range h.lang.quotes {
+		if strings.HasPrefix(text, q) {
+			return q
+		}
+	}
+	return ""
+}
+
+// closing returns the end of a string or comment.
+//
+// Parameters:
+//
+//	text (string) - the text behind the opening delimiter
+//	end (string)  - the closing delimiter
+//	raw (bool)    - backslashes do not escape
+//
+// Returns:
+//
+//	int - the offset behind the closing delimiter, -1 if it is missing
+func (h *Highlighter) closing(text, end string, raw bool) int {
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && !raw:
+			i++
+		case strings.HasPrefix(text[i:], end):
+			if h.lang.doubled && len(end) == 1 && strings.HasPrefix(text[i+1:], end) {
+				i++
+				continue
+			}
+			return i + len(end)
+		}
+	}
+	return -1
+}
+
+// wordStyle returns the style of an identifier.
+//
+// Parameters:
+//
+//	word (string) - the identifier
+//	rest (string) - the text behind the identifier
+//
+// Returns:
+//
+//	string - the style, empty for plain identifiers
+func (h *Highlighter) wordStyle(word, rest string) string {
+	key := word
+	if h.lang.ignoreCase {
+		key = strings.ToUpper(word)
+	}
+	switch {
+	case h.lang.keywords[key]:
+		return h.theme.Keyword
+	case h.lang.types[key]:
+		return h.theme.Type
+	case h.lang.keys && strings.HasPrefix(strings.TrimLeft(rest, " \t"), ":") && !h.lang.plainKeys:
+		return h.theme.Key
+	case h.lang.functions && strings.HasPrefix(rest, "("):
+		return h.theme.Function
+	}
+	return ""
+}
+
+// isIdentChar reports whether a character belongs to an identifier.
+func (h *Highlighter) isIdentChar(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c) || strings.ContainsRune(h.lang.identChars, c)
+}
+
+// identLength returns the length of the identifier or number at the start
+// of the text. Numbers may contain dots, e.g. 3.14.
+//
+// Parameters:
+//
+//	text (string) - the rest of the line
+//
+// Returns:
+//
+//	int - the length in bytes
+func (h *Highlighter) identLength(text string) int {
+	number := len(text) > 0 && text[0] >= '0' && text[0] <= '9'
+	for i, c := range text {
+		if h.isIdentChar(c) {
+			continue
+		}
+		if number && c == '.' && i+1 < len(text) && text[i+1] >= '0' && text[i+1] <= '9' {
+			continue
+		}
+		return i
+	}
+	return len(text)
+}
+
+// variableLength returns the length of the shell variable at the start of
+// the text, e.g. $HOME, ${name} or $1.
+//
+// Parameters:
+//
+//	text (string) - the rest of the line, starting with '$'
+//
+// Returns:
+//
+//	int - the length in bytes
+func variableLength(text string) int {
+	if text[1] == '{' {
+		if n := strings.IndexByte(text, '}'); n > 0 {
+			return n + 1
+		}
+		return len(text)
+	}
+	if strings.ContainsRune("0123456789?@#*!$-", rune(text[1])) {
+		return 2
+	}
+	n := 1
+	for n < len(text) && (text[n] == '_' || text[n] < utf8.RuneSelf && (unicode.IsLetter(rune(text[n])) || unicode.IsDigit(rune(text[n])))) {
+		n++
+	}
+	if n == 1 {
+		return 1
+	}
+	return n
+}
+
+// containsWord reports whether a space separated list contains a word.
+func containsWord(list, word string) bool {
+	for _, w := range strings.Fields(list) {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
+
+// Markdown highlights the fenced code blocks of a Markdown text. The other
+// text, including the fences, is returned unchanged.
+//
+// Parameters:
+//
+//	text (string)  - the Markdown text
+//	theme (*Theme) - the colors, nil returns the text unchanged
+//	base (string)  - the style of the surrounding text
+//
+// Returns:
+//
+//	string - the text with highlighted code
+func Markdown(text string, theme *Theme, base string) string {
+	if theme == nil {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	var h *Highlighter
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence == "":
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				fence = trimmed[:3]
+				h = New(trimmed[3:], theme, base)
+			}
+		case strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "":
+			fence, h = "", nil
+		case h != nil:
+			lines[i] = h.Line(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
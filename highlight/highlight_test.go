@@ -0,0 +1,156 @@
+package highlight
+
+import (
+	"picochat/console"
+	"strings"
+	"testing"
+)
+
+// testTheme marks the tokens with readable tags.
+var testTheme = Theme{
+	Keyword:  "<k>",
+	Type:     "<t>",
+	Function: "<f>",
+	String:   "<s>",
+	Number:   "<n>",
+	Comment:  "<c>",
+	Variable: "<v>",
+	Key:      "<key>",
+}
+
+// tags replaces the resets behind the tokens with a closing tag.
+func tags(s string) string {
+	return strings.ReplaceAll(s, console.ColorReset, "</>")
+}
+
+func TestHighlighter_Line(t *testing.T) {
+	tests := []struct {
+		name  string
+		lang  string
+		input string
+		want  string
+	}{
+		{"go func", "go", "func main() {", "<k>func</> <f>main</>() {"},
+		{"go types and numbers", "go", "var x int = 0x1F + 3.14", "<k>var</> x <t>int</> = <n>0x1F</> + <n>3.14</>"},
+		{"go comment", "go", "x++ // done", "x++ <c>// done</>"},
+		{"go escaped quote", "go", `s := "a\"b" + 'c'`, `s := <s>"a\"b"</> + <s>'c'</>`},
+		{"go identifier with digits", "go", "v2 := x1", "v2 := x1"},
+		{"python", "Python", "def f(a): return None  # note", "<k>def</> <f>f</>(a): <k>return</> <k>None</>  <c># note</>"},
+		{"javascript", "js", "const $el = fn(`t`)", "<k>const</> $el = <f>fn</>(<s>`t`</>)"},
+		{"typescript", "ts", "let n: number", "<k>let</> n: <t>number</>"},
+		{"shell", "bash", `echo "$HOME" $1 ${PATH} # c`, `<t>echo</> <s>"$HOME"</> <v>$1</> <v>${PATH}</> <c># c</>`},
+		{"shell hash in word", "sh", "grep a#b", "grep a#b"},
+		{"json", "json", `{"key": [1, true, "v"]}`, `{<key>"key"</>: [<n>1</>, <k>true</>, <s>"v"</>]}`},
+		{"yaml", "yaml", "- name: 'it''s' # c", "- <key>name</>: <s>'it''s'</> <c># c</>"},
+		{"yaml key with space", "yml", "key two: 1.5", "<key>key two</>: <n>1.5</>"},
+		{"sql", "sql", "select count(*) from t -- c", "<k>select</> <f>count</>(*) <k>from</> t <c>-- c</>"},
+		{"sql doubled quote", "sql", "WHERE a = 'x''y'", "<k>WHERE</> a = <s>'x''y'</>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(tt.lang, &testTheme, "")
+			if h == nil {
+				t.Fatalf("New(%q) = nil", tt.lang)
+			}
+			if got := tags(h.Line(tt.input)); got != tt.want {
+				t.Errorf("Line(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlighter_Multiline(t *testing.T) {
+	tests := []struct {
+		name  string
+		lang  string
+		lines []string
+		want  []string
+	}{
+		{
+			name:  "go raw string",
+			lang:  "go",
+			lines: []string{"s := `a", `b\`, "c` + x"},
+			want:  []string{"s := <s>`a</>", `<s>b\</>`, "<s>c`</> + x"},
+		},
+		{
+			name:  "block comment",
+			lang:  "javascript",
+			lines: []string{"/* a", " b */ let"},
+			want:  []string{"<c>/* a</>", "<c> b */</> <k>let</>"},
+		},
+		{
+			name:  "python docstring",
+			lang:  "py",
+			lines: []string{`"""doc`, `more"""`, "pass"},
+			want:  []string{`<s>"""doc</>`, `<s>more"""</>`, "<k>pass</>"},
+		},
+		{
+			name:  "unclosed string ends with the line",
+			lang:  "go",
+			lines: []string{`s := "a`, "return"},
+			want:  []string{`s := <s>"a</>`, "<k>return</>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(tt.lang, &testTheme, "")
+			for i, line := range tt.lines {
+				if got := tags(h.Line(line)); got != tt.want[i] {
+					t.Errorf("Line(%q) = %q, want %q", line, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNew_Unsupported(t *testing.T) {
+	if h := New("cobol", &testTheme, ""); h != nil {
+		t.Errorf("New(cobol) = %v, want nil", h)
+	}
+	if h := New("go", nil, ""); h != nil {
+		t.Errorf("New(go, nil) = %v, want nil", h)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	input := "Use `func` here:\n```go\nfunc f()\n```\n~~~\nfunc g()\n~~~\nfunc h()"
+	want := "Use `func` here:\n```go\n<k>func</><base> <f>f</><base>()\n```\n~~~\nfunc g()\n~~~\nfunc h()"
+
+	if got := tags(Markdown(input, &testTheme, "<base>")); got != want {
+		t.Errorf("Markdown() = %q, want %q", got, want)
+	}
+	if got := Markdown(input, nil, ""); got != input {
+		t.Errorf("Markdown() without theme = %q, want %q", got, input)
+	}
+}
+
+func TestThemeFor(t *testing.T) {
+	tests := []struct {
+		mode      string
+		colorterm string
+		term      string
+		want      *Theme
+	}{
+		{"off", "truecolor", "xterm-256color", nil},
+		{"16", "truecolor", "", &Basic},
+		{"256", "", "", &Color256},
+		{"truecolor", "", "", &TrueColor},
+		{"auto", "truecolor", "xterm", &TrueColor},
+		{"auto", "24bit", "", &TrueColor},
+		{"auto", "", "xterm-256color", &Color256},
+		{"auto", "", "xterm", &Basic},
+		{"auto", "", "dumb", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode+"/"+tt.colorterm+"/"+tt.term, func(t *testing.T) {
+			t.Setenv("COLORTERM", tt.colorterm)
+			t.Setenv("TERM", tt.term)
+			if got := ThemeFor(tt.mode); got != tt.want {
+				t.Errorf("ThemeFor(%q) = %v, want %v", tt.mode, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package highlight
+
+import "strings"
+
+// language describes the tokens of a programming language.
+type language struct {
+	keywords     map[string]bool
+	types        map[string]bool // types, builtins and constants
+	lineComments []string
+	commentSpace bool      // line comments need a blank in front (shell, YAML)
+	blockComment [2]string // start and end, empty if not supported
+	quotes       []string  // string delimiters, longest first
+	multiline    string    // delimiters of strings that may span lines
+	raw          string    // delimiters of strings without escapes
+	doubled      bool      // a doubled quote escapes the quote (SQL)
+	identChars   string    // characters in identifiers besides letters, digits and _
+	ignoreCase   bool      // keywords are case insensitive
+	variables    bool      // $name and ${name} are variables
+	keys         bool      // a string in front of ':' is a key
+	plainKeys    bool      // unquoted keys at the start of a line (YAML)
+	functions    bool      // an identifier in front of '(' is a function
+}
+
+// words returns a set of the space separated words.
+func words(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		set[w] = true
+	}
+	return set
+}
+
+var goLang = &language{
+	keywords: words(`break case chan const continue default defer else fallthrough for func go goto if
+		import interface map package range return select struct switch type var`),
+	types: words(`any bool byte comparable complex64 complex128 error float32 float64 int int8 int16 int32
+		int64 rune string uint uint8 uint16 uint32 uint64 uintptr true false nil iota
+		append cap clear close complex copy delete imag len make max min new panic print println real recover`),
+	lineComments: []string{"//"},
+	blockComment: [2]string{"/*", "*/"},
+	quotes:       []string{`"`, "'", "`"},
+	multiline:    "`",
+	raw:          "`",
+	functions:    true,
+}
+
+var pythonLang = &language{
+	keywords: words(`and as assert async await break class continue def del elif else except finally for
+		from global if import in is lambda match case nonlocal not or pass raise return try while with yield
+		True False None`),
+	types: words(`bool bytes dict float frozenset int list object set str tuple type self cls
+		Exception ValueError TypeError KeyError IndexError RuntimeError`),
+	lineComments: []string{"#"},
+	quotes:       []string{`"""`, "'''", `"`, "'"},
+	multiline:    `""" '''`,
+	functions:    true,
+}
+
+var jsKeywords = `async await break case catch class const continue debugger default delete do else export
+	extends finally for from function if import in instanceof let new of return static super switch throw
+	try typeof var void while with yield`
+
+var jsTypes = `true false null undefined this NaN Infinity Array Boolean Date Error JSON Map Math Number Object
+	Promise RegExp Set String Symbol console document window`
+
+var javascriptLang = &language{
+	keywords:     words(jsKeywords),
+	types:        words(jsTypes),
+	lineComments: []string{"//"},
+	blockComment: [2]string{"/*", "*/"},
+	quotes:       []string{`"`, "'", "`"},
+	multiline:    "`",
+	identChars:   "$",
+	functions:    true,
+}
+
+var typescriptLang = &language{
+	keywords: words(jsKeywords + ` abstract as declare enum implements interface keyof namespace private
+		protected public readonly satisfies type`),
+	types:        words(jsTypes + ` any boolean never number object string symbol unknown void`),
+	lineComments: []string{"//"},
+	blockComment: [2]string{"/*", "*/"},
+	quotes:       []string{`"`, "'", "`"},
+	multiline:    "`",
+	identChars:   "$",
+	functions:    true,
+}
+
+var shellLang = &language{
+	keywords: words(`if then else elif fi for while until do done case esac in function select return
+		local export readonly declare exit break continue`),
+	types:        words(`alias cd echo eval exec printf pwd read set shift source test trap unset true false`),
+	lineComments: []string{"#"},
+	commentSpace: true,
+	quotes:       []string{`"`, "'"},
+	multiline:    `" '`,
+	raw:          "'",
+	variables:    true,
+}
+
+var jsonLang = &language{
+	keywords:     words("true false null"),
+	lineComments: []string{"//"}, // JSON with comments
+	blockComment: [2]string{"/*", "*/"},
+	quotes:       []string{`"`},
+	keys:         true,
+}
+
+var yamlLang = &language{
+	keywords:     words("true false null yes no on off True False Null ~"),
+	lineComments: []string{"#"},
+	commentSpace: true,
+	quotes:       []string{`"`, "'"},
+	raw:          "'",
+	doubled:      true,
+	keys:         true,
+	plainKeys:    true,
+}
+
+var sqlLang = &language{
+	keywords: words(`ADD ALL ALTER AND AS ASC BEGIN BETWEEN BY CASE COMMIT CONSTRAINT CREATE CROSS DEFAULT
+		DELETE DESC DISTINCT DROP ELSE END EXISTS FOREIGN FROM FULL GROUP HAVING IF IN INDEX INNER INSERT
+		INTO IS JOIN KEY LEFT LIKE LIMIT NOT NULL OFFSET ON OR ORDER OUTER PRIMARY REFERENCES RETURNING
+		RIGHT ROLLBACK SELECT SET TABLE THEN TRANSACTION UNION UNIQUE UPDATE USING VALUES VIEW WHEN WHERE WITH`),
+	types: words(`BIGINT BLOB BOOLEAN CHAR DATE DECIMAL FLOAT INT INTEGER NUMERIC REAL SERIAL SMALLINT TEXT
+		TIMESTAMP VARCHAR TRUE FALSE`),
+	lineComments: []string{"--"},
+	blockComment: [2]string{"/*", "*/"},
+	quotes:       []string{"'", `"`},
+	raw:          `' "`,
+	doubled:      true,
+	ignoreCase:   true,
+	functions:    true,
+}
+
+// languages maps the labels of code fences to the languages.
+var languages = map[string]*language{
+	"go":         goLang,
+	"golang":     goLang,
+	"python":     pythonLang,
+	"py":         pythonLang,
+	"python3":    pythonLang,
+	"javascript": javascriptLang,
+	"js":         javascriptLang,
+	"jsx":        javascriptLang,
+	"mjs":        javascriptLang,
+	"typescript": typescriptLang,
+	"ts":         typescriptLang,
+	"tsx":        typescriptLang,
+	"sh":         shellLang,
+	"bash":       shellLang,
+	"shell":      shellLang,
+	"zsh":        shellLang,
+	"json":       jsonLang,
+	"jsonc":      jsonLang,
+	"yaml":       yamlLang,
+	"yml":        yamlLang,
+	"sql":        sqlLang,
+}
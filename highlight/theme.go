@@ -0,0 +1,90 @@
+package highlight
+
+import (
+	"os"
+	"picochat/console"
+	"strings"
+)
+
+// Theme holds the Esc sequences of the token styles.
+type Theme struct {
+	Keyword  string
+	Type     string // types, builtins and constants
+	Function string
+	String   string
+	Number   string
+	Comment  string
+	Variable string // shell variables
+	Key      string // JSON and YAML keys
+}
+
+// Basic uses the 16 standard terminal colors.
+var Basic = Theme{
+	Keyword:  console.Magenta,
+	Type:     console.Yellow,
+	Function: console.Blue,
+	String:   console.Green,
+	Number:   console.Cyan,
+	Comment:  console.BrightBlack,
+	Variable: console.Red,
+	Key:      console.BrightBlue,
+}
+
+// Color256 uses the 256 color palette.
+var Color256 = Theme{
+	Keyword:  console.Pink256,
+	Type:     console.Cyan256,
+	Function: console.Blue256,
+	String:   console.Green256,
+	Number:   console.Orange256,
+	Comment:  console.Gray256,
+	Variable: console.Tan256,
+	Key:      console.Blue256,
+}
+
+// TrueColor uses 24 bit colors.
+var TrueColor = Theme{
+	Keyword:  console.RGB(198, 120, 221),
+	Type:     console.RGB(229, 192, 123),
+	Function: console.RGB(97, 175, 239),
+	String:   console.RGB(152, 195, 121),
+	Number:   console.RGB(209, 154, 102),
+	Comment:  console.RGB(127, 132, 142),
+	Variable: console.RGB(224, 108, 117),
+	Key:      console.RGB(97, 175, 239),
+}
+
+// ThemeFor returns the theme of a highlight setting. The "auto" setting
+// picks the richest theme the terminal announces in $COLORTERM and $TERM.
+//
+// Parameters:
+//
+//	mode (string) - the setting: "auto", "off", "16", "256" or "truecolor"
+//
+// Returns:
+//
+//	*Theme - the theme, nil if highlighting is off
+func ThemeFor(mode string) *Theme {
+	switch mode {
+	case "off":
+		return nil
+	case "16":
+		return &Basic
+	case "256":
+		return &Color256
+	case "truecolor":
+		return &TrueColor
+	}
+
+	switch colorterm := strings.ToLower(os.Getenv("COLORTERM")); colorterm {
+	case "truecolor", "24bit":
+		return &TrueColor
+	}
+	switch terminal := os.Getenv("TERM"); {
+	case terminal == "dumb":
+		return nil
+	case strings.Contains(terminal, "256color"):
+		return &Color256
+	}
+	return &Basic
+}
@@ -10,7 +10,8 @@ import (
 )
 
 var (
-	resolvedSchema *jsonschema.Resolved
+	resolvedSchema    *jsonschema.Resolved
+	resolvedSchemaKey string
 )
 
 // PrettyPrint reformats a JSON string representation into pretty style.
@@ -55,13 +56,14 @@ func ValidateJSON(schemaMap map[string]any, jsonStr string) error {
 		return fmt.Errorf("json string is empty")
 	}
 
-	resolved := resolvedSchema
+	schemaBytes, err := json.Marshal(schemaMap)
+	if err != nil {
+		return fmt.Errorf("marshal schema failed: %w", err)
+	}
 
-	if resolved == nil {
-		schemaBytes, err := json.Marshal(schemaMap)
-		if err != nil {
-			return fmt.Errorf("marshal schema failed: %w", err)
-		}
+	// cache the resolved schema as long as the same schema is used
+	resolved := resolvedSchema
+	if resolved == nil || resolvedSchemaKey != string(schemaBytes) {
 		var schema jsonschema.Schema
 		if err := json.Unmarshal(schemaBytes, &schema); err != nil {
 			return fmt.Errorf("unmarshal schema failed: %w", err)
@@ -73,6 +75,7 @@ func ValidateJSON(schemaMap map[string]any, jsonStr string) error {
 		}
 
 		resolvedSchema = resolved
+		resolvedSchemaKey = string(schemaBytes)
 	}
 
 	var instance any
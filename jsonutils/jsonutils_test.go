@@ -118,4 +118,16 @@ func TestValidateJSON(t *testing.T) {
 			t.Fatalf("expected resolvedSchema cache to remain set")
 		}
 	})
+	t.Run("schema change invalidates cache", func(t *testing.T) {
+		other := map[string]any{
+			"type":     "object",
+			"required": []any{"b"},
+		}
+		if err := ValidateJSON(schema, `{"a":1}`); err != nil {
+			t.Fatalf("prime ValidateJSON returned error: %v", err)
+		}
+		if err := ValidateJSON(other, `{"a":1}`); err == nil {
+			t.Fatalf("expected validation against new schema to fail")
+		}
+	})
 }
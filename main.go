@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"picochat/args"
+	"picochat/backend"
+	"picochat/batch"
 	"picochat/chat"
 	"picochat/command"
 	"picochat/config"
@@ -13,12 +15,19 @@ import (
 	"picochat/paths"
 	"picochat/utils"
 	"picochat/version"
+	"strings"
+
+	"golang.org/x/term"
 )
 
 type Session struct {
-	Config  *config.Config
-	History *messages.ChatHistory
-	Quiet   bool
+	Config        *config.Config
+	History       *messages.ChatHistory
+	Quiet         bool
+	Template      string               // template spec from -template
+	Script        []command.ScriptStep // steps from -script
+	KeepGoing     bool                 // continue the script after a failed step
+	RequestConfig *config.Config       // config override for the next request only
 }
 
 const (
@@ -34,15 +43,34 @@ const (
 //
 // Returns:
 //
-//	none
-func sendPrompt(session *Session, prompt string) {
-	if err := session.History.AddUser(prompt, session.Config.ImagePath); err != nil {
-		console.Error(err)
-		return
+//	error - error if attaching files or the chat request fails
+func sendPrompt(session *Session, prompt string) error {
+	if len(session.Config.RunOutputs) > 0 {
+		prompt = strings.Join(session.Config.RunOutputs, "") + prompt
+	}
+
+	var documents []string
+	if len(session.Config.FilePaths) > 0 {
+		// re-read the files to send their current content
+		bundle, err := messages.BuildFileBundle(session.Config.FilePaths, messages.MaxAttachTokens, backend.SupportsFileInput(session.Config.Backend))
+		if err != nil {
+			return fmt.Errorf("attach files failed: %w", err)
+		}
+		if !session.Quiet {
+			console.Warns(bundle.Skipped)
+		}
+		prompt = messages.AttachFiles(prompt, bundle)
+		documents = bundle.Documents
+	}
+
+	if err := session.History.AddUserAttachments(prompt, session.Config.ImagePaths, documents); err != nil {
+		return err
 	}
 
-	session.Config.ImagePath = "" // store once in history and forget
-	runChat(session)
+	session.Config.ImagePaths = nil // store once in history and forget
+	session.Config.FilePaths = nil
+	session.Config.RunOutputs = nil
+	return runChat(session)
 }
 
 // retryPrompt triggers a new chat run based on existing history.
@@ -53,9 +81,9 @@ func sendPrompt(session *Session, prompt string) {
 //
 // Returns:
 //
-//	none
-func retryPrompt(session *Session) {
-	runChat(session)
+//	error - error if the chat request fails
+func retryPrompt(session *Session) error {
+	return runChat(session)
 }
 
 // runChat sends the prepared chat request and renders the final result.
@@ -66,26 +94,265 @@ func retryPrompt(session *Session) {
 //
 // Returns:
 //
-//	none
-func runChat(session *Session) {
+//	error - error if the chat request or the output fails
+func runChat(session *Session) error {
+	cfg := session.Config
+	if session.RequestConfig != nil {
+		cfg = session.RequestConfig
+		session.RequestConfig = nil // applies to a single request only
+	}
+
 	stop := make(chan struct{})
 	go console.StartSpinner(session.Quiet, stop)
 	defer console.StopSpinner(session.Quiet, stop)
 
-	result, err := chat.HandleChat(session.Config, session.History, stop)
+	result, err := chat.HandleChat(cfg, session.History, stop)
 	if err != nil {
-		console.Error(err)
-		return
+		return err
 	}
 
 	if err := output.RenderResult(
 		os.Stdout,
 		result,
-		session.Config.OutputFmt,
+		cfg.OutputFmt,
 		session.Quiet,
 	); err != nil {
-		console.Error(fmt.Errorf("output failed: %w", err))
+		return fmt.Errorf("output failed: %w", err)
+	}
+	return nil
+}
+
+// runCommand executes a command line and processes its result. Macro
+// steps returned by the command are run in order.
+//
+// Parameters:
+//
+//	session (*Session) - active runtime session
+//	line (string)      - the command line
+//	depth (int)        - macro nesting depth
+//
+// Returns:
+//
+//	bool - true if the session should quit
+//	bool - true if the command failed
+func runCommand(session *Session, line string, depth int) (bool, bool) {
+	result := command.HandleCommand(line, session.History, os.Stdin)
+	if result.Error != nil {
+		console.Error(fmt.Errorf("command handler error: %w", result.Error))
+		return false, true
+	}
+	if !session.Quiet {
+		console.Warn(result.Warn)
+		console.Info(result.Info)
+	}
+	if result.Output != "" {
+		fmt.Println(result.Output)
+	}
+	if result.Quit {
+		return true, false
+	}
+	session.RequestConfig = result.Config
+	var err error
+	if result.Retry {
+		err = retryPrompt(session)
+	} else if result.Pasted != "" {
+		// start the request with pasted content from clipboard
+		err = sendPrompt(session, result.Pasted)
+	}
+	if err != nil {
+		console.Error(err)
+		return false, true
+	}
+	if result.Draft != "" {
+		console.SetDraft(result.Draft)
+	}
+	if len(result.Steps) > 0 {
+		return runMacro(session, result.Steps, depth+1)
+	}
+	return false, false
+}
+
+// runMacro runs the steps of a macro. Commands are handled like typed
+// commands, all other steps are sent as prompts. The macro stops at the
+// first failing step.
+//
+// Parameters:
+//
+//	session (*Session) - active runtime session
+//	steps ([]string)   - commands and prompts
+//	depth (int)        - macro nesting depth
+//
+// Returns:
+//
+//	bool - true if the session should quit
+//	bool - true if a step failed
+func runMacro(session *Session, steps []string, depth int) (bool, bool) {
+	if depth > command.MaxExpansionDepth {
+		console.Error(fmt.Errorf("macro nesting deeper than %d, recursion?", command.MaxExpansionDepth))
+		return false, true
+	}
+
+	for _, step := range steps {
+		if !session.Quiet {
+			console.Info(fmt.Sprintf("Macro step: %s", step))
+		}
+		if !command.IsCommandLine(step) {
+			if err := sendPrompt(session, step); err != nil {
+				console.Error(err)
+				return false, true
+			}
+			continue
+		}
+		if quit, failed := runCommand(session, step, depth); quit || failed {
+			return quit, failed
+		}
+	}
+	return false, false
+}
+
+// runScript runs the steps of a script file. Commands are handled like
+// typed commands, prompts are sent like typed prompts (including the
+// -template spec). The script stops at the first failing step unless
+// keep-going is set.
+//
+// Parameters:
+//
+//	session (*Session) - active runtime session
+//
+// Returns:
+//
+//	int - number of failed steps
+func runScript(session *Session) int {
+	failed := 0
+	for i, step := range session.Script {
+		if !session.Quiet {
+			first, _, _ := strings.Cut(step.Text, "\n")
+			console.Info(fmt.Sprintf("Step %d/%d (line %d): %s", i+1, len(session.Script), step.Line, first))
+		}
+
+		ok := true
+		if step.IsCommand {
+			quit, stepFailed := runCommand(session, step.Text, 0)
+			if quit {
+				break
+			}
+			ok = !stepFailed
+		} else {
+			prompt := step.Text
+			var err error
+			if session.Template != "" {
+				prompt, err = applyTemplate(session, prompt)
+			}
+			if err == nil {
+				err = sendPrompt(session, prompt)
+			}
+			if err != nil {
+				console.Error(err)
+				ok = false
+			}
+		}
+
+		if !ok {
+			failed++
+			if !session.KeepGoing {
+				console.Error(fmt.Errorf("script stopped at line %d", step.Line))
+				break
+			}
+		}
+	}
+	return failed
+}
+
+// runBatch processes a JSONL batch file and writes the results to the
+// output file or stdout. Items already in the output file are skipped.
+//
+// Parameters:
+//
+//	session (*Session) - active runtime session
+//	inPath (string)    - path of the JSONL input
+//	outPath (string)   - path of the JSONL output, empty for stdout
+//	workers (int)      - number of parallel requests
+//
+// Returns:
+//
+//	error - error if the batch cannot be run or an item failed
+func runBatch(session *Session, inPath, outPath string, workers int) error {
+	items, err := batch.LoadItems(inPath)
+	if err != nil {
+		return err
 	}
+	for i := range items {
+		if items[i].Template == "" {
+			items[i].Template = session.Template
+		}
+	}
+
+	out := os.Stdout
+	done := map[string]bool{}
+	showProgress := !session.Quiet && outPath != "" // stdout is reserved for results otherwise
+	if outPath != "" {
+		if outPath, err = paths.ExpandHomeDir(outPath); err != nil {
+			return err
+		}
+		if done, err = batch.DoneIDs(outPath); err != nil {
+			return err
+		}
+		if out, err = batch.OpenOutput(outPath); err != nil {
+			return err
+		}
+		defer out.Close()
+		if showProgress && len(done) > 0 {
+			console.Info(fmt.Sprintf("Resuming %s: %d item(s) done.", outPath, len(done)))
+		}
+	}
+
+	var progress func(batch.Result)
+	if showProgress {
+		progress = func(r batch.Result) {
+			if r.Error != "" {
+				console.Warn(fmt.Sprintf("%s failed: %s", r.ID, r.Error))
+				return
+			}
+			console.Info(fmt.Sprintf("%s done (%.1fs).", r.ID, float64(r.DurationMS)/1000))
+		}
+	}
+
+	summary, err := batch.Run(session.Config, items, done, workers, out, progress)
+	if err != nil {
+		return err
+	}
+	if showProgress {
+		console.Info(summary.String())
+	}
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d batch item(s) failed", summary.Failed)
+	}
+	return nil
+}
+
+// applyTemplate renders the -template spec with the prompt as input and
+// stores the template config for the next request.
+//
+// Parameters:
+//
+//	session (*Session) - active runtime session
+//	prompt  (string)   - user input prompt
+//
+// Returns:
+//
+//	string - the rendered prompt
+//	error  - error if the template cannot be rendered
+func applyTemplate(session *Session, prompt string) (string, error) {
+	key, tplArgs, _ := command.ParseTemplateArgs(strings.Fields(session.Template))
+	text, reqCfg, warn, err := command.ExpandTemplate(key, tplArgs, prompt)
+	if err != nil {
+		return "", err
+	}
+	if !session.Quiet {
+		console.Warn(warn)
+	}
+	session.RequestConfig = reqCfg
+	return text, nil
 }
 
 // initSessionFromArgs parses CLI args, loads config, applies overrides,
@@ -143,10 +410,34 @@ func initSessionFromArgs() (bool, *Session, []string, error) {
 		cfg.Model = *args.Model
 	}
 
-	if *args.Image != "" {
-		cfg.ImagePath = *args.Image
-		if !paths.FileExists(cfg.ImagePath) {
-			return false, nil, nil, fmt.Errorf("image file not found")
+	for _, image := range args.Images {
+		if err := command.ValidateImage(image); err != nil {
+			return false, nil, nil, err
+		}
+		cfg.ImagePaths = append(cfg.ImagePaths, image)
+	}
+
+	if len(args.Files) > 0 {
+		bundle, err := messages.BuildFileBundle(args.Files, messages.MaxAttachTokens, backend.SupportsFileInput(cfg.Backend))
+		if err != nil {
+			return false, nil, nil, fmt.Errorf("attach files failed: %w", err)
+		}
+		warn = append(warn, bundle.Skipped...)
+		cfg.FilePaths = bundle.Files
+	}
+
+	if *args.Template != "" {
+		key, _, _ := command.ParseTemplateArgs(strings.Fields(*args.Template))
+		if _, err := config.LookupTemplate(key); err != nil {
+			return false, nil, nil, fmt.Errorf("load template failed: %w", err)
+		}
+	}
+
+	var script []command.ScriptStep
+	if *args.Script != "" {
+		script, err = command.LoadScript(*args.Script)
+		if err != nil {
+			return false, nil, nil, err
 		}
 	}
 
@@ -161,9 +452,12 @@ func initSessionFromArgs() (bool, *Session, []string, error) {
 	}
 
 	session := &Session{
-		Config:  cfg,
-		History: history,
-		Quiet:   cfg.Quiet,
+		Config:    cfg,
+		History:   history,
+		Quiet:     cfg.Quiet,
+		Template:  *args.Template,
+		Script:    script,
+		KeepGoing: *args.KeepGoing,
 	}
 
 	return false, session, warn, nil
@@ -180,12 +474,29 @@ func main() {
 		os.Exit(1)
 	}
 
+	console.DetectColors()
+	if err := console.SetTheme(session.Config.Colors.Theme, session.Config.Colors.Overrides()); err != nil {
+		warn = append(warn, fmt.Sprintf("config value 'Colors' invalid: %v", err))
+	}
+
 	printNewLine := func() {
 		if !session.Quiet {
 			fmt.Println()
 		}
 	}
 
+	if *args.Batch != "" {
+		// stdout may be the result file, so there is no startup info
+		if !session.Quiet {
+			console.Warns(warn)
+		}
+		if err := runBatch(session, *args.Batch, *args.Out, *args.Workers); err != nil {
+			console.Error(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if !session.Quiet {
 		console.Warns(warn)
 		if *args.Model != "" {
@@ -194,10 +505,29 @@ func main() {
 		console.Info("PicoChat started.")
 	}
 
+	if session.Script != nil {
+		if failed := runScript(session); failed > 0 {
+			console.Error(fmt.Errorf("%d script step(s) failed", failed))
+			os.Exit(1)
+		}
+		return
+	}
+
+	console.SetCompleter(command.Complete)
+	console.SetExternalEditor(func(text string) (string, error) {
+		return utils.EditText(text, ".md")
+	})
+	console.SetViMode(session.Config.Input.Mode == "vi")
+	if err := console.SetCancelKey(session.Config.Input.CancelKey); err != nil {
+		console.Warn(err.Error())
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		loadInputHistory()
+	}
 	for {
 		printNewLine()
 		if !session.Quiet {
-			fmt.Print(console.Prompt + console.ShadowText)
+			fmt.Print(console.StyledPrompt())
 			console.SetCursorPos(console.PromptWidth() + 1)
 		}
 
@@ -227,28 +557,10 @@ func main() {
 
 		if input.IsCommand {
 			fmt.Println() // newline even in quiet mode
-			result := command.HandleCommand(input.Text, session.History, os.Stdin)
-			console.AddCommand(input.Text)
-			if result.Error != nil {
-				console.Error(fmt.Errorf("command handler error: %w", result.Error))
-				continue
-			}
-			if !session.Quiet {
-				console.Warn(result.Warn)
-				console.Info(result.Info)
-			}
-			if result.Output != "" {
-				fmt.Println(result.Output)
-			}
-			if result.Quit {
+			rememberInput(input.Text)
+			if quit, _ := runCommand(session, input.Text, 0); quit {
 				break
 			}
-			if result.Retry {
-				retryPrompt(session)
-			} else if result.Pasted != "" {
-				// start the request with pasted content from clipboard
-				sendPrompt(session, result.Pasted)
-			}
 
 			if input.EOF {
 				// we come from stdin pipe
@@ -258,10 +570,63 @@ func main() {
 			}
 		}
 
-		sendPrompt(session, input.Text)
+		prompt := input.Text
+		rememberInput(prompt)
+		if session.Template != "" {
+			prompt, err = applyTemplate(session, prompt)
+			if err != nil {
+				console.Error(err)
+				if input.EOF {
+					os.Exit(1)
+				}
+				continue
+			}
+		}
+		if err := sendPrompt(session, prompt); err != nil {
+			console.Error(err)
+		}
 
 		if input.EOF {
 			break
 		}
 	}
 }
+
+// loadInputHistory loads the persistent input history for the Up/Down keys
+// and the reverse search.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func loadInputHistory() {
+	path, err := paths.GetInputHistoryFile()
+	if err == nil {
+		err = console.LoadHistory(path)
+	}
+	if err != nil {
+		console.Warn(fmt.Sprintf("Input history not available: %v", err))
+	}
+}
+
+// rememberInput adds a prompt or command to the input history. Lines that
+// set a secret are not stored.
+//
+// Parameters:
+//
+//	text (string) - the entered prompt or command
+//
+// Returns:
+//
+//	none
+func rememberInput(text string) {
+	if command.IsSensitive(text) {
+		return
+	}
+	if err := console.AddCommand(text); err != nil {
+		console.Warn(fmt.Sprintf("Input history not saved: %v", err))
+	}
+}
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"picochat/args"
+	"picochat/command"
 	"picochat/config"
 	"picochat/messages"
+	"picochat/paths"
+	"strings"
 	"testing"
 )
 
@@ -24,7 +30,8 @@ func newTestSession() *Session {
 	}
 }
 
-func TestSendPrompt_AppendsUserAndClearsImagePath(t *testing.T) {
+func TestSendPrompt_AppendsUserAndClearsImagePaths(t *testing.T) {
+	t.Cleanup(paths.OverrideHistoryPath(t.TempDir()))
 	session := newTestSession()
 
 	tmpDir := t.TempDir()
@@ -32,12 +39,12 @@ func TestSendPrompt_AppendsUserAndClearsImagePath(t *testing.T) {
 	if err := os.WriteFile(imagePath, []byte("dummy-image"), 0644); err != nil {
 		t.Fatalf("write image file failed: %v", err)
 	}
-	session.Config.ImagePath = imagePath
+	session.Config.ImagePaths = []string{imagePath, "https://example.com/cat.png"}
 
 	sendPrompt(session, "hello")
 
-	if session.Config.ImagePath != "" {
-		t.Fatalf("expected image path to be cleared, got %q", session.Config.ImagePath)
+	if session.Config.ImagePaths != nil {
+		t.Fatalf("expected image paths to be cleared, got %v", session.Config.ImagePaths)
 	}
 	if session.History.Len() != 2 {
 		t.Fatalf("expected history length 2, got %d", session.History.Len())
@@ -50,22 +57,144 @@ func TestSendPrompt_AppendsUserAndClearsImagePath(t *testing.T) {
 	if last.Content != "hello" {
 		t.Fatalf("expected last content %q, got %q", "hello", last.Content)
 	}
-	if len(last.Images) != 1 {
-		t.Fatalf("expected one image payload, got %d", len(last.Images))
+	if len(last.Images) != 2 || last.Images[1] != "https://example.com/cat.png" {
+		t.Fatalf("expected stored image and unchanged URL, got %v", last.Images)
 	}
 }
 
 func TestSendPrompt_InvalidImageDoesNotAppend(t *testing.T) {
 	session := newTestSession()
-	session.Config.ImagePath = "/path/does/not/exist.jpg"
+	session.Config.ImagePaths = []string{"/path/does/not/exist.jpg"}
 
 	sendPrompt(session, "hello")
 
 	if session.History.Len() != 1 {
 		t.Fatalf("expected history length 1, got %d", session.History.Len())
 	}
-	if session.Config.ImagePath == "" {
-		t.Fatal("expected image path to remain set after add-user failure")
+	if len(session.Config.ImagePaths) != 1 {
+		t.Fatal("expected image paths to remain set after add-user failure")
+	}
+}
+
+func TestSendPrompt_AttachesAndClearsFiles(t *testing.T) {
+	session := newTestSession()
+
+	filePath := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(filePath, []byte("# Notes"), 0644); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+	session.Config.FilePaths = []string{filePath}
+	session.Config.RunOutputs = []string{"Command: `ls` (exit code 0)\n```console\nnotes.md\n```\n\n"}
+
+	sendPrompt(session, "summarize")
+
+	if session.Config.FilePaths != nil || session.Config.RunOutputs != nil {
+		t.Fatalf("expected attachments to be cleared, got %v %v", session.Config.FilePaths, session.Config.RunOutputs)
+	}
+	last := session.History.GetLast()
+	if !strings.Contains(last.Content, "```markdown\n# Notes\n```") || !strings.Contains(last.Content, "```console\nnotes.md\n```\n\nsummarize") {
+		t.Fatalf("unexpected content: %q", last.Content)
+	}
+}
+
+func TestRunMacro(t *testing.T) {
+	session := newTestSession()
+
+	quit, failed := runMacro(session, []string{"/system Be brief.", "/inject user hello"}, 1)
+	if quit || failed {
+		t.Fatalf("runMacro() = %v, %v", quit, failed)
+	}
+	msgs := session.History.Get()
+	if msgs[0].Content != "Be brief." || session.History.GetLast().Content != "hello" {
+		t.Fatalf("unexpected history: %+v", msgs)
+	}
+
+	// the prompt fails with the invalid backend URL
+	if _, failed := runMacro(session, []string{"hello", "/clear"}, 1); !failed {
+		t.Fatal("expected failing prompt")
+	}
+	if session.History.Len() == 1 {
+		t.Fatal("macro continued after failing prompt")
+	}
+
+	before := session.History.Len()
+	if _, failed := runMacro(session, []string{"/unknown", "not sent"}, 1); !failed {
+		t.Fatal("expected failing step")
+	}
+	if session.History.Len() != before {
+		t.Fatal("macro continued after failing step")
+	}
+	if _, failed := runMacro(session, []string{"hello"}, command.MaxExpansionDepth+1); !failed {
+		t.Fatal("expected nesting error")
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	steps := []command.ScriptStep{
+		{Line: 1, Text: "/system Be brief.", IsCommand: true},
+		{Line: 2, Text: "/unknown", IsCommand: true},
+		{Line: 3, Text: "/inject user hello", IsCommand: true},
+	}
+
+	session := newTestSession()
+	session.Script = steps
+	if failed := runScript(session); failed != 1 {
+		t.Fatalf("runScript() = %d failed, want 1", failed)
+	}
+	if session.History.Len() != 1 || session.History.Get()[0].Content != "Be brief." {
+		t.Fatalf("script did not stop after failed step: %+v", session.History.Get())
+	}
+
+	session = newTestSession()
+	session.Script = steps
+	session.KeepGoing = true
+	if failed := runScript(session); failed != 1 {
+		t.Fatalf("runScript() = %d failed, want 1", failed)
+	}
+	if session.History.GetLast().Content != "hello" {
+		t.Fatalf("script did not keep going: %+v", session.History.Get())
+	}
+
+	// prompts fail with the invalid backend URL
+	session = newTestSession()
+	session.Script = []command.ScriptStep{{Line: 1, Text: "hello"}, {Line: 2, Text: "again"}}
+	session.KeepGoing = true
+	if failed := runScript(session); failed != 2 {
+		t.Fatalf("runScript() = %d failed, want 2", failed)
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"content":"ok"},"done":true}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.jsonl")
+	out := filepath.Join(dir, "out.jsonl")
+	if err := os.WriteFile(in, []byte(`{"id":"a","prompt":"one"}`+"\n"+`{"id":"b","prompt":"two"}`+"\n"), 0644); err != nil {
+		t.Fatalf("write input failed: %v", err)
+	}
+	if err := os.WriteFile(out, []byte(`{"id":"a","output":"old"}`+"\n"), 0644); err != nil {
+		t.Fatalf("write output failed: %v", err)
+	}
+
+	session := newTestSession()
+	session.Config.URL = server.URL
+	if err := runBatch(session, in, out, 2); err != nil {
+		t.Fatalf("runBatch() error: %v", err)
+	}
+	data, _ := os.ReadFile(out)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"id":"b"`) || !strings.Contains(lines[1], `"output":"ok"`) {
+		t.Fatalf("unexpected output file:\n%s", data)
+	}
+
+	session.Config.URL = "://invalid-url"
+	os.Remove(out)
+	if err := runBatch(session, in, out, 2); err == nil || !strings.Contains(err.Error(), "2 batch item(s) failed") {
+		t.Fatalf("expected failed items, got %v", err)
 	}
 }
 
@@ -99,6 +228,25 @@ func TestRetryPrompt_InvalidURLDoesNotAppendAssistant(t *testing.T) {
 	}
 }
 
+func TestRunChat_RequestConfigAppliesOnce(t *testing.T) {
+	session := newTestSession()
+	if err := session.History.AddUser("hello", ""); err != nil {
+		t.Fatalf("add user failed: %v", err)
+	}
+	reqCfg := *session.Config
+	reqCfg.Model = "template-model"
+	session.RequestConfig = &reqCfg
+
+	runChat(session)
+
+	if session.RequestConfig != nil {
+		t.Fatal("expected request config to be consumed")
+	}
+	if session.Config.Model != "test-model" {
+		t.Fatalf("session model changed to %q", session.Config.Model)
+	}
+}
+
 func TestInitSessionFromArgs_ShowVersionShortCircuit(t *testing.T) {
 	prevShowVersion := *args.ShowVersion
 	t.Cleanup(func() {
@@ -0,0 +1,804 @@
+package markdown
+
+import (
+	"io"
+	"picochat/console"
+	"picochat/highlight"
+	"picochat/utils"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ruleWidth is the width of a horizontal rule.
+const ruleWidth = 40
+
+// lineKind is the kind of a Markdown line.
+type lineKind int
+
+const (
+	kindText lineKind = iota
+	kindHeading
+	kindBullet
+	kindOrdered
+	kindQuote
+	kindRule
+	kindFence
+	kindTable
+)
+
+// block is a classified line: its kind, the text in front of the content
+// and the byte offset of the content.
+type block struct {
+	kind    lineKind
+	indent  string
+	marker  string // list marker or heading level
+	content int
+}
+
+// Renderer renders Markdown text for the terminal while it is streamed.
+// Lines are styled as soon as their kind is known, so that the text appears
+// with the chunks of the answer. Tables are buffered until their last row
+// has arrived, because their columns are aligned.
+type Renderer struct {
+	w       io.Writer
+	line    strings.Builder // start of the current line until its kind is known
+	decided bool            // the kind of the current line is known
+	kind    lineKind
+	inline  inline
+	fence   string // marker of the open code fence, empty outside of code
+	theme   *highlight.Theme
+	styles  console.Theme          // styles of the elements, from the active UI theme
+	code    *highlight.Highlighter // highlighter of the open code fence, nil if none
+	table   []string               // buffered table lines
+	partial []byte                 // incomplete UTF-8 sequence at the end of a chunk
+	err     error
+}
+
+// NewRenderer returns a renderer that writes to w. Headings, quotes,
+// fences and other elements are styled by the active console theme.
+//
+// Parameters:
+//
+//	w (io.Writer)            - the terminal output
+//	theme (*highlight.Theme) - the colors of fenced code, nil disables highlighting
+//
+// Returns:
+//
+//	*Renderer - the renderer
+func NewRenderer(w io.Writer, theme *highlight.Theme) *Renderer {
+	r := &Renderer{w: w, theme: theme, styles: console.ActiveTheme()}
+	r.inline.out = r.print
+	r.inline.codeStyle = r.styles.Code
+	return r
+}
+
+// Write renders the next chunk of the text.
+//
+// Parameters:
+//
+//	p ([]byte) - the chunk
+//
+// Returns:
+//
+//	int   - the number of bytes consumed, always len(p)
+//	error - error if the output cannot be written
+func (r *Renderer) Write(p []byte) (int, error) {
+	data := append(r.partial, p...)
+	r.partial = nil
+	for len(data) > 0 {
+		c, size := utf8.DecodeRune(data)
+		if c == utf8.RuneError && !utf8.FullRune(data) {
+			r.partial = append([]byte(nil), data...)
+			break
+		}
+		r.feed(c)
+		data = data[size:]
+	}
+	return len(p), r.err
+}
+
+// Flush renders the rest of the text, including an unfinished last line
+// and a buffered table. The output ends without a line break.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	error - error if the output cannot be written
+func (r *Renderer) Flush() error {
+	if len(r.partial) > 0 {
+		r.line.Write(r.partial)
+		r.partial = nil
+	}
+	// a table is followed by a line break unless its last row is unfinished
+	pending := r.line.Len() > 0 || r.decided
+	if pending {
+		r.finishLine(false)
+	}
+	r.flushTable(!pending)
+	return r.err
+}
+
+// feed renders one character.
+//
+// Parameters:
+//
+//	c (rune) - the character
+//
+// Returns:
+//
+//	none
+func (r *Renderer) feed(c rune) {
+	switch {
+	case c == '\n':
+		r.finishLine(true)
+	case r.decided:
+		r.inline.feed(c)
+	default:
+		r.line.WriteRune(c)
+		if r.fence == "" {
+			text := r.line.String()
+			if b, ok := classify(text, false); ok && b.kind != kindFence && b.kind != kindTable && b.kind != kindRule {
+				r.line.Reset()
+				r.start(text, b)
+			}
+		}
+	}
+}
+
+// start begins a line whose kind is known: the prefix is printed and the
+// content is streamed from now on.
+//
+// Parameters:
+//
+//	text (string) - the start of the line read so far
+//	b (block)     - the classified line
+//
+// Returns:
+//
+//	none
+func (r *Renderer) start(text string, b block) {
+	r.flushTable(true)
+	r.decided, r.kind = true, b.kind
+
+	base := ""
+	switch b.kind {
+	case kindHeading:
+		base = r.styles.Heading
+		if len(b.marker) == 1 {
+			base += console.Underline
+		}
+		r.print(b.indent)
+	case kindBullet:
+		r.print(b.indent + r.styled(r.styles.Marker, "•") + " ")
+	case kindOrdered:
+		r.print(b.indent + r.styled(r.styles.Marker, b.marker) + " ")
+	case kindQuote:
+		base = r.styles.Quote
+		r.print(b.indent + r.styled(r.styles.Border, "│") + " ")
+	default:
+		r.print(b.indent)
+	}
+	r.inline.begin(base)
+	for _, c := range text[b.content:] {
+		r.inline.feed(c)
+	}
+}
+
+// finishLine ends the current line. Lines that are buffered until their
+// end (code, fences, tables and rules) are rendered here.
+//
+// Parameters:
+//
+//	newline (bool) - the line ends with a line break
+//
+// Returns:
+//
+//	none
+func (r *Renderer) finishLine(newline bool) {
+	if r.decided {
+		r.inline.end()
+		r.decided = false
+		if newline {
+			r.print("\n")
+		}
+		return
+	}
+
+	text := r.line.String()
+	r.line.Reset()
+	if r.fence != "" {
+		r.codeLine(text, newline)
+		return
+	}
+
+	b, _ := classify(text, true)
+	switch b.kind {
+	case kindTable:
+		r.table = append(r.table, text)
+		return
+	case kindFence:
+		r.flushTable(true)
+		trimmed := strings.TrimSpace(text)
+		r.fence = trimmed[:3]
+		r.print(b.indent + r.styled(r.styles.Border, r.fence))
+		if lang := strings.TrimSpace(trimmed[3:]); lang != "" {
+			r.print(" " + r.styled(r.styles.Label, lang))
+			r.code = highlight.New(lang, r.theme, "")
+		}
+	case kindRule:
+		r.flushTable(true)
+		r.print(b.indent + r.styled(r.styles.Border, strings.Repeat("─", ruleWidth)))
+	default:
+		r.start(text, b)
+		r.inline.end()
+		r.decided = false
+	}
+	if newline {
+		r.print("\n")
+	}
+}
+
+// codeLine renders a line inside a code fence, or the closing fence.
+//
+// Parameters:
+//
+//	text (string)  - the line
+//	newline (bool) - the line ends with a line break
+//
+// Returns:
+//
+//	none
+func (r *Renderer) codeLine(text string, newline bool) {
+	if trimmed := strings.TrimSpace(text); strings.HasPrefix(trimmed, r.fence) && strings.Trim(trimmed, r.fence[:1]) == "" {
+		r.fence, r.code = "", nil
+		r.print(r.styled(r.styles.Border, text))
+	} else if r.code != nil {
+		r.print(r.code.Line(text))
+	} else {
+		r.print(text)
+	}
+	if newline {
+		r.print("\n")
+	}
+}
+
+// flushTable renders the buffered table. Rows without a separator line
+// below the header are not a table and are rendered as text.
+//
+// Parameters:
+//
+//	newline (bool) - end the table with a line break
+//
+// Returns:
+//
+//	none
+func (r *Renderer) flushTable(newline bool) {
+	if len(r.table) == 0 {
+		return
+	}
+	lines := r.table
+	r.table = nil
+
+	var rows [][]string
+	for _, line := range lines {
+		rows = append(rows, splitRow(line))
+	}
+	if len(rows) < 2 || !isSeparatorRow(rows[1]) {
+		for i, line := range lines {
+			r.print(r.renderInline(line, ""))
+			if i < len(lines)-1 || newline {
+				r.print("\n")
+			}
+		}
+		return
+	}
+
+	data := make([][]string, 0, len(rows)-1)
+	for i, row := range rows {
+		if i == 1 {
+			continue
+		}
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			if i == 0 {
+				cell = console.Bold + cell + console.ColorReset
+			}
+			cells[j] = r.renderInline(cell, "")
+		}
+		data = append(data, cells)
+	}
+	table := strings.Split(utils.MarkdownTable(data), "\n")
+	for i, line := range table {
+		if i == 1 {
+			line = r.styled(r.styles.Border, line)
+		}
+		r.print(line)
+		if i < len(table)-1 || newline {
+			r.print("\n")
+		}
+	}
+}
+
+// styled encloses text in a style. Elements without a style in the theme
+// stay plain.
+//
+// Parameters:
+//
+//	style (string) - esc sequences of the style
+//	text (string)  - the text
+//
+// Returns:
+//
+//	string - the styled text
+func (r *Renderer) styled(style, text string) string {
+	if style == "" {
+		return text
+	}
+	return style + text + console.ColorReset
+}
+
+// print writes text to the output and keeps the first error.
+//
+// Parameters:
+//
+//	s (string) - the text
+//
+// Returns:
+//
+//	none
+func (r *Renderer) print(s string) {
+	if r.err == nil && s != "" {
+		_, r.err = io.WriteString(r.w, s)
+	}
+}
+
+// classify determines the kind of a line from its start.
+//
+// Parameters:
+//
+//	line (string)   - the line or its start
+//	complete (bool) - the line is complete
+//
+// Returns:
+//
+//	block - the classified line
+//	bool  - false if more characters are needed
+func classify(line string, complete bool) (block, bool) {
+	trimmed := strings.TrimLeft(line, " \t")
+	indent := line[:len(line)-len(trimmed)]
+	text := block{kind: kindText, indent: indent, content: len(indent)}
+	if trimmed == "" {
+		return text, complete
+	}
+
+	switch c := trimmed[0]; {
+	case c == '`' || c == '~':
+		fence := strings.Repeat(string(c), 3)
+		if strings.HasPrefix(trimmed, fence) {
+			return block{kind: kindFence, indent: indent}, true
+		}
+		if !complete && strings.HasPrefix(fence, trimmed) {
+			return block{}, false
+		}
+	case c == '|':
+		return block{kind: kindTable, indent: indent}, true
+	case c == '#':
+		level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+		rest := trimmed[level:]
+		if level > 6 {
+			break
+		}
+		if rest == "" && !complete {
+			return block{}, false
+		}
+		if rest == "" || rest[0] == ' ' {
+			content := len(line) - len(strings.TrimLeft(rest, " "))
+			return block{kind: kindHeading, indent: indent, marker: trimmed[:level], content: content}, true
+		}
+	case c == '-' || c == '*' || c == '_' || c == '+':
+		if isRule(trimmed, c) {
+			if complete && strings.Count(trimmed, string(c)) >= 3 {
+				return block{kind: kindRule, indent: indent}, true
+			}
+			if !complete {
+				return block{}, false // a rule or a list item
+			}
+		}
+		if c != '_' && len(trimmed) > 1 && trimmed[1] == ' ' {
+			return block{kind: kindBullet, indent: indent, content: len(indent) + 2}, true
+		}
+	case c >= '0' && c <= '9':
+		digits := len(trimmed) - len(strings.TrimLeft(trimmed, "0123456789"))
+		rest := trimmed[digits:]
+		if !complete && (rest == "" || rest == "." || rest == ")") {
+			return block{}, false
+		}
+		if digits <= 9 && len(rest) > 1 && (rest[0] == '.' || rest[0] == ')') && rest[1] == ' ' {
+			return block{kind: kindOrdered, indent: indent, marker: trimmed[:digits+1], content: len(indent) + digits + 2}, true
+		}
+	case c == '>':
+		if len(trimmed) == 1 && !complete {
+			return block{}, false
+		}
+		content := len(indent) + 1
+		if len(trimmed) > 1 && trimmed[1] == ' ' {
+			content++
+		}
+		return block{kind: kindQuote, indent: indent, content: content}, true
+	}
+	return text, true
+}
+
+// isRule reports whether a line consists only of a rule character and
+// spaces.
+//
+// Parameters:
+//
+//	s (string) - the line without indentation
+//	c (byte)   - the rule character
+//
+// Returns:
+//
+//	bool - true if the line may be a horizontal rule
+func isRule(s string, c byte) bool {
+	return strings.Trim(s, string(c)+" \t") == ""
+}
+
+// splitRow returns the trimmed cells of a table line.
+//
+// Parameters:
+//
+//	line (string) - the table line
+//
+// Returns:
+//
+//	[]string - the cells
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "\\|") {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// isSeparatorRow reports whether the cells form the separator line below a
+// table header, e.g. "---", ":---" or ":---:".
+//
+// Parameters:
+//
+//	cells ([]string) - the cells
+//
+// Returns:
+//
+//	bool - true for a separator line
+func isSeparatorRow(cells []string) bool {
+	for _, cell := range cells {
+		trimmed := strings.Trim(cell, ":")
+		if trimmed == "" || strings.Trim(trimmed, "-") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// inline renders emphasis, strong emphasis and code spans of a line while
+// it is streamed. Markers are held back until the next character shows
+// whether they open or close a span. The text of an open span is held until
+// the span is closed; if the line ends first, the opening marker is printed
+// literally and the rest of the line is rendered again.
+type inline struct {
+	out       func(string)
+	base      string // style of the line, e.g. for headings
+	codeStyle string
+	strong    bool
+	em        bool
+	code      bool
+	held      []rune // emphasis markers waiting for the next character
+	prev      rune   // last printed character
+	escaped   bool
+	opener    string          // markers of the outermost open span, empty if none
+	raw       []rune          // characters after the opener
+	pending   strings.Builder // styled output of the open spans
+}
+
+// begin starts a line.
+//
+// Parameters:
+//
+//	base (string) - the style of the line
+//
+// Returns:
+//
+//	none
+func (in *inline) begin(base string) {
+	in.base = base
+	in.strong, in.em, in.code, in.escaped = false, false, false, false
+	in.held = nil
+	in.prev = ' '
+	in.opener, in.raw = "", nil
+	in.pending.Reset()
+	in.out(base)
+}
+
+// feed renders one character of the line.
+//
+// Parameters:
+//
+//	c (rune) - the character
+//
+// Returns:
+//
+//	none
+func (in *inline) feed(c rune) {
+	if in.opener != "" {
+		in.raw = append(in.raw, c)
+	}
+	if len(in.held) > 0 {
+		if c == in.held[0] && len(in.held) < 3 {
+			in.held = append(in.held, c)
+			return
+		}
+		in.resolve(c)
+	}
+
+	switch {
+	case in.escaped:
+		in.escaped = false
+		if !unicode.IsPunct(c) && !unicode.IsSymbol(c) {
+			in.emit('\\')
+		}
+		in.emit(c)
+	case in.code:
+		if c == '`' {
+			in.code = false
+			in.restyle()
+		} else {
+			in.emit(c)
+		}
+	case c == '\\':
+		in.escaped = true
+	case c == '`':
+		in.code = true
+		in.hold("`", 0)
+		in.restyle()
+	case c == '*' || c == '_':
+		in.held = []rune{c}
+	default:
+		in.emit(c)
+	}
+}
+
+// end finishes the line and resets the style.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (in *inline) end() {
+	if len(in.held) > 0 {
+		in.resolve(' ')
+	}
+	if in.opener != "" {
+		in.unwind()
+		return
+	}
+	if in.escaped {
+		in.emit('\\')
+		in.escaped = false
+	}
+	if in.base != "" || in.strong || in.em || in.code {
+		in.out(console.ColorReset)
+	}
+}
+
+// resolve decides whether the held markers open or close a span. A marker
+// opens a span before a non-blank character and closes it after one;
+// underscores inside words (snake_case) stay literal.
+//
+// Parameters:
+//
+//	next (rune) - the character after the markers
+//
+// Returns:
+//
+//	none
+func (in *inline) resolve(next rune) {
+	markers := in.held
+	in.held = nil
+
+	opens := !unicode.IsSpace(next)
+	closes := !unicode.IsSpace(in.prev)
+	if markers[0] == '_' {
+		opens = opens && !isWordChar(in.prev)
+		closes = closes && !isWordChar(next)
+	}
+
+	toggle := func(active *bool) bool {
+		if (*active && closes) || (!*active && opens) {
+			*active = !*active
+			return true
+		}
+		return false
+	}
+
+	changed := false
+	switch len(markers) {
+	case 1:
+		changed = toggle(&in.em)
+	case 2:
+		changed = toggle(&in.strong)
+	default:
+		if changed = toggle(&in.strong); changed {
+			toggle(&in.em)
+		}
+	}
+	if !changed {
+		for _, m := range markers {
+			in.emit(m)
+		}
+		return
+	}
+	in.hold(string(markers), next)
+	in.restyle()
+}
+
+// hold starts holding the output when the outermost span opens.
+//
+// Parameters:
+//
+//	opener (string) - the opening markers
+//	next (rune)     - the character after the markers, 0 if not yet read
+//
+// Returns:
+//
+//	none
+func (in *inline) hold(opener string, next rune) {
+	if in.opener != "" {
+		return
+	}
+	in.opener, in.raw = opener, nil
+	if next != 0 {
+		in.raw = append(in.raw, next)
+	}
+}
+
+// unwind handles spans that are still open at the end of the line: the
+// held output is dropped, the opening marker is printed literally and the
+// characters after it are rendered again.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (in *inline) unwind() {
+	opener, raw := in.opener, in.raw
+	in.opener, in.raw = "", nil
+	in.pending.Reset()
+	in.strong, in.em, in.code, in.escaped = false, false, false, false
+	for _, m := range opener {
+		in.emit(m)
+	}
+	for _, c := range raw {
+		in.feed(c)
+	}
+	in.end()
+}
+
+// restyle applies the style of the active spans.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (in *inline) restyle() {
+	style := console.ColorReset + in.base
+	if in.strong {
+		style += console.Bold
+	}
+	if in.em {
+		style += console.Italics
+	}
+	if in.code {
+		style += in.codeStyle
+	}
+	in.write(style)
+	if in.opener != "" && !in.strong && !in.em && !in.code {
+		in.out(in.pending.String())
+		in.opener, in.raw = "", nil
+		in.pending.Reset()
+	}
+}
+
+// write prints styled text, or holds it while a span is open.
+//
+// Parameters:
+//
+//	s (string) - the text
+//
+// Returns:
+//
+//	none
+func (in *inline) write(s string) {
+	if in.opener != "" {
+		in.pending.WriteString(s)
+		return
+	}
+	in.out(s)
+}
+
+// emit prints a character.
+//
+// Parameters:
+//
+//	c (rune) - the character
+//
+// Returns:
+//
+//	none
+func (in *inline) emit(c rune) {
+	in.write(string(c))
+	in.prev = c
+}
+
+// isWordChar reports whether a rune is a letter or digit.
+//
+// Parameters:
+//
+//	r (rune) - the character
+//
+// Returns:
+//
+//	bool - true for letters and digits
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// renderInline renders the emphasis and code spans of a complete text.
+//
+// Parameters:
+//
+//	text (string) - the text
+//	base (string) - the style of the text
+//
+// Returns:
+//
+//	string - the styled text
+func (r *Renderer) renderInline(text, base string) string {
+	var sb strings.Builder
+	in := inline{out: func(s string) { sb.WriteString(s) }, codeStyle: r.styles.Code}
+	in.begin(base)
+	for _, c := range text {
+		in.feed(c)
+	}
+	in.end()
+	return sb.String()
+}
@@ -0,0 +1,244 @@
+package markdown
+
+import (
+	"bytes"
+	"picochat/console"
+	"picochat/highlight"
+	"strings"
+	"testing"
+)
+
+// styles are the styles of the default theme, which is active in the tests.
+var styles = console.Themes["default"]
+
+// render renders the text in chunks of the given size.
+func render(t *testing.T, text string, chunk int) string {
+	t.Helper()
+	return renderTheme(t, text, chunk, nil)
+}
+
+// renderTheme renders the text with highlighted code.
+func renderTheme(t *testing.T, text string, chunk int, theme *highlight.Theme) string {
+	t.Helper()
+	var out bytes.Buffer
+	r := NewRenderer(&out, theme)
+	data := []byte(text)
+	for len(data) > 0 {
+		n := min(chunk, len(data))
+		if _, err := r.Write(data[:n]); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+		data = data[n:]
+	}
+	if err := r.Flush(); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+	return out.String()
+}
+
+// plain removes the escape sequences of the rendered text.
+func plain(s string) string {
+	for {
+		start := strings.Index(s, "\033[")
+		if start < 0 {
+			return s
+		}
+		end := strings.IndexFunc(s[start+2:], func(r rune) bool { return r >= 'A' && r <= 'z' && r != '[' && r != ';' })
+		s = s[:start] + s[start+2+end+1:]
+	}
+}
+
+func TestRenderer_Blocks(t *testing.T) {
+	reset := console.ColorReset
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"text", "plain text", "plain text"},
+		{"heading 1", "# Title", styles.Heading + console.Underline + "Title" + reset},
+		{"heading 3", "### Title", styles.Heading + "Title" + reset},
+		{"hashtag", "#tag", "#tag"},
+		{"too many hashes", "####### x", "####### x"},
+		{"bullet", "- item", styles.Marker + "•" + reset + " item"},
+		{"nested bullet", "  * item", "  " + styles.Marker + "•" + reset + " item"},
+		{"ordered", "12. item", styles.Marker + "12." + reset + " item"},
+		{"ordered paren", "3) item", styles.Marker + "3)" + reset + " item"},
+		{"number", "2024 was", "2024 was"},
+		{"quote", "> said", styles.Border + "│" + reset + " " + styles.Quote + "said" + reset},
+		{"rule", "---", styles.Border + strings.Repeat("─", ruleWidth) + reset},
+		{"rule with spaces", "* * *", styles.Border + strings.Repeat("─", ruleWidth) + reset},
+		{"emphasis", "an *important* word", "an " + reset + console.Italics + "important" + reset + " word"},
+		{"strong", "a **bold** word", "a " + reset + console.Bold + "bold" + reset + " word"},
+		{"strong emphasis", "***both***", reset + console.Bold + console.Italics + "both" + reset},
+		{"underscore emphasis", "an _important_ word", "an " + reset + console.Italics + "important" + reset + " word"},
+		{"snake case", "call snake_case_name", "call snake_case_name"},
+		{"multiplication", "2 * 3 * 4", "2 * 3 * 4"},
+		{"unclosed emphasis", "*open", "*open"},
+		{"code span", "run `go *test*`", "run " + reset + styles.Code + "go *test*" + reset},
+		{"escape", `\*not\* emphasis`, "*not* emphasis"},
+		{"backslash", `C:\dir`, `C:\dir`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := render(t, tt.input, len(tt.input)); got != tt.want {
+				t.Errorf("render(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderer_UnmatchedMarkers(t *testing.T) {
+	reset := console.ColorReset
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"pointer", "x = *ptr", "x = *ptr"},
+		{"glob", "glob *.go files", "glob *.go files"},
+		{"price", "price $5*2", "price $5*2"},
+		{"strong", "a **b", "a **b"},
+		{"code span", "run `go test", "run `go test"},
+		{"closed then open", "*a* and *b", reset + console.Italics + "a" + reset + " and *b"},
+		{"open before closed", "*a `b`", "*a " + reset + styles.Code + "b" + reset},
+		{"next line", "x = *ptr\n*y*", "x = *ptr\n" + reset + console.Italics + "y" + reset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, chunk := range []int{1, len(tt.input)} {
+				if got := render(t, tt.input, chunk); got != tt.want {
+					t.Errorf("render(%q) with chunk size %d = %q, want %q", tt.input, chunk, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderer_Theme(t *testing.T) {
+	t.Cleanup(func() { _ = console.SetTheme("default", nil) })
+	if err := console.SetTheme("light", map[string]string{"border": "none"}); err != nil {
+		t.Fatalf("SetTheme failed: %v", err)
+	}
+	light := console.ActiveTheme()
+
+	input := "> quote\n---\nuse `go vet`\n```\ncode\n```"
+	want := "│ " + light.Quote + "quote" + console.ColorReset + "\n" +
+		strings.Repeat("─", ruleWidth) + "\n" +
+		"use " + console.ColorReset + light.Code + "go vet" + console.ColorReset + "\n" +
+		"```\ncode\n```"
+	if got := render(t, input, len(input)); got != want {
+		t.Errorf("render() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderer_ShortLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"blank", "  ", "  "},
+		{"blank then text", "a\n  \nb", "a\n  \nb"},
+		{"digits", "42", "42"},
+		{"digits then text", "The answer:\n42\n", "The answer:\n42\n"},
+		{"year then text", "2024\nok", "2024\nok"},
+		{"hash", "#", ""},
+		{"hash then text", "#\nok", "\nok"},
+		{"quote marker", ">", "│ "},
+		{"quote marker then text", ">\nok", "│ \nok"},
+		{"two dashes", "--", "--"},
+		{"two dashes then text", "--\nok", "--\nok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, chunk := range []int{1, len(tt.input)} {
+				if got := plain(render(t, tt.input, chunk)); got != tt.want {
+					t.Errorf("render(%q) with chunk size %d = %q, want %q", tt.input, chunk, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderer_Fence(t *testing.T) {
+	input := "```go\nx := a * b * c\n# no heading\n```\nafter"
+	want := styles.Border + "```" + console.ColorReset + " " + styles.Label + "go" + console.ColorReset + "\n" +
+		"x := a * b * c\n# no heading\n" +
+		styles.Border + "```" + console.ColorReset + "\nafter"
+
+	if got := render(t, input, len(input)); got != want {
+		t.Errorf("render() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderer_HighlightedFence(t *testing.T) {
+	input := "```python\nreturn 1\n```\n```text\nreturn 1\n```"
+	theme := &highlight.Basic
+	reset := console.ColorReset
+	want := styles.Border + "```" + reset + " " + styles.Label + "python" + reset + "\n" +
+		theme.Keyword + "return" + reset + " " + theme.Number + "1" + reset + "\n" +
+		styles.Border + "```" + reset + "\n" +
+		styles.Border + "```" + reset + " " + styles.Label + "text" + reset + "\n" +
+		"return 1\n" +
+		styles.Border + "```" + reset
+
+	for _, chunk := range []int{1, len(input)} {
+		if got := renderTheme(t, input, chunk, theme); got != want {
+			t.Errorf("render() with chunk size %d = %q, want %q", chunk, got, want)
+		}
+	}
+}
+
+func TestRenderer_Table(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "aligned columns",
+			input: "| Name | Qty |\n|:--|--:|\n| 中国 | 1 |\n| **x** | 22 |\nafter",
+			want:  "| Name | Qty |\n| ---- | --- |\n| 中国 | 1   |\n| x    | 22  |\nafter",
+		},
+		{
+			name:  "table at the end",
+			input: "| a | b |\n|---|---|\n| 1 | 2 |",
+			want:  "| a   | b   |\n| --- | --- |\n| 1   | 2   |",
+		},
+		{
+			name:  "escaped pipe",
+			input: "| a |\n|---|\n| x \\| y |\n",
+			want:  "| a     |\n| ----- |\n| x | y |\n",
+		},
+		{
+			name:  "no separator",
+			input: "| just a line\ntext",
+			want:  "| just a line\ntext",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := plain(render(t, tt.input, len(tt.input))); got != tt.want {
+				t.Errorf("render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderer_Streaming(t *testing.T) {
+	input := "# Report 😊\n\nSome **bold** and *em* text with `code`.\n" +
+		"- 中国是一个拥有悠久历史的文明古国。\n1. first\n> quote\n\n" +
+		"| k | v |\n|---|---|\n| a | b |\n---\n```sh\necho *\n```\nlast line"
+	want := render(t, input, len(input))
+
+	for _, chunk := range []int{1, 2, 3, 7} {
+		if got := render(t, input, chunk); got != want {
+			t.Errorf("render() with chunk size %d = %q, want %q", chunk, got, want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package messages
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// CodeBlock is a fenced code block of a Markdown message.
+type CodeBlock struct {
+	Lang     string // language of the info string, may be empty
+	Filename string // file name annotated by the model, may be empty
+	Code     string // content, each line terminated by a newline
+	Lines    int    // number of lines
+}
+
+var (
+	fenceOpenRe   = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})\\s*(.*)$")
+	infoFileRe    = regexp.MustCompile(`(?:title|file|filename|name|path)=["']?([^"'\s]+)`)
+	labelFileRe   = regexp.MustCompile("^(?:#+\\s*)?(?:[Ff]ile(?:name)?:\\s*)?[*_`]*([\\w./\\\\-]+\\.[\\w]+)[*_`]*:?$")
+	commentFileRe = regexp.MustCompile(`^(?://|#|--|;|/\*|<!--)\s*(?:[Ff]ile(?:name)?:\s*)?([\w./-]+\.\w+)\s*(?:\*/|-->)?$`)
+)
+
+// ExtractCodeBlocks returns all fenced code blocks of a Markdown text.
+// File names are taken from the info string (e.g. "go title=main.go" or
+// "go:main.go"), from a label line right before the fence (e.g.
+// "**main.go**" or "File: `main.go`") or from a comment in the first line
+// of the code (e.g. "// main.go").
+//
+// Parameters:
+//
+//	s (string) - the Markdown text
+//
+// Returns:
+//
+//	[]CodeBlock - the code blocks in order of appearance
+func ExtractCodeBlocks(s string) []CodeBlock {
+	var blocks []CodeBlock
+	lines := strings.Split(s, "\n")
+	label := "" // last non-empty line before a fence
+
+	for i := 0; i < len(lines); i++ {
+		m := fenceOpenRe.FindStringSubmatch(lines[i])
+		if m == nil || (m[1][0] == '`' && strings.Contains(m[2], "`")) {
+			if t := strings.TrimSpace(lines[i]); t != "" {
+				label = t
+			}
+			continue
+		}
+
+		fence := m[1]
+		block := CodeBlock{}
+		block.Lang, block.Filename = parseInfoString(m[2])
+		if block.Filename == "" {
+			block.Filename = labelFilename(label)
+		}
+
+		var code []string
+		closed := false
+		for i++; i < len(lines); i++ {
+			t := strings.TrimSpace(lines[i])
+			if strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]) == "" {
+				closed = true
+				break
+			}
+			code = append(code, lines[i])
+		}
+		if !closed && len(code) > 0 && code[len(code)-1] == "" {
+			code = code[:len(code)-1] // trailing newline of an unterminated block
+		}
+
+		if block.Filename == "" && len(code) > 0 {
+			if c := commentFileRe.FindStringSubmatch(strings.TrimSpace(code[0])); c != nil {
+				block.Filename = c[1]
+			}
+		}
+		block.Lines = len(code)
+		if len(code) > 0 {
+			block.Code = strings.Join(code, "\n") + "\n"
+		}
+		blocks = append(blocks, block)
+		label = ""
+	}
+
+	return blocks
+}
+
+// parseInfoString splits a fence info string into language and file name.
+//
+// Parameters:
+//
+//	info (string) - text after the opening fence
+//
+// Returns:
+//
+//	string - the language
+//	string - the file name or empty string
+func parseInfoString(info string) (string, string) {
+	info = strings.TrimSpace(info)
+	if info == "" {
+		return "", ""
+	}
+	if m := infoFileRe.FindStringSubmatch(info); m != nil {
+		lang, _, _ := strings.Cut(strings.Fields(info)[0], ":")
+		if strings.Contains(lang, "=") {
+			lang = ""
+		}
+		return lang, m[1]
+	}
+
+	fields := strings.Fields(info)
+	lang := fields[0]
+	if l, file, ok := strings.Cut(lang, ":"); ok && isPathLike(file) {
+		return l, file // "go:main.go"
+	}
+	if len(fields) > 1 && isPathLike(fields[1]) {
+		return lang, fields[1] // "go main.go"
+	}
+	if isPathLike(lang) && strings.ContainsAny(lang, "./") {
+		return strings.TrimPrefix(filepath.Ext(lang), "."), lang // "main.go"
+	}
+	return lang, ""
+}
+
+// labelFilename extracts a file name from a label line like "**main.go**".
+//
+// Parameters:
+//
+//	label (string) - the line before the fence
+//
+// Returns:
+//
+//	string - the file name or empty string
+func labelFilename(label string) string {
+	if m := labelFileRe.FindStringSubmatch(label); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// isPathLike checks if a string looks like a relative file name with an
+// extension.
+//
+// Parameters:
+//
+//	s (string) - the candidate
+//
+// Returns:
+//
+//	bool - true if s contains a dot-separated extension
+func isPathLike(s string) bool {
+	ext := filepath.Ext(s)
+	return len(ext) > 1 && len(ext) < len(s) && !strings.ContainsAny(s, " \t\"'`")
+}
@@ -0,0 +1,57 @@
+package messages
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractCodeBlocks(t *testing.T) {
+	text := "Intro\n" +
+		"**main.go**\n```go\npackage main\n```\n" +
+		"Run it:\n```sh\ngo run .\n```\n" +
+		"```python title=\"app/util.py\"\nx = 1\n```\n" +
+		"```js:web/app.js\nlet a\n```\n" +
+		"```\n// cmd/tool.go\npackage tool\n```\n" +
+		"````markdown\n```go\nnested\n```\n````\n" +
+		"~~~\ntilde\n~~~\n" +
+		"```go\nunterminated\n"
+
+	want := []CodeBlock{
+		{Lang: "go", Filename: "main.go", Code: "package main\n", Lines: 1},
+		{Lang: "sh", Code: "go run .\n", Lines: 1},
+		{Lang: "python", Filename: "app/util.py", Code: "x = 1\n", Lines: 1},
+		{Lang: "js", Filename: "web/app.js", Code: "let a\n", Lines: 1},
+		{Filename: "cmd/tool.go", Code: "// cmd/tool.go\npackage tool\n", Lines: 2},
+		{Lang: "markdown", Code: "```go\nnested\n```\n", Lines: 3},
+		{Code: "tilde\n", Lines: 1},
+		{Lang: "go", Code: "unterminated\n", Lines: 1},
+	}
+
+	got := ExtractCodeBlocks(text)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ExtractCodeBlocks() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseInfoString(t *testing.T) {
+	tests := []struct {
+		info     string
+		wantLang string
+		wantFile string
+	}{
+		{"go", "go", ""},
+		{"go main.go", "go", "main.go"},
+		{"go:main.go", "go", "main.go"},
+		{"main.go", "go", "main.go"},
+		{"yaml filename=deploy.yml", "yaml", "deploy.yml"},
+		{"c++", "c++", ""},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		lang, file := parseInfoString(tt.info)
+		if lang != tt.wantLang || file != tt.wantFile {
+			t.Errorf("parseInfoString(%q) = (%q, %q), want (%q, %q)", tt.info, lang, file, tt.wantLang, tt.wantFile)
+		}
+	}
+}
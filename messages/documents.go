@@ -0,0 +1,137 @@
+package messages
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"picochat/extract"
+	"picochat/paths"
+	"strings"
+)
+
+// Document is a file sent to the backend as native file input. The
+// content is kept in the image store like attached images.
+type Document struct {
+	Name string `json:"name"`
+	Ref  string `json:"ref"` // store reference, or data URL after ResolveDocuments
+}
+
+// IsNativeDocument checks if a file can be sent as native file input to
+// backends that support it. Other documents are always sent as text.
+//
+// Parameters:
+//
+//	path (string) - the file path
+//
+// Returns:
+//
+//	bool - true for PDF files
+func IsNativeDocument(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".pdf")
+}
+
+// StoreDocument copies a document into the content-addressed store.
+//
+// Parameters:
+//
+//	path (string) - path to the document
+//
+// Returns:
+//
+//	Document - file name and store reference
+//	error    - error if any
+func StoreDocument(path string) (Document, error) {
+	fullPath, err := paths.ExpandHomeDir(path)
+	if err != nil {
+		return Document{}, err
+	}
+
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return Document{}, fmt.Errorf("read document failed: %w", err)
+	}
+
+	ref, err := storeImageData(data, "application/pdf")
+	if err != nil {
+		return Document{}, err
+	}
+	return Document{Name: filepath.Base(fullPath), Ref: ref}, nil
+}
+
+// ResolveDocuments returns a copy of the messages with all document
+// references replaced by data URLs. The input slice is not modified.
+//
+// Parameters:
+//
+//	in ([]Message) - messages as stored in the history
+//
+// Returns:
+//
+//	[]Message - messages with inline document payloads
+//	error     - error if any document cannot be loaded
+func ResolveDocuments(in []Message) ([]Message, error) {
+	out := make([]Message, len(in))
+	copy(out, in)
+
+	for i := range out {
+		if len(out[i].Documents) == 0 {
+			continue
+		}
+
+		docs := make([]Document, len(out[i].Documents))
+		for j, doc := range out[i].Documents {
+			resolved, err := ResolveImage(doc.Ref)
+			if err != nil {
+				return nil, fmt.Errorf("load document %q failed: %w", doc.Name, err)
+			}
+			docs[j] = Document{Name: doc.Name, Ref: resolved}
+		}
+		out[i].Documents = docs
+	}
+
+	return out, nil
+}
+
+// InlineDocuments returns a copy of the messages with all documents
+// converted into extracted text blocks in front of the message content.
+// It is used by backends without native file input.
+//
+// Parameters:
+//
+//	in ([]Message) - messages as stored in the history
+//
+// Returns:
+//
+//	[]Message - messages without documents
+//	error     - error if any document cannot be loaded or extracted
+func InlineDocuments(in []Message) ([]Message, error) {
+	out := make([]Message, len(in))
+	copy(out, in)
+
+	for i := range out {
+		if len(out[i].Documents) == 0 {
+			continue
+		}
+
+		var sb strings.Builder
+		for _, doc := range out[i].Documents {
+			fullPath, ok, err := imageRefPath(doc.Ref)
+			if err != nil || !ok {
+				return nil, fmt.Errorf("invalid document reference %q", doc.Ref)
+			}
+			data, err := os.ReadFile(fullPath)
+			if err != nil {
+				return nil, fmt.Errorf("read stored document failed: %w", err)
+			}
+			text, err := extract.Text(doc.Name, data)
+			if err != nil {
+				return nil, err
+			}
+			sb.WriteString(FormatFileBlock(doc.Name, "text", text))
+		}
+		out[i].Content = sb.String() + out[i].Content
+		out[i].Documents = nil
+	}
+
+	return out, nil
+}
@@ -0,0 +1,63 @@
+package messages
+
+import (
+	"os"
+	"path/filepath"
+	"picochat/paths"
+	"strings"
+	"testing"
+)
+
+func TestDocuments_StoreResolveInline(t *testing.T) {
+	t.Cleanup(paths.OverrideHistoryPath(t.TempDir()))
+
+	pdf := "%PDF-1.4\n1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n" +
+		"2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 >>\nendobj\n" +
+		"3 0 obj\n<< /Type /Page /Contents 4 0 R >>\nendobj\n" +
+		"4 0 obj\n<< /Length 24 >>\nstream\nBT (Quarterly data) Tj ET\nendstream\nendobj\n%%EOF\n"
+	src := filepath.Join(t.TempDir(), "report.pdf")
+	if err := os.WriteFile(src, []byte(pdf), 0644); err != nil {
+		t.Fatalf("write pdf failed: %v", err)
+	}
+
+	h := NewHistory("system", 10)
+	if err := h.AddUserAttachments("summarize", nil, []string{src}); err != nil {
+		t.Fatalf("AddUser failed: %v", err)
+	}
+	docs := h.GetLast().Documents
+	if len(docs) != 1 || docs[0].Name != "report.pdf" || !IsImageRef(docs[0].Ref) || !strings.HasSuffix(docs[0].Ref, ".pdf") {
+		t.Fatalf("unexpected documents: %+v", docs)
+	}
+
+	resolved, err := ResolveDocuments(h.Get())
+	if err != nil {
+		t.Fatalf("ResolveDocuments failed: %v", err)
+	}
+	if got := resolved[1].Documents[0].Ref; !strings.HasPrefix(got, "data:application/pdf;base64,") {
+		t.Fatalf("resolved ref = %q", got)
+	}
+	if !IsImageRef(h.GetLast().Documents[0].Ref) {
+		t.Fatal("ResolveDocuments modified the history")
+	}
+
+	inlined, err := InlineDocuments(h.Get())
+	if err != nil {
+		t.Fatalf("InlineDocuments failed: %v", err)
+	}
+	want := "File: `report.pdf`\n```text\n--- Page 1 ---\nQuarterly data\n```\n\nsummarize"
+	if inlined[1].Content != want || inlined[1].Documents != nil {
+		t.Fatalf("inlined message = %+v", inlined[1])
+	}
+}
+
+func TestAddUser_MissingDocument(t *testing.T) {
+	t.Cleanup(paths.OverrideHistoryPath(t.TempDir()))
+
+	h := NewHistory("system", 10)
+	if err := h.AddUserAttachments("hello", nil, []string{"/does/not/exist.pdf"}); err == nil {
+		t.Fatal("expected error for missing document")
+	}
+	if h.Len() != 1 {
+		t.Fatalf("history length = %d, want 1", h.Len())
+	}
+}
@@ -0,0 +1,355 @@
+package messages
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"picochat/extract"
+	"picochat/paths"
+	"picochat/utils"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MaxFileSize     = 1 << 20  // per attached text file in bytes
+	MaxDocumentSize = 32 << 20 // per attached document (PDF, DOCX, ...) in bytes
+	MaxAttachTokens = 16000    // estimated tokens of all attached files
+	binarySniffLen  = 8000
+)
+
+var langByExt = map[string]string{
+	".go":    "go",
+	".py":    "python",
+	".js":    "javascript",
+	".mjs":   "javascript",
+	".ts":    "typescript",
+	".tsx":   "tsx",
+	".jsx":   "jsx",
+	".java":  "java",
+	".kt":    "kotlin",
+	".c":     "c",
+	".h":     "c",
+	".cpp":   "cpp",
+	".hpp":   "cpp",
+	".cs":    "csharp",
+	".rs":    "rust",
+	".rb":    "ruby",
+	".php":   "php",
+	".swift": "swift",
+	".sh":    "bash",
+	".bash":  "bash",
+	".ps1":   "powershell",
+	".bat":   "batch",
+	".sql":   "sql",
+	".html":  "html",
+	".css":   "css",
+	".xml":   "xml",
+	".json":  "json",
+	".yaml":  "yaml",
+	".yml":   "yaml",
+	".toml":  "toml",
+	".ini":   "ini",
+	".md":    "markdown",
+	".lua":   "lua",
+	".r":     "r",
+	".txt":   "text",
+}
+
+var langByName = map[string]string{
+	"makefile":   "makefile",
+	"dockerfile": "dockerfile",
+	"go.mod":     "go",
+}
+
+// FileBundle holds the attached files rendered as fenced blocks.
+type FileBundle struct {
+	Text      string   // the rendered blocks
+	Files     []string // the included files
+	Documents []string // included files to send as native file input
+	Skipped   []string // reasons for skipped files
+	Tokens    float64  // estimated tokens of Text
+}
+
+// BuildFileBundle reads files, directories and glob patterns and renders
+// each text file as fenced block labelled with its path and language.
+// Text of documents (PDF, DOCX, ODT, HTML, EPUB) is extracted first. With
+// nativePDF, PDF files are not extracted but listed in Documents instead.
+// Binary files, files larger than MaxFileSize, files ignored by .gitignore
+// and files exceeding the token limit are skipped.
+//
+// Parameters:
+//
+//	patterns ([]string) - file paths, directories or glob patterns
+//	tokenLimit (float64) - max. estimated tokens of the bundle
+//	nativePDF (bool)     - true if the backend accepts PDF file input
+//
+// Returns:
+//
+//	FileBundle - the rendered files
+//	error      - error if a pattern matches nothing
+func BuildFileBundle(patterns []string, tokenLimit float64, nativePDF bool) (FileBundle, error) {
+	var bundle FileBundle
+
+	files, err := expandFilePatterns(patterns)
+	if err != nil {
+		return bundle, err
+	}
+
+	var sb strings.Builder
+	for _, file := range files {
+		isDocument := extract.IsDocument(file)
+		sizeLimit := MaxFileSize
+		if isDocument {
+			sizeLimit = MaxDocumentSize
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			bundle.Skipped = append(bundle.Skipped, fmt.Sprintf("skipped %s (read failed)", file))
+			continue
+		}
+		if len(data) > sizeLimit {
+			bundle.Skipped = append(bundle.Skipped, fmt.Sprintf("skipped %s (larger than %d KiB)", file, sizeLimit/1024))
+			continue
+		}
+
+		if nativePDF && IsNativeDocument(file) {
+			bundle.Files = append(bundle.Files, file)
+			bundle.Documents = append(bundle.Documents, file)
+			continue
+		}
+
+		content, lang := string(data), DetectLanguage(file)
+		if isDocument {
+			content, err = extract.Text(file, data)
+			if err != nil {
+				bundle.Skipped = append(bundle.Skipped, fmt.Sprintf("skipped %s (%v)", file, err))
+				continue
+			}
+			lang = "text"
+		} else if isBinary(data) {
+			bundle.Skipped = append(bundle.Skipped, fmt.Sprintf("skipped %s (binary file)", file))
+			continue
+		}
+
+		block := FormatFileBlock(file, lang, content)
+		tokens := CalculateTokens(block)
+		if bundle.Tokens+tokens > tokenLimit {
+			bundle.Skipped = append(bundle.Skipped, fmt.Sprintf("skipped %s (exceeds token limit of %.0f)", file, tokenLimit))
+			continue
+		}
+
+		sb.WriteString(block)
+		bundle.Tokens += tokens
+		bundle.Files = append(bundle.Files, file)
+	}
+
+	bundle.Text = sb.String()
+	return bundle, nil
+}
+
+// AttachFiles prepends the rendered file bundle to a prompt.
+//
+// Parameters:
+//
+//	prompt (string) - the user prompt
+//	bundle (FileBundle) - the rendered files
+//
+// Returns:
+//
+//	string - the prompt with attached files
+func AttachFiles(prompt string, bundle FileBundle) string {
+	if bundle.Text == "" {
+		return prompt
+	}
+	return bundle.Text + prompt
+}
+
+// FormatFileBlock renders a file as a fenced block with a path label. The
+// fence is extended if the content already contains backtick fences.
+//
+// Parameters:
+//
+//	path (string)    - the file path used as label
+//	lang (string)    - the fence language (may be empty)
+//	content (string) - the file content
+//
+// Returns:
+//
+//	string - the labelled block followed by a blank line
+func FormatFileBlock(path, lang, content string) string {
+	return fmt.Sprintf("File: `%s`\n%s\n", filepath.ToSlash(path), fenceBlock(lang, content))
+}
+
+// FormatCommandBlock renders the output of a shell command as a fenced
+// block labelled with the command line and its exit code.
+//
+// Parameters:
+//
+//	command (string) - the command line
+//	output (string)  - combined stdout and stderr
+//	exitCode (int)   - the exit code of the command
+//
+// Returns:
+//
+//	string - the labelled block followed by a blank line
+func FormatCommandBlock(command, output string, exitCode int) string {
+	return fmt.Sprintf("Command: `%s` (exit code %d)\n%s\n", command, exitCode, fenceBlock("console", output))
+}
+
+// fenceBlock encloses content in a code fence that is longer than any
+// backtick fence inside the content.
+//
+// Parameters:
+//
+//	lang (string)    - the fence language (may be empty)
+//	content (string) - the block content
+//
+// Returns:
+//
+//	string - the fenced block ending with a newline
+func fenceBlock(lang, content string) string {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	content = strings.TrimRight(content, "\r\n")
+	return fmt.Sprintf("%s%s\n%s\n%s\n", fence, lang, content, fence)
+}
+
+// DetectLanguage returns the code fence language for a file path.
+//
+// Parameters:
+//
+//	path (string) - the file path
+//
+// Returns:
+//
+//	string - the language name or empty string if unknown
+func DetectLanguage(path string) string {
+	base := strings.ToLower(filepath.Base(path))
+	if lang, ok := langByName[base]; ok {
+		return lang
+	}
+	return langByExt[filepath.Ext(base)]
+}
+
+// expandFilePatterns resolves paths, glob patterns and directories into a
+// list of unique files. Directories are walked recursively with
+// respect to .gitignore files; .git directories are always skipped.
+//
+// Parameters:
+//
+//	patterns ([]string) - file paths, directories or glob patterns
+//
+// Returns:
+//
+//	[]string - the resolved files
+//	error    - error if a pattern matches nothing
+func expandFilePatterns(patterns []string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		expanded, err := paths.ExpandHomeDir(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		matches, err := filepath.Glob(expanded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", pattern)
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, fmt.Errorf("stat file failed: %w", err)
+			}
+			if !info.IsDir() {
+				files = append(files, filepath.Clean(match))
+				continue
+			}
+			dirFiles, err := walkDir(match)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, dirFiles...)
+		}
+	}
+
+	// keep the given order, but attach each file only once
+	seen := make(map[string]bool, len(files))
+	return slices.DeleteFunc(files, func(f string) bool {
+		if seen[f] {
+			return true
+		}
+		seen[f] = true
+		return false
+	}), nil
+}
+
+// walkDir lists all regular files below a directory that are not ignored.
+//
+// Parameters:
+//
+//	root (string) - the directory to walk
+//
+// Returns:
+//
+//	[]string - the files in lexical order
+//	error    - error if any
+func walkDir(root string) ([]string, error) {
+	var (
+		files  []string
+		ignore utils.GitIgnore
+	)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (d.Name() == ".git" || ignore.Match(path, true)) {
+				return filepath.SkipDir
+			}
+			return ignore.AddDir(path)
+		}
+		if d.Type().IsRegular() && !ignore.Match(path, false) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read directory %q failed: %w", root, err)
+	}
+	return files, nil
+}
+
+// isBinary reports whether data looks like a binary file.
+//
+// Parameters:
+//
+//	data ([]byte) - the file content
+//
+// Returns:
+//
+//	bool - true if the data contains NUL bytes or invalid UTF-8
+func isBinary(data []byte) bool {
+	sniff := data[:min(len(data), binarySniffLen)]
+	if bytes.IndexByte(sniff, 0) >= 0 {
+		return true
+	}
+	if len(sniff) < len(data) {
+		// drop a multi-byte rune cut at the end of the sniffed range
+		for i := 0; i < utf8.UTFMax && len(sniff) > 0 && !utf8.RuneStart(data[len(sniff)]); i++ {
+			sniff = sniff[:len(sniff)-1]
+		}
+	}
+	return !utf8.Valid(sniff)
+}
@@ -0,0 +1,211 @@
+package messages
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir failed: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write file failed: %v", err)
+		}
+	}
+}
+
+func TestBuildFileBundle_Directory(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		".gitignore":     "*.log\nvendor/\n",
+		"main.go":        "package main\n",
+		"debug.log":      "ignored",
+		"vendor/lib.go":  "package lib",
+		".git/config":    "[core]",
+		"sub/notes.md":   "# Notes",
+		"sub/image.bin":  "PNG\x00\x01",
+		"sub/latin1.txt": "caf\xe9",
+	})
+
+	bundle, err := BuildFileBundle([]string{dir}, MaxAttachTokens, false)
+	if err != nil {
+		t.Fatalf("BuildFileBundle failed: %v", err)
+	}
+
+	want := []string{
+		filepath.Join(dir, ".gitignore"),
+		filepath.Join(dir, "main.go"),
+		filepath.Join(dir, "sub", "notes.md"),
+	}
+	if strings.Join(bundle.Files, "|") != strings.Join(want, "|") {
+		t.Fatalf("Files = %v, want %v", bundle.Files, want)
+	}
+	if len(bundle.Skipped) != 2 {
+		t.Fatalf("Skipped = %v, want 2 binary files", bundle.Skipped)
+	}
+	if !strings.Contains(bundle.Text, "```go\npackage main\n```") {
+		t.Errorf("missing go block in %q", bundle.Text)
+	}
+	if !strings.Contains(bundle.Text, "```markdown\n# Notes\n```") {
+		t.Errorf("missing markdown block in %q", bundle.Text)
+	}
+	if bundle.Tokens <= 0 {
+		t.Errorf("Tokens = %v, want > 0", bundle.Tokens)
+	}
+}
+
+func TestBuildFileBundle_GlobAndDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"a.py": "print('a')",
+		"b.py": "print('b')",
+		"c.sh": "echo c",
+	})
+
+	bundle, err := BuildFileBundle([]string{filepath.Join(dir, "*.py"), filepath.Join(dir, "a.py")}, MaxAttachTokens, false)
+	if err != nil {
+		t.Fatalf("BuildFileBundle failed: %v", err)
+	}
+	if len(bundle.Files) != 2 {
+		t.Fatalf("Files = %v, want a.py and b.py once", bundle.Files)
+	}
+}
+
+func TestBuildFileBundle_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := BuildFileBundle([]string{filepath.Join(dir, "missing.txt")}, MaxAttachTokens, false); err == nil {
+		t.Error("expected error for missing file")
+	}
+	if _, err := BuildFileBundle([]string{filepath.Join(dir, "*.none")}, MaxAttachTokens, false); err == nil {
+		t.Error("expected error for glob without matches")
+	}
+}
+
+func TestBuildFileBundle_TokenLimit(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"small.txt": "one two",
+		"large.txt": strings.Repeat("word ", 100),
+	})
+
+	bundle, err := BuildFileBundle([]string{filepath.Join(dir, "small.txt"), filepath.Join(dir, "large.txt")}, 20, false)
+	if err != nil {
+		t.Fatalf("BuildFileBundle failed: %v", err)
+	}
+	if len(bundle.Files) != 1 || filepath.Base(bundle.Files[0]) != "small.txt" {
+		t.Fatalf("Files = %v, want only small.txt", bundle.Files)
+	}
+	if len(bundle.Skipped) != 1 || !strings.Contains(bundle.Skipped[0], "token limit") {
+		t.Fatalf("Skipped = %v, want token limit warning", bundle.Skipped)
+	}
+}
+
+func TestFormatFileBlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		lang    string
+		content string
+		want    string
+	}{
+		{"with language", "x/main.go", "go", "package main\n\n", "File: `x/main.go`\n```go\npackage main\n```\n\n"},
+		{"without language", "data.abc", "", "x", "File: `data.abc`\n```\nx\n```\n\n"},
+		{"nested fence", "README.md", "markdown", "```sh\nls\n```", "File: `README.md`\n````markdown\n```sh\nls\n```\n````\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatFileBlock(tt.path, tt.lang, tt.content); got != tt.want {
+				t.Errorf("FormatFileBlock() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatCommandBlock(t *testing.T) {
+	got := FormatCommandBlock("go test ./...", "FAIL\n", 1)
+	want := "Command: `go test ./...` (exit code 1)\n```console\nFAIL\n```\n\n"
+	if got != want {
+		t.Errorf("FormatCommandBlock() = %q, want %q", got, want)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"main.go", "go"},
+		{"docs/README.MD", "markdown"},
+		{"Dockerfile", "dockerfile"},
+		{"data.abc", ""},
+	}
+
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.path); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestBuildFileBundle_Documents(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"page.html":  "<html><body><h1>Report</h1><p>Numbers &amp; facts</p></body></html>",
+		"report.pdf": "%PDF-1.4 not really a pdf",
+		"broken.odt": "not a zip",
+	})
+	pdfPath := filepath.Join(dir, "report.pdf")
+
+	bundle, err := BuildFileBundle([]string{filepath.Join(dir, "page.html"), pdfPath, filepath.Join(dir, "broken.odt")}, MaxAttachTokens, true)
+	if err != nil {
+		t.Fatalf("BuildFileBundle failed: %v", err)
+	}
+	if !strings.Contains(bundle.Text, "```text\n# Report\n\nNumbers & facts\n```") {
+		t.Errorf("missing extracted html text in %q", bundle.Text)
+	}
+	if len(bundle.Documents) != 1 || bundle.Documents[0] != pdfPath {
+		t.Errorf("Documents = %v, want [%s]", bundle.Documents, pdfPath)
+	}
+	if len(bundle.Skipped) != 1 || !strings.Contains(bundle.Skipped[0], "broken.odt") {
+		t.Errorf("Skipped = %v, want broken.odt", bundle.Skipped)
+	}
+
+	// without native input the PDF has to be extracted and fails here
+	bundle, err = BuildFileBundle([]string{pdfPath}, MaxAttachTokens, false)
+	if err != nil {
+		t.Fatalf("BuildFileBundle failed: %v", err)
+	}
+	if len(bundle.Documents) != 0 || len(bundle.Skipped) != 1 {
+		t.Errorf("unexpected bundle: documents=%v skipped=%v", bundle.Documents, bundle.Skipped)
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"empty", nil, false},
+		{"text", []byte("hello"), false},
+		{"utf8", []byte("héllo 世界"), false},
+		{"nul byte", []byte("a\x00b"), true},
+		{"invalid utf8", []byte{0xff, 0xfe, 'a'}, true},
+		{"rune cut at sniff boundary", append([]byte(strings.Repeat("a", binarySniffLen-1)), "世"...), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBinary(tt.data); got != tt.want {
+				t.Errorf("isBinary() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
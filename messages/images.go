@@ -0,0 +1,358 @@
+package messages
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"mime"
+	"os"
+	"path/filepath"
+	"picochat/config"
+	"picochat/paths"
+	"picochat/utils"
+	"strings"
+)
+
+// ImageRefPrefix marks an image entry as reference to a file in the
+// image store instead of inline base64 data.
+const ImageRefPrefix = "sha256:"
+
+var imageExtByMime = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"image/bmp":  ".bmp",
+}
+
+// StoreImage copies an image file into the content-addressed image store
+// and returns a reference for the message history. The image is
+// preprocessed first (see processImage). Identical images are stored only
+// once.
+//
+// Parameters:
+//
+//	path (string) - path to the image file
+//
+// Returns:
+//
+//	string           - image reference in the form "sha256:<hash>.<ext>"
+//	utils.ImageStats - original and uploaded size
+//	error            - error if any
+func StoreImage(path string) (string, utils.ImageStats, error) {
+	fullPath, err := paths.ExpandHomeDir(path)
+	if err != nil {
+		return "", utils.ImageStats{}, err
+	}
+
+	mimeType, err := utils.GetMimeType(fullPath)
+	if err != nil {
+		return "", utils.ImageStats{}, err
+	}
+
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return "", utils.ImageStats{}, fmt.Errorf("read image failed: %w", err)
+	}
+
+	return processImage(data, mimeType)
+}
+
+// StoreImageData copies raw image data (e.g. from the clipboard) into the
+// image store.
+//
+// Parameters:
+//
+//	data ([]byte) - raw image data
+//
+// Returns:
+//
+//	string           - image reference in the form "sha256:<hash>.<ext>"
+//	utils.ImageStats - original and uploaded size
+//	error            - error if the data is not an image
+func StoreImageData(data []byte) (string, utils.ImageStats, error) {
+	mimeType := utils.DetectImageType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		return "", utils.ImageStats{}, fmt.Errorf("unsupported data type %q (only image/* allowed)", mimeType)
+	}
+	return processImage(data, mimeType)
+}
+
+// processImage downscales, re-encodes and EXIF-orients an image according
+// to the [Images] settings and stores the result.
+//
+// Parameters:
+//
+//	data ([]byte)     - raw image data
+//	mimeType (string) - MIME type of the original data
+//
+// Returns:
+//
+//	string           - image reference
+//	utils.ImageStats - original and uploaded size
+//	error            - error if any
+func processImage(data []byte, mimeType string) (string, utils.ImageStats, error) {
+	var opts utils.ImageOptions
+	if cfg, _, err := config.Get(); err == nil {
+		opts = utils.ImageOptions{MaxDimension: cfg.Images.MaxDimension, Quality: cfg.Images.Quality, WebPQuality: cfg.Images.WebPQuality}
+	}
+
+	out, outType, stats, err := utils.ProcessImage(data, opts)
+	if err != nil {
+		return "", stats, fmt.Errorf("process image failed: %w", err)
+	}
+	if stats.Changed {
+		mimeType = outType
+	}
+
+	ref, err := storeImageData(out, mimeType)
+	return ref, stats, err
+}
+
+// prepareImage turns an image entry into the form stored in the history.
+// Files are copied into the image store, URLs and existing store
+// references are kept.
+//
+// Parameters:
+//
+//	image (string) - image file path, store reference or URL
+//
+// Returns:
+//
+//	string - the history entry
+//	error  - error if the file cannot be stored or the reference is missing
+func prepareImage(image string) (string, error) {
+	if IsImageURL(image) {
+		return image, nil
+	}
+
+	fullPath, ok, err := imageRefPath(image)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		if !paths.FileExists(fullPath) {
+			return "", fmt.Errorf("stored image %q not found", image)
+		}
+		return image, nil
+	}
+	ref, _, err := StoreImage(image)
+	return ref, err
+}
+
+// storeImageData writes raw image data into the image store unless a file
+// with the same hash already exists.
+//
+// Parameters:
+//
+//	data ([]byte)     - raw image data
+//	mimeType (string) - MIME type of the image
+//
+// Returns:
+//
+//	string - image reference
+//	error  - error if any
+func storeImageData(data []byte, mimeType string) (string, error) {
+	imageDir, err := paths.GetImagePath()
+	if err != nil {
+		return "", fmt.Errorf("image path not found: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:]) + imageExtension(mimeType)
+	fullPath := filepath.Join(imageDir, name)
+
+	if !paths.FileExists(fullPath) {
+		if err := os.WriteFile(fullPath, data, 0644); err != nil {
+			return "", fmt.Errorf("write image %q failed: %w", name, err)
+		}
+	}
+
+	return ImageRefPrefix + name, nil
+}
+
+// ResolveImage turns an image reference into a data URL. URLs and legacy
+// inline payloads (data URL or plain base64) are returned unchanged.
+//
+// Parameters:
+//
+//	img (string) - image entry of a message
+//
+// Returns:
+//
+//	string - data URL or original payload
+//	error  - error if the referenced file cannot be read
+func ResolveImage(img string) (string, error) {
+	fullPath, ok, err := imageRefPath(img)
+	if err != nil || !ok {
+		return img, err
+	}
+
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("read stored image failed: %w", err)
+	}
+
+	mimeType := imageMimeType(filepath.Ext(fullPath))
+	return fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(data)), nil
+}
+
+// ResolveImages returns a copy of the messages with all image references
+// replaced by data URLs. The input slice is not modified.
+//
+// Parameters:
+//
+//	in ([]Message) - messages as stored in the history
+//
+// Returns:
+//
+//	[]Message - messages with inline image payloads
+//	error     - error if any image cannot be loaded
+func ResolveImages(in []Message) ([]Message, error) {
+	out := make([]Message, len(in))
+	copy(out, in)
+
+	for i := range out {
+		if len(out[i].Images) == 0 {
+			continue
+		}
+
+		imgs := make([]string, len(out[i].Images))
+		for j, img := range out[i].Images {
+			resolved, err := ResolveImage(img)
+			if err != nil {
+				return nil, err
+			}
+			imgs[j] = resolved
+		}
+		out[i].Images = imgs
+	}
+
+	return out, nil
+}
+
+// IsImageURL checks if an image entry is a remote http(s) URL. URLs are
+// passed to the backend unchanged.
+//
+// Parameters:
+//
+//	img (string) - image entry of a message
+//
+// Returns:
+//
+//	bool - true if the entry is a URL
+func IsImageURL(img string) bool {
+	lower := strings.ToLower(img)
+	return strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://")
+}
+
+// IsImageRef checks if an image entry is a reference to the image store.
+//
+// Parameters:
+//
+//	img (string) - image entry of a message
+//
+// Returns:
+//
+//	bool - true if the entry is a reference
+func IsImageRef(img string) bool {
+	return strings.HasPrefix(img, ImageRefPrefix)
+}
+
+// imageRefPath resolves the file path of an image reference.
+//
+// Parameters:
+//
+//	img (string) - image entry of a message
+//
+// Returns:
+//
+//	string - full path of the referenced file
+//	bool   - true if the entry is a reference
+//	error  - error if the reference is invalid
+func imageRefPath(img string) (string, bool, error) {
+	name, ok := strings.CutPrefix(img, ImageRefPrefix)
+	if !ok {
+		return "", false, nil
+	}
+	if name == "" || name != filepath.Base(name) {
+		return "", true, fmt.Errorf("invalid image reference %q", img)
+	}
+
+	imageDir, err := paths.GetImagePath()
+	if err != nil {
+		return "", true, fmt.Errorf("image path not found: %w", err)
+	}
+	return filepath.Join(imageDir, name), true, nil
+}
+
+// imageBase64Len returns the length of the base64 payload of an image
+// entry without loading referenced files.
+//
+// Parameters:
+//
+//	img (string) - image entry of a message
+//
+// Returns:
+//
+//	int - length of the base64 data
+func imageBase64Len(img string) int {
+	if IsImageURL(img) {
+		return 0 // size unknown without download
+	}
+	fullPath, ok, err := imageRefPath(img)
+	if !ok {
+		return len(utils.StripDataURLPrefix(img))
+	}
+	if err != nil {
+		return 0
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return 0
+	}
+	return int(math.Ceil(float64(info.Size())/3)) * 4
+}
+
+// imageExtension maps an image MIME type to a file extension.
+//
+// Parameters:
+//
+//	mimeType (string) - MIME type of the image
+//
+// Returns:
+//
+//	string - file extension including the dot
+func imageExtension(mimeType string) string {
+	if ext, ok := imageExtByMime[mimeType]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ".img"
+}
+
+// imageMimeType maps a file extension back to an image MIME type.
+//
+// Parameters:
+//
+//	ext (string) - file extension including the dot
+//
+// Returns:
+//
+//	string - MIME type of the image
+func imageMimeType(ext string) string {
+	for mimeType, e := range imageExtByMime {
+		if e == ext {
+			return mimeType
+		}
+	}
+	if mimeType := mime.TypeByExtension(ext); mimeType != "" {
+		return mimeType
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,180 @@
+package messages
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"picochat/paths"
+	"strings"
+	"testing"
+)
+
+func writeTestImage(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return file
+}
+
+// encodeTestPNG returns a valid PNG image of the given size.
+func encodeTestPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestStoreImage_Deduplicates(t *testing.T) {
+	t.Cleanup(paths.OverrideHistoryPath(t.TempDir()))
+	srcDir := t.TempDir()
+
+	first := writeTestImage(t, srcDir, "a.jpg", []byte("same-bytes"))
+	second := writeTestImage(t, srcDir, "b.jpg", []byte("same-bytes"))
+
+	ref1, _, err := StoreImage(first)
+	if err != nil {
+		t.Fatalf("StoreImage returned error: %v", err)
+	}
+	ref2, _, err := StoreImage(second)
+	if err != nil {
+		t.Fatalf("StoreImage returned error: %v", err)
+	}
+
+	if ref1 != ref2 {
+		t.Fatalf("expected identical refs, got %q and %q", ref1, ref2)
+	}
+	if !IsImageRef(ref1) || !strings.HasSuffix(ref1, ".jpg") {
+		t.Fatalf("unexpected ref format %q", ref1)
+	}
+
+	imageDir, err := paths.GetImagePath()
+	if err != nil {
+		t.Fatalf("GetImagePath returned error: %v", err)
+	}
+	entries, err := os.ReadDir(imageDir)
+	if err != nil {
+		t.Fatalf("read image dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 stored image, got %d", len(entries))
+	}
+}
+
+func TestResolveImage(t *testing.T) {
+	t.Cleanup(paths.OverrideHistoryPath(t.TempDir()))
+	file := writeTestImage(t, t.TempDir(), "img.png", []byte("ABC"))
+
+	ref, _, err := StoreImage(file)
+	if err != nil {
+		t.Fatalf("StoreImage returned error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{name: "stored reference", in: ref, want: "data:image/png;base64,QUJD"},
+		{name: "legacy data url unchanged", in: "data:image/jpeg;base64,QUJD", want: "data:image/jpeg;base64,QUJD"},
+		{name: "legacy plain base64 unchanged", in: "QUJD", want: "QUJD"},
+		{name: "missing file", in: ImageRefPrefix + "missing.png", wantErr: true},
+		{name: "path traversal", in: ImageRefPrefix + "../x.png", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveImage(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("ResolveImage(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveImages_DoesNotModifyInput(t *testing.T) {
+	t.Cleanup(paths.OverrideHistoryPath(t.TempDir()))
+	file := writeTestImage(t, t.TempDir(), "img.png", []byte("ABC"))
+
+	h := NewHistory("sys", 5)
+	if err := h.AddUser("look", file); err != nil {
+		t.Fatalf("AddUser returned error: %v", err)
+	}
+	ref := h.GetLast().Images[0]
+
+	out, err := ResolveImages(h.Get())
+	if err != nil {
+		t.Fatalf("ResolveImages returned error: %v", err)
+	}
+	if out[1].Images[0] != "data:image/png;base64,QUJD" {
+		t.Fatalf("unexpected resolved image %q", out[1].Images[0])
+	}
+	if h.GetLast().Images[0] != ref {
+		t.Fatalf("history image modified: %q", h.GetLast().Images[0])
+	}
+}
+
+func TestEstimateTokens_ImageRef(t *testing.T) {
+	t.Cleanup(paths.OverrideHistoryPath(t.TempDir()))
+	file := writeTestImage(t, t.TempDir(), "img.png", []byte("ABCDEF"))
+
+	withRef := NewHistory("", 5)
+	if err := withRef.AddUser("", file); err != nil {
+		t.Fatalf("AddUser returned error: %v", err)
+	}
+	inline := NewHistory("", 5)
+	inline.Messages = append(inline.Messages, Message{Role: RoleUser, Images: []string{"data:image/png;base64,QUJDREVG"}})
+
+	if got, want := withRef.EstimateTokens(), inline.EstimateTokens(); got != want {
+		t.Fatalf("EstimateTokens() = %v, want %v", got, want)
+	}
+}
+
+func TestPrepareImage(t *testing.T) {
+	t.Cleanup(paths.OverrideHistoryPath(t.TempDir()))
+
+	ref, _, err := StoreImageData(encodeTestPNG(t, 2, 2))
+	if err != nil {
+		t.Fatalf("StoreImageData returned error: %v", err)
+	}
+	if !IsImageRef(ref) || !strings.HasSuffix(ref, ".png") {
+		t.Fatalf("unexpected ref format %q", ref)
+	}
+	if _, _, err := StoreImageData([]byte("plain text")); err == nil {
+		t.Fatal("expected error for non-image data")
+	}
+
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"https://example.com/cat.png", "https://example.com/cat.png", false},
+		{ref, ref, false},
+		{"sha256:" + strings.Repeat("0", 64) + ".png", "", true},
+	}
+	for _, tt := range tests {
+		got, err := prepareImage(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("prepareImage(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Fatalf("prepareImage(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
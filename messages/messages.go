@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"picochat/config"
 	"picochat/console"
-	"picochat/utils"
+	"slices"
 )
 
 const (
@@ -14,16 +14,21 @@ const (
 )
 
 type Message struct {
-	Role      string   `json:"role"`
-	Content   string   `json:"content"`
-	Images    []string `json:"images,omitempty"` ////IMAGES
-	Reasoning string   `json:"-"`
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Images    []string   `json:"images,omitempty"` ////IMAGES
+	Documents []Document `json:"documents,omitempty"`
+	Reasoning string     `json:"-"`
 }
 
 type ChatHistory struct {
 	Messages          []Message
 	MaxContext        int
 	MaxContextReached bool
+	Persona           string
+
+	undo []historySnapshot
+	redo []historySnapshot
 }
 
 // NewHistory creates a new ChatHistory with a system prompt and maximum context size.
@@ -48,40 +53,62 @@ func NewHistory(systemPrompt string, maxContext int) *ChatHistory {
 //
 // Parameters:
 //
-//	role (string)      - Role of the stored message (System, User, Assistant)
-//	reasoning (string) - Reasoning body (if separate from message)
-//	content (string)   - Message body
-//	image (string)     - Image as base64 encoded string
+//	role (string)        - Role of the stored message (System, User, Assistant)
+//	reasoning (string)   - Reasoning body (if separate from message)
+//	content (string)     - Message body
+//	images ([]string)    - Image file paths, store references or URLs
+//	documents ([]string) - Paths to documents sent as native file input
 //
 // Returns:
 //
 //	error
-func (h *ChatHistory) add(role, reasoning, content, image string) error {
-	switch role {
-	case RoleSystem, RoleUser, RoleAssistant:
-		if role != RoleAssistant {
-			// safety net - just in case
-			reasoning = ""
+func (h *ChatHistory) add(role, reasoning, content string, images, documents []string) error {
+	if err := validateRole(role); err != nil {
+		return err
+	}
+	if role != RoleAssistant {
+		// safety net - just in case
+		reasoning = ""
+	}
+
+	////IMAGES
+	var refs []string
+	for _, image := range images {
+		ref, err := prepareImage(image)
+		if err != nil {
+			return fmt.Errorf("store image failed: %w", err)
 		}
+		refs = append(refs, ref)
+	}
 
-		////IMAGES
-		var images []string
-		if image != "" {
-			mime, err := utils.GetMimeType(image)
-			if err != nil {
-				return err
-			}
-			b64, err := utils.ImageToBase64(image)
-			if err != nil {
-				return fmt.Errorf("convert image to base64 failed: %w", err)
-			}
-			imageData := fmt.Sprintf("data:%s;base64,%s", mime, b64)
-			images = append(images, imageData)
+	var docs []Document
+	for _, path := range documents {
+		doc, err := StoreDocument(path)
+		if err != nil {
+			return fmt.Errorf("store document failed: %w", err)
 		}
+		docs = append(docs, doc)
+	}
 
-		h.Messages = append(h.Messages, Message{Role: role, Reasoning: reasoning, Content: content, Images: images})
-		h.compress()
+	h.Messages = append(h.Messages, Message{Role: role, Reasoning: reasoning, Content: content, Images: refs, Documents: docs})
+	h.redo = nil // a new message invalidates reverted states
+	h.compress()
 
+	return nil
+}
+
+// validateRole checks if the role is one of the supported message roles.
+//
+// Parameters:
+//
+//	role (string) - Role of the message (System, User, Assistant)
+//
+// Returns:
+//
+//	error - error if the role is invalid
+func validateRole(role string) error {
+	switch role {
+	case RoleSystem, RoleUser, RoleAssistant:
 		return nil
 	default:
 		return fmt.Errorf("invalid role %q", role)
@@ -89,11 +116,31 @@ func (h *ChatHistory) add(role, reasoning, content, image string) error {
 }
 
 func (h *ChatHistory) AddUser(content, image string) error {
-	return h.add(RoleUser, "", content, image)
+	var images []string
+	if image != "" {
+		images = []string{image}
+	}
+	return h.add(RoleUser, "", content, images, nil)
+}
+
+// AddUserAttachments appends a user message with several images and
+// native documents.
+//
+// Parameters:
+//
+//	content (string)     - Message body
+//	images ([]string)    - Image file paths, store references or URLs
+//	documents ([]string) - Paths to documents sent as native file input
+//
+// Returns:
+//
+//	error
+func (h *ChatHistory) AddUserAttachments(content string, images, documents []string) error {
+	return h.add(RoleUser, "", content, images, documents)
 }
 
 func (h *ChatHistory) AddAssistant(reasoning, content string) error {
-	return h.add(RoleAssistant, reasoning, content, "")
+	return h.add(RoleAssistant, reasoning, content, nil, nil)
 }
 
 // Discard removes the last assistant message from the history if present.
@@ -135,6 +182,77 @@ func (h *ChatHistory) Trim(index int) bool {
 	return true
 }
 
+// Delete removes the message with the given index. The system prompt at
+// index 0 cannot be removed.
+//
+// Parameters:
+//
+//	index (int) - index of the message to remove
+//
+// Returns:
+//
+//	error - error if the index is invalid
+func (h *ChatHistory) Delete(index int) error {
+	if index == 0 {
+		return fmt.Errorf("system prompt cannot be deleted")
+	}
+	if _, err := h.GetByIndex(index); err != nil {
+		return err
+	}
+
+	h.Messages = slices.Delete(h.Messages, index, index+1)
+	h.MaxContextReached = h.Len() >= h.MaxContext
+	return nil
+}
+
+// Insert adds a hand-written user or assistant message at the given index.
+// An index equal to the history length appends the message. The system
+// prompt always stays at index 0.
+//
+// Parameters:
+//
+//	index (int)      - target index of the new message (1..Len)
+//	role (string)    - Role of the message (User, Assistant)
+//	content (string) - Message body
+//
+// Returns:
+//
+//	error - error if role or index is invalid
+func (h *ChatHistory) Insert(index int, role, content string) error {
+	if err := validateRole(role); err != nil {
+		return err
+	}
+	if role == RoleSystem {
+		return fmt.Errorf("only one system prompt allowed")
+	}
+	if index < 1 || index > h.Len() {
+		return fmt.Errorf("index %d out of bounds", index)
+	}
+
+	h.Messages = slices.Insert(h.Messages, index, Message{Role: role, Content: content})
+	h.compress()
+	return nil
+}
+
+// SetContent replaces the content of the message with the given index.
+//
+// Parameters:
+//
+//	index (int)      - index of the message to change
+//	content (string) - new message body
+//
+// Returns:
+//
+//	error - error if the index is invalid
+func (h *ChatHistory) SetContent(index int, content string) error {
+	if _, err := h.GetByIndex(index); err != nil {
+		return err
+	}
+
+	h.Messages[index].Content = content
+	return nil
+}
+
 // Get returns the slice of messages in the history.
 //
 // Parameters:
@@ -368,9 +486,11 @@ func (h *ChatHistory) EstimateTokens() float64 {
 		text := msg.Reasoning + msg.Content
 		total += CalculateTokens(text)
 
-		if len(msg.Images) > 0 && msg.Images[0] != "" {
-			b64 := utils.StripDataURLPrefix(msg.Images[0])
-			total += CalculateBase64Tokens(b64)
+		for _, img := range msg.Images {
+			if img == "" {
+				continue
+			}
+			total += calculateBase64LenTokens(imageBase64Len(img))
 		}
 	}
 
@@ -24,8 +24,8 @@ func TestNewHistory(t *testing.T) {
 func TestAddAndClear(t *testing.T) {
 	h := NewHistory("init", 5)
 
-	h.add(RoleUser, "", "Hello!", "")
-	h.add(RoleAssistant, "", "Hi there!", "")
+	h.add(RoleUser, "", "Hello!", nil, nil)
+	h.add(RoleAssistant, "", "Hi there!", nil, nil)
 
 	if h.Len() != 3 {
 		t.Errorf("expected 3 messages, got %d", h.Len())
@@ -54,8 +54,8 @@ func TestDiscard(t *testing.T) {
 
 	t.Run("last message is assistant - remove last", func(t *testing.T) {
 		h := NewHistory("sys", 5)
-		_ = h.add(RoleUser, "", "hello", "")
-		_ = h.add(RoleAssistant, "", "hi", "")
+		_ = h.add(RoleUser, "", "hello", nil, nil)
+		_ = h.add(RoleAssistant, "", "hi", nil, nil)
 
 		h.Discard()
 
@@ -70,7 +70,7 @@ func TestDiscard(t *testing.T) {
 
 	t.Run("last message is not assistant - no change", func(t *testing.T) {
 		h := NewHistory("sys", 5)
-		_ = h.add(RoleUser, "", "hello", "")
+		_ = h.add(RoleUser, "", "hello", nil, nil)
 
 		h.Discard()
 
@@ -89,7 +89,7 @@ func TestChatHistory_add_InvalidRole(t *testing.T) {
 
 	initialLen := h.Len()
 
-	err := h.add("alien", "", "we come in peace", "")
+	err := h.add("alien", "", "we come in peace", nil, nil)
 
 	if err == nil {
 		t.Fatalf("expected error for invalid role, got nil")
@@ -109,7 +109,7 @@ func TestCompressKeepsLimitAndPrompt(t *testing.T) {
 	h := NewHistory("system prompt", 5)
 
 	for i := 1; i <= 10; i++ {
-		h.add(RoleUser, "", fmt.Sprintf("msg %d", i), "")
+		h.add(RoleUser, "", fmt.Sprintf("msg %d", i), nil, nil)
 	}
 
 	if h.Len() != 5 {
@@ -136,7 +136,7 @@ func TestSetContextSize(t *testing.T) {
 
 	// add 9 messages to fill context
 	for i := 1; i < 10; i++ {
-		h.add(RoleUser, "", fmt.Sprintf("msg %d", i), "")
+		h.add(RoleUser, "", fmt.Sprintf("msg %d", i), nil, nil)
 	}
 
 	// Invalid values
@@ -194,8 +194,8 @@ func TestAddAndCompress(t *testing.T) {
 
 	// add 4 User/Assistant messages → +1 System = 5
 	for i := 1; i <= 4; i++ {
-		h.add(RoleUser, "", "Hello", "")
-		h.add(RoleAssistant, "", "Hi there", "")
+		h.add(RoleUser, "", "Hello", nil, nil)
+		h.add(RoleAssistant, "", "Hi there", nil, nil)
 	}
 
 	if h.Len() != 5 {
@@ -207,7 +207,7 @@ func TestAddAndCompress(t *testing.T) {
 	}
 
 	// Now add another message - this should trigger Compress()
-	h.add(RoleUser, "", "Overflow message", "")
+	h.add(RoleUser, "", "Overflow message", nil, nil)
 
 	if h.Len() != 5 {
 		t.Errorf("After compress, expected length 5, got %d", h.Len())
@@ -222,7 +222,7 @@ func TestAddAndCompress(t *testing.T) {
 
 func TestReplaceMessages(t *testing.T) {
 	h := NewHistory("init", 5)
-	h.add(RoleUser, "", "first", "")
+	h.add(RoleUser, "", "first", nil, nil)
 
 	newMessages := []Message{
 		{Role: RoleSystem, Content: "replaced system"},
@@ -241,8 +241,8 @@ func TestReplaceMessages(t *testing.T) {
 
 func TestEstimateTokens(t *testing.T) {
 	h := NewHistory("short prompt", 5)
-	h.add(RoleUser, "", "This is a short message", "")
-	h.add(RoleAssistant, "", "This is a slightly longer reply that includes a few more words.", "")
+	h.add(RoleUser, "", "This is a short message", nil, nil)
+	h.add(RoleAssistant, "", "This is a slightly longer reply that includes a few more words.", nil, nil)
 
 	tokens := h.EstimateTokens()
 	if tokens <= 0 {
@@ -287,7 +287,7 @@ func TestAddAssistant(t *testing.T) {
 
 func TestGetByIndex(t *testing.T) {
 	h := NewHistory("sys", 5)
-	_ = h.add(RoleUser, "", "hello", "")
+	_ = h.add(RoleUser, "", "hello", nil, nil)
 
 	t.Run("valid index", func(t *testing.T) {
 		msg, err := h.GetByIndex(1)
@@ -328,7 +328,7 @@ func TestGetLastMessage(t *testing.T) {
 		t.Errorf("expected last message to be system at init, got %s", last.Role)
 	}
 
-	h.add(RoleUser, "", "msg", "")
+	h.add(RoleUser, "", "msg", nil, nil)
 	last = h.GetLast()
 	if last.Role != RoleUser || last.Content != "msg" {
 		t.Errorf("unexpected last message: %+v", last)
@@ -337,9 +337,9 @@ func TestGetLastMessage(t *testing.T) {
 
 func TestGetLastRole(t *testing.T) {
 	h := NewHistory("init", 5)
-	h.add(RoleUser, "", "hello", "")
-	h.add(RoleAssistant, "", "hi", "")
-	h.add(RoleUser, "", "bye", "")
+	h.add(RoleUser, "", "hello", nil, nil)
+	h.add(RoleAssistant, "", "hi", nil, nil)
+	h.add(RoleUser, "", "bye", nil, nil)
 
 	t.Run("find last user", func(t *testing.T) {
 		msg, ok := h.GetLastRole(RoleUser)
@@ -382,7 +382,7 @@ func TestIsEmpty(t *testing.T) {
 	if !h.IsEmpty() {
 		t.Errorf("expected history to be empty (only system prompt)")
 	}
-	h.add(RoleUser, "", "hi", "")
+	h.add(RoleUser, "", "hi", nil, nil)
 	if h.IsEmpty() {
 		t.Errorf("expected history to be non-empty after user message")
 	}
@@ -396,7 +396,7 @@ func TestEstimateTokens_IncludesReasoning(t *testing.T) {
 
 	// Reasoning-only message (empty content) should raise number of tokens
 	// because EstimateTokens counts Reasoning + Content.
-	if err := h.add(RoleAssistant, "This is hidden reasoning text", "", ""); err != nil {
+	if err := h.add(RoleAssistant, "This is hidden reasoning text", "", nil, nil); err != nil {
 		t.Fatalf("add failed: %v", err)
 	}
 
@@ -409,9 +409,9 @@ func TestEstimateTokens_IncludesReasoning(t *testing.T) {
 func TestTrim(t *testing.T) {
 	newHistory := func() *ChatHistory {
 		h := NewHistory("sys", 10)
-		_ = h.add(RoleUser, "", "u1", "")
-		_ = h.add(RoleAssistant, "", "a1", "")
-		_ = h.add(RoleUser, "", "u2", "")
+		_ = h.add(RoleUser, "", "u1", nil, nil)
+		_ = h.add(RoleAssistant, "", "a1", nil, nil)
+		_ = h.add(RoleUser, "", "u2", nil, nil)
 		return h
 	}
 
@@ -464,3 +464,85 @@ func TestTrim(t *testing.T) {
 		}
 	})
 }
+
+func TestDelete(t *testing.T) {
+	h := NewHistory("sys", 10)
+	_ = h.AddUser("hello", "")
+	_ = h.AddAssistant("", "hi")
+
+	if err := h.Delete(0); err == nil {
+		t.Fatal("expected error when deleting system prompt")
+	}
+	if err := h.Delete(5); err == nil {
+		t.Fatal("expected error for out of bounds index")
+	}
+	if err := h.Delete(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.Len() != 2 || h.GetLast().Content != "hi" {
+		t.Fatalf("unexpected history after delete: %+v", h.Get())
+	}
+	if h.UndoSteps() != 0 {
+		t.Fatalf("expected no undo step (recorded by the command), got %d", h.UndoSteps())
+	}
+}
+
+func TestInsert(t *testing.T) {
+	tests := []struct {
+		name    string
+		index   int
+		role    string
+		wantErr bool
+	}{
+		{"append user", 3, RoleUser, false},
+		{"insert assistant after system", 1, RoleAssistant, false},
+		{"system role rejected", 1, RoleSystem, true},
+		{"invalid role rejected", 1, "alien", true},
+		{"index 0 rejected", 0, RoleUser, true},
+		{"index beyond end rejected", 4, RoleUser, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHistory("sys", 10)
+			_ = h.AddUser("hello", "")
+			_ = h.AddAssistant("", "hi")
+
+			err := h.Insert(tt.index, tt.role, "injected")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if h.Len() != 3 {
+					t.Fatalf("expected unchanged history, got %d messages", h.Len())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			msg, _ := h.GetByIndex(tt.index)
+			if msg.Role != tt.role || msg.Content != "injected" {
+				t.Fatalf("unexpected message at %d: %+v", tt.index, msg)
+			}
+			if h.Get()[0].Role != RoleSystem {
+				t.Fatal("expected system prompt to stay at index 0")
+			}
+		})
+	}
+}
+
+func TestSetContent(t *testing.T) {
+	h := NewHistory("sys", 10)
+	_ = h.AddUser("hello", "")
+
+	if err := h.SetContent(1, "changed"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.GetLast().Content != "changed" {
+		t.Fatalf("expected content %q, got %q", "changed", h.GetLast().Content)
+	}
+	if err := h.SetContent(7, "x"); err == nil {
+		t.Fatal("expected error for out of bounds index")
+	}
+}
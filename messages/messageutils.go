@@ -1,6 +1,7 @@
 package messages
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -10,6 +11,11 @@ import (
 	"time"
 )
 
+type sessionFile struct {
+	Persona  string    `json:"persona,omitempty"`
+	Messages []Message `json:"messages"`
+}
+
 // LoadHistoryFromFile reads a chat history from a file and returns
 // a ChatHistory instance.
 //
@@ -38,12 +44,18 @@ func LoadHistoryFromFile(fileName string) (*ChatHistory, error) {
 		return nil, fmt.Errorf("read file %s failed: %w", fullPath, err)
 	}
 
-	var messages []Message
-	if err := json.Unmarshal(data, &messages); err != nil {
+	var session sessionFile
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		// legacy format: plain message array
+		err = json.Unmarshal(data, &session.Messages)
+	} else {
+		err = json.Unmarshal(data, &session)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("parse json in file %s failed: %w", fileName, err)
 	}
 
-	return &ChatHistory{Messages: messages}, nil
+	return &ChatHistory{Messages: session.Messages, Persona: session.Persona}, nil
 }
 
 // SaveHistoryToFile writes the chat history to a file in the history
@@ -51,15 +63,15 @@ func LoadHistoryFromFile(fileName string) (*ChatHistory, error) {
 //
 // Parameters:
 //
-//	fileName (string)    - optional fileName (or timestamp if omitted)
-//	messages ([]Message) - the complete current chat history messages
-//	overwrite (bool)     - allow replacing an existing target file
+//	fileName (string)       - optional fileName (or timestamp if omitted)
+//	history (*ChatHistory)  - the current chat history (messages and persona)
+//	overwrite (bool)        - allow replacing an existing target file
 //
 // Returns:
 //
 //	string - the actual fileName
 //	error  - error if any
-func SaveHistoryToFile(fileName string, messages []Message, overwrite bool) (string, error) {
+func SaveHistoryToFile(fileName string, history *ChatHistory, overwrite bool) (string, error) {
 	if strings.HasPrefix(fileName, "#") {
 		return "", fmt.Errorf("filename must not start with '#'")
 	}
@@ -83,7 +95,8 @@ func SaveHistoryToFile(fileName string, messages []Message, overwrite bool) (str
 		return "", fmt.Errorf("filename already exists")
 	}
 
-	data, err := json.MarshalIndent(messages, "", "  ")
+	session := sessionFile{Persona: history.Persona, Messages: history.Get()}
+	data, err := json.MarshalIndent(session, "", "  ")
 	if err != nil {
 		return "", fmt.Errorf("marshal messages failed: %w", err)
 	}
@@ -119,5 +132,19 @@ func CalculateTokens(s string) float64 {
 //
 //	float64 - the estimated token count
 func CalculateBase64Tokens(base64 string) float64 {
-	return (float64(len(base64)) / 3.3 * 1.1)
+	return calculateBase64LenTokens(len(base64))
+}
+
+// calculateBase64LenTokens estimates the number of tokens for base64 data
+// of the given length.
+//
+// Parameters:
+//
+//	n (int) - length of the base64 data
+//
+// Returns:
+//
+//	float64 - the estimated token count
+func calculateBase64LenTokens(n int) float64 {
+	return (float64(n) / 3.3 * 1.1)
 }
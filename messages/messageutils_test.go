@@ -2,6 +2,8 @@ package messages
 
 import (
 	"math"
+	"os"
+	"path/filepath"
 	"picochat/paths"
 	"strings"
 	"testing"
@@ -9,7 +11,7 @@ import (
 
 func TestSaveAndLoad(t *testing.T) {
 	h := NewHistory("persist me", 5)
-	if err := h.add(RoleUser, "", "data", ""); err != nil {
+	if err := h.add(RoleUser, "", "data", nil, nil); err != nil {
 		t.Fatalf("add failed: %v", err)
 	}
 
@@ -17,7 +19,7 @@ func TestSaveAndLoad(t *testing.T) {
 	restore := paths.OverrideHistoryPath(tmpDir)
 	t.Cleanup(restore)
 
-	filename, err := SaveHistoryToFile("", h.Get(), false)
+	filename, err := SaveHistoryToFile("", h, false)
 	if err != nil {
 		t.Fatalf("save failed: %v", err)
 	}
@@ -37,7 +39,7 @@ func TestSaveAndLoad(t *testing.T) {
 
 func TestSaveAndLoad_DropsReasoning(t *testing.T) {
 	h := NewHistory("persist me", 5)
-	if err := h.add(RoleAssistant, "internal reasoning that must not be persisted", "visible content", ""); err != nil {
+	if err := h.add(RoleAssistant, "internal reasoning that must not be persisted", "visible content", nil, nil); err != nil {
 		t.Fatalf("add failed: %v", err)
 	}
 
@@ -45,7 +47,7 @@ func TestSaveAndLoad_DropsReasoning(t *testing.T) {
 	restore := paths.OverrideHistoryPath(tmpDir)
 	t.Cleanup(restore)
 
-	filename, err := SaveHistoryToFile("", h.Get(), false)
+	filename, err := SaveHistoryToFile("", h, false)
 	if err != nil {
 		t.Fatalf("save failed: %v", err)
 	}
@@ -73,14 +75,14 @@ func TestSaveHistoryToFile_RejectsInvalidFilename(t *testing.T) {
 	restore := paths.OverrideHistoryPath(tmpDir)
 	t.Cleanup(restore)
 
-	if _, err := SaveHistoryToFile("#12", h.Get(), false); err == nil {
+	if _, err := SaveHistoryToFile("#12", h, false); err == nil {
 		t.Fatal("expected error for filename starting with '#', got nil")
 	}
 }
 
 func TestSaveHistoryToFile_OverwriteHandling(t *testing.T) {
 	h := NewHistory("persist me", 5)
-	if err := h.add(RoleUser, "", "first", ""); err != nil {
+	if err := h.add(RoleUser, "", "first", nil, nil); err != nil {
 		t.Fatalf("add failed: %v", err)
 	}
 
@@ -89,15 +91,15 @@ func TestSaveHistoryToFile_OverwriteHandling(t *testing.T) {
 	t.Cleanup(restore)
 
 	name := "overwrite-test"
-	if _, err := SaveHistoryToFile(name, h.Get(), false); err != nil {
+	if _, err := SaveHistoryToFile(name, h, false); err != nil {
 		t.Fatalf("initial save failed: %v", err)
 	}
 
-	if _, err := SaveHistoryToFile(name, h.Get(), false); err == nil {
+	if _, err := SaveHistoryToFile(name, h, false); err == nil {
 		t.Fatal("expected error when overwriting without permission, got nil")
 	}
 
-	if _, err := SaveHistoryToFile(name, h.Get(), true); err != nil {
+	if _, err := SaveHistoryToFile(name, h, true); err != nil {
 		t.Fatalf("overwrite save failed: %v", err)
 	}
 }
@@ -123,3 +125,44 @@ func TestCalculateTokens(t *testing.T) {
 		t.Fatalf("expected %.1f, got %.1f", want, got)
 	}
 }
+
+func TestSaveAndLoad_Persona(t *testing.T) {
+	t.Cleanup(paths.OverrideHistoryPath(t.TempDir()))
+
+	h := NewHistory("persist me", 5)
+	h.Persona = "dev"
+
+	filename, err := SaveHistoryToFile("", h, false)
+	if err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	loaded, err := LoadHistoryFromFile(filename)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if loaded.Persona != "dev" {
+		t.Fatalf("expected persona %q, got %q", "dev", loaded.Persona)
+	}
+	if loaded.Len() != 1 || loaded.Messages[0].Content != "persist me" {
+		t.Fatalf("unexpected loaded messages: %+v", loaded.Messages)
+	}
+}
+
+func TestLoadHistoryFromFile_LegacyArray(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Cleanup(paths.OverrideHistoryPath(tmpDir))
+
+	legacy := `[{"role":"system","content":"sys"},{"role":"user","content":"hi"}]`
+	if err := os.WriteFile(filepath.Join(tmpDir, "legacy.chat"), []byte(legacy), 0644); err != nil {
+		t.Fatalf("write legacy file: %v", err)
+	}
+
+	loaded, err := LoadHistoryFromFile("legacy")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if loaded.Len() != 2 || loaded.Persona != "" {
+		t.Fatalf("unexpected legacy history: %+v", loaded)
+	}
+}
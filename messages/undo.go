@@ -0,0 +1,171 @@
+package messages
+
+import "slices"
+
+// MaxUndoSteps limits the number of snapshots kept for /undo.
+const MaxUndoSteps = 20
+
+type historySnapshot struct {
+	messages          []Message
+	maxContext        int
+	maxContextReached bool
+	persona           string
+}
+
+// Checkpoint records the current history state on the undo stack. It must
+// be called before a destructive operation. The redo stack is discarded.
+// The methods of ChatHistory do not record checkpoints themselves, so each
+// command records exactly one undo step.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	none
+func (h *ChatHistory) Checkpoint() {
+	h.undo = pushSnapshot(h.undo, h.snapshot())
+	h.redo = nil
+}
+
+// RecordChange runs a history change and records the previous state on the
+// undo stack if the change succeeds.
+//
+// Parameters:
+//
+//	change (func() error) - the operation that changes the history
+//
+// Returns:
+//
+//	error - the error of the change, nothing is recorded in that case
+func (h *ChatHistory) RecordChange(change func() error) error {
+	prev := h.snapshot()
+	if err := change(); err != nil {
+		return err
+	}
+	h.undo = pushSnapshot(h.undo, prev)
+	h.redo = nil
+	return nil
+}
+
+// Undo restores the state before the last recorded operation.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	bool - true if a state was restored, false if the undo stack is empty
+func (h *ChatHistory) Undo() bool {
+	if len(h.undo) == 0 {
+		return false
+	}
+
+	prev := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = pushSnapshot(h.redo, h.snapshot())
+	h.restore(prev)
+	return true
+}
+
+// Redo reapplies the last operation reverted by Undo.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	bool - true if a state was restored, false if the redo stack is empty
+func (h *ChatHistory) Redo() bool {
+	if len(h.redo) == 0 {
+		return false
+	}
+
+	next := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = pushSnapshot(h.undo, h.snapshot())
+	h.restore(next)
+	return true
+}
+
+// UndoSteps returns the number of available undo steps.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	int
+func (h *ChatHistory) UndoSteps() int {
+	return len(h.undo)
+}
+
+// RedoSteps returns the number of available redo steps.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	int
+func (h *ChatHistory) RedoSteps() int {
+	return len(h.redo)
+}
+
+// snapshot copies the current history state.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	historySnapshot - copy of messages, context size and state and persona
+func (h *ChatHistory) snapshot() historySnapshot {
+	return historySnapshot{
+		messages:          slices.Clone(h.Messages),
+		maxContext:        h.MaxContext,
+		maxContextReached: h.MaxContextReached,
+		persona:           h.Persona,
+	}
+}
+
+// restore replaces the history state with a snapshot.
+//
+// Parameters:
+//
+//	s (historySnapshot) - the state to restore
+//
+// Returns:
+//
+//	none
+func (h *ChatHistory) restore(s historySnapshot) {
+	h.Messages = slices.Clone(s.messages)
+	h.MaxContext = s.maxContext
+	h.MaxContextReached = s.maxContextReached
+	h.Persona = s.persona
+}
+
+// pushSnapshot appends a snapshot to a stack and drops the oldest entry
+// if the stack exceeds MaxUndoSteps.
+//
+// Parameters:
+//
+//	stack ([]historySnapshot) - the undo or redo stack
+//	s (historySnapshot)       - the snapshot to push
+//
+// Returns:
+//
+//	[]historySnapshot - the updated stack
+func pushSnapshot(stack []historySnapshot, s historySnapshot) []historySnapshot {
+	stack = append(stack, s)
+	if len(stack) > MaxUndoSteps {
+		stack = slices.Delete(stack, 0, len(stack)-MaxUndoSteps)
+	}
+	return stack
+}
@@ -0,0 +1,113 @@
+package messages
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestUndoRedo(t *testing.T) {
+	h := NewHistory("sys", 10)
+	_ = h.AddUser("hello", "")
+	_ = h.AddAssistant("", "hi")
+
+	h.Checkpoint()
+	h.ClearExceptSystemPrompt()
+
+	if h.UndoSteps() != 1 {
+		t.Fatalf("expected 1 undo step, got %d", h.UndoSteps())
+	}
+	if !h.Undo() {
+		t.Fatal("expected undo to succeed")
+	}
+	if h.Len() != 3 {
+		t.Fatalf("expected 3 messages after undo, got %d", h.Len())
+	}
+	if h.RedoSteps() != 1 {
+		t.Fatalf("expected 1 redo step, got %d", h.RedoSteps())
+	}
+	if !h.Redo() {
+		t.Fatal("expected redo to succeed")
+	}
+	if !h.IsEmpty() {
+		t.Fatalf("expected cleared history after redo, got %d messages", h.Len())
+	}
+	if h.Redo() {
+		t.Fatal("expected redo on empty stack to fail")
+	}
+}
+
+func TestUndo_EmptyStack(t *testing.T) {
+	h := NewHistory("sys", 10)
+	if h.Undo() {
+		t.Fatal("expected undo on empty stack to fail")
+	}
+}
+
+func TestUndo_SnapshotIsIndependent(t *testing.T) {
+	h := NewHistory("sys", 10)
+	_ = h.AddUser("hello", "")
+
+	h.Checkpoint()
+	h.Messages[1].Content = "changed"
+
+	h.Undo()
+	if h.GetLast().Content != "hello" {
+		t.Fatalf("expected snapshot content %q, got %q", "hello", h.GetLast().Content)
+	}
+}
+
+func TestRecordChange(t *testing.T) {
+	h := NewHistory("sys", 10)
+	_ = h.AddUser("hello", "")
+	_ = h.AddAssistant("", "hi")
+	_ = h.AddUser("again", "")
+	_ = h.AddAssistant("", "hi again")
+
+	if err := h.RecordChange(func() error { return h.Delete(0) }); err == nil {
+		t.Fatal("expected error when deleting system prompt")
+	}
+	if h.UndoSteps() != 0 {
+		t.Fatalf("expected no undo step for a failed change, got %d", h.UndoSteps())
+	}
+
+	if err := h.RecordChange(func() error { return h.SetContextSize(3) }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.UndoSteps() != 1 || h.MaxCtx() != 3 || h.Len() != 3 {
+		t.Fatalf("unexpected state: steps=%d context=%d len=%d", h.UndoSteps(), h.MaxCtx(), h.Len())
+	}
+
+	h.Undo()
+	if h.MaxCtx() != 10 || h.Len() != 5 {
+		t.Fatalf("expected undo to restore context 10 and 5 messages, got %d and %d", h.MaxCtx(), h.Len())
+	}
+	h.Redo()
+	if h.MaxCtx() != 3 || h.Len() != 3 {
+		t.Fatalf("expected redo to restore context 3 and 3 messages, got %d and %d", h.MaxCtx(), h.Len())
+	}
+}
+
+func TestCheckpoint_Bounded(t *testing.T) {
+	h := NewHistory("sys", 100)
+	for i := range MaxUndoSteps + 5 {
+		_ = h.AddUser(fmt.Sprintf("msg %d", i), "")
+		h.Checkpoint()
+	}
+
+	if h.UndoSteps() != MaxUndoSteps {
+		t.Fatalf("expected %d undo steps, got %d", MaxUndoSteps, h.UndoSteps())
+	}
+}
+
+func TestAdd_ClearsRedo(t *testing.T) {
+	h := NewHistory("sys", 10)
+	_ = h.AddUser("hello", "")
+	h.Checkpoint()
+	h.ClearExceptSystemPrompt()
+	h.Undo()
+
+	_ = h.AddAssistant("", "hi")
+	if h.RedoSteps() != 0 {
+		t.Fatalf("expected redo stack to be cleared, got %d", h.RedoSteps())
+	}
+}
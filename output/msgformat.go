@@ -3,24 +3,26 @@ package output
 import (
 	"fmt"
 	"picochat/console"
+	"picochat/highlight"
 	"picochat/messages"
 	"strings"
 )
 
 // FormatMessage formats a single chat message with optional index header and
-// role-based color output.
+// role-based color output. With colors, fenced code is highlighted.
 //
 // Parameters:
 //
-//	msg (messages.Message) - the message to format
-//	index (int)            - the message index shown in the header
-//	header (bool)          - include role/index header if true
-//	color (bool)           - apply role-based colors or styles if true
+//	msg (messages.Message)   - the message to format
+//	index (int)              - the message index shown in the header
+//	header (bool)            - include role/index header if true
+//	color (bool)             - apply role-based colors or styles if true
+//	theme (*highlight.Theme) - colors of fenced code, nil disables highlighting
 //
 // Returns:
 //
 //	string - the formatted message text
-func FormatMessage(msg messages.Message, index int, header, color bool) string {
+func FormatMessage(msg messages.Message, index int, header, color bool, theme *highlight.Theme) string {
 	headerText := ""
 	if header {
 		headerText = fmt.Sprintf("(%d:%s)\n", index, msg.Role)
@@ -29,19 +31,24 @@ func FormatMessage(msg messages.Message, index int, header, color bool) string {
 		}
 	}
 
-	output := fmt.Sprintf("%s%s", headerText, msg.Content)
+	if !color {
+		return headerText + msg.Content
+	}
 
-	if color {
-		switch msg.Role {
-		case messages.RoleSystem:
-			output = console.Colorize(console.Magenta, output)
-		case messages.RoleUser:
-			output = console.Colorize(console.Cyan, output)
-		case messages.RoleAssistant:
-			// nothing to do here
-		}
+	roleColor := ""
+	switch msg.Role {
+	case messages.RoleSystem:
+		roleColor = console.Magenta
+	case messages.RoleUser:
+		roleColor = console.Cyan
+	case messages.RoleAssistant:
+		// nothing to do here
 	}
 
+	output := headerText + highlight.Markdown(msg.Content, theme, roleColor)
+	if roleColor != "" {
+		output = console.Colorize(roleColor, output)
+	}
 	return output
 }
 
@@ -49,20 +56,21 @@ func FormatMessage(msg messages.Message, index int, header, color bool) string {
 //
 // Parameters:
 //
-//	msgs ([]Message) - Array of struct containing the full message history
-//	color (bool)     - apply role-based colors or styles if true
+//	msgs ([]Message)         - Array of struct containing the full message history
+//	color (bool)             - apply role-based colors or styles if true
+//	theme (*highlight.Theme) - colors of fenced code, nil disables highlighting
 //
 // Returns:
 //
 //	string - the full conversation text (without reasoning)
-func FormatConversation(msgs []messages.Message, color bool) string {
+func FormatConversation(msgs []messages.Message, color bool, theme *highlight.Theme) string {
 	var builder strings.Builder
 
 	for index, msg := range msgs {
 		if index > 0 {
 			builder.WriteString("\n\n")
 		}
-		builder.WriteString(FormatMessage(msg, index, true, color))
+		builder.WriteString(FormatMessage(msg, index, true, color, theme))
 	}
 
 	return builder.String()
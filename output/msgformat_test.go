@@ -1,6 +1,8 @@
 package output
 
 import (
+	"picochat/console"
+	"picochat/highlight"
 	"picochat/messages"
 	"regexp"
 	"testing"
@@ -15,7 +17,7 @@ func stripANSI(s string) string {
 func TestFormatMessage_NoHeaderNoColor(t *testing.T) {
 	msg := messages.Message{Role: messages.RoleAssistant, Content: "hello world"}
 
-	got := FormatMessage(msg, 0, false, false)
+	got := FormatMessage(msg, 0, false, false, nil)
 	if got != "hello world" {
 		t.Fatalf("expected %q, got %q", "hello world", got)
 	}
@@ -24,7 +26,7 @@ func TestFormatMessage_NoHeaderNoColor(t *testing.T) {
 func TestFormatMessage_WithHeader(t *testing.T) {
 	msg := messages.Message{Role: messages.RoleUser, Content: "hello"}
 
-	got := stripANSI(FormatMessage(msg, 2, true, false))
+	got := stripANSI(FormatMessage(msg, 2, true, false, nil))
 	want := "(2:user)\nhello"
 	if got != want {
 		t.Fatalf("expected %q, got %q", want, got)
@@ -38,9 +40,23 @@ func TestFormatConversation(t *testing.T) {
 		{Role: messages.RoleAssistant, Content: "hey"},
 	}
 
-	got := stripANSI(FormatConversation(msgs, false))
+	got := stripANSI(FormatConversation(msgs, false, nil))
 	want := "(0:system)\nsys\n\n(1:user)\nhi\n\n(2:assistant)\nhey"
 	if got != want {
 		t.Fatalf("expected %q, got %q", want, got)
 	}
 }
+
+func TestFormatMessage_HighlightsCode(t *testing.T) {
+	msg := messages.Message{Role: messages.RoleUser, Content: "see\n```go\nreturn x\n```"}
+
+	got := FormatMessage(msg, 1, false, true, &highlight.Basic)
+	want := console.Cyan + "see\n```go\n" + highlight.Basic.Keyword + "return" + console.ColorReset + console.Cyan + " x\n```" + console.ColorReset
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	if got := FormatMessage(msg, 1, false, false, &highlight.Basic); got != msg.Content {
+		t.Fatalf("expected %q without colors, got %q", msg.Content, got)
+	}
+}
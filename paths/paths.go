@@ -13,7 +13,11 @@ const (
 	cfgDefaultSuffix = ".toml"
 )
 
-const HistorySuffix = ".chat"
+const (
+	HistorySuffix    = ".chat"
+	imageDirName     = "images"
+	inputHistoryName = "input_history.jsonl"
+)
 
 // GetConfigPath returns the path to the configuration file.
 //
@@ -149,6 +153,52 @@ func GetHistoryPath() (string, error) {
 	return historyDir, nil
 }
 
+// GetImagePath returns the path to the image store inside the history directory.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - the image directory path
+//	error - error if any
+func GetImagePath() (string, error) {
+	historyDir, err := GetHistoryPath()
+	if err != nil {
+		return "", err
+	}
+	imageDir := filepath.Join(historyDir, imageDirName)
+	err = os.MkdirAll(imageDir, 0755)
+	if err != nil {
+		return "", err
+	}
+	return imageDir, nil
+}
+
+// GetInputHistoryFile returns the path to the file with the prompts and
+// commands entered at the input prompt.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - the input history file path
+//	error - error if any
+func GetInputHistoryFile() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	err = os.MkdirAll(configDir, 0755)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, inputHistoryName), nil
+}
+
 // fallbackToXDGOrHome returns the XDG config directory or the home config directory.
 //
 // Parameters:
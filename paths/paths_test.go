@@ -136,6 +136,22 @@ func TestGetConfigPath_AliasAndDefaultUseConfigPathEnv(t *testing.T) {
 	}
 }
 
+func TestGetInputHistoryFile_UsesConfigDir(t *testing.T) {
+	cfgDir := filepath.Join(t.TempDir(), "picochat")
+	t.Setenv("CONFIG_PATH", cfgDir)
+
+	got, err := GetInputHistoryFile()
+	if err != nil {
+		t.Fatalf("GetInputHistoryFile failed: %v", err)
+	}
+	if want := filepath.Join(cfgDir, "input_history.jsonl"); got != want {
+		t.Fatalf("GetInputHistoryFile = %q; want %q", got, want)
+	}
+	if info, err := os.Stat(cfgDir); err != nil || !info.IsDir() {
+		t.Fatalf("config dir %s was not created", cfgDir)
+	}
+}
+
 func TestOverrideHistoryPath_Restore(t *testing.T) {
 	tmpDir := t.TempDir()
 	restore := OverrideHistoryPath(tmpDir)
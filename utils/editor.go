@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// EditorCommand returns the configured external editor as command and
+// arguments. $VISUAL takes precedence over $EDITOR.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	[]string - editor executable followed by its arguments
+func EditorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// EditText writes text to a temporary file, opens it in the external editor
+// and returns the saved result. The terminal must be in normal (cooked) mode.
+//
+// Parameters:
+//
+//	text (string) - the initial text
+//	suffix (string) - file suffix for editor syntax detection (e.g. ".md")
+//
+// Returns:
+//
+//	string - the edited text without trailing line breaks
+//	error  - error if any
+func EditText(text, suffix string) (string, error) {
+	f, err := os.CreateTemp("", "picochat-*"+suffix)
+	if err != nil {
+		return "", fmt.Errorf("create temp file failed: %w", err)
+	}
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return "", fmt.Errorf("write temp file failed: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("close temp file failed: %w", err)
+	}
+
+	editor := EditorCommand()
+	cmd := exec.Command(editor[0], append(editor[1:], tmpPath)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("run editor %q failed: %w", editor[0], err)
+	}
+
+	data, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("read temp file failed: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
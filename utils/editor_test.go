@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "code --wait")
+
+	got := EditorCommand()
+	if len(got) != 2 || got[0] != "code" || got[1] != "--wait" {
+		t.Fatalf("unexpected editor command %q", got)
+	}
+
+	t.Setenv("VISUAL", "nano")
+	if got := EditorCommand(); got[0] != "nano" {
+		t.Fatalf("expected VISUAL to take precedence, got %q", got)
+	}
+}
+
+func TestEditText(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sed")
+	}
+	t.Setenv("TMPDIR", t.TempDir()) // sed leaves a backup file
+	t.Setenv("VISUAL", "sed -i.bak s/hello/goodbye/")
+
+	got, err := EditText("hello world\n", ".txt")
+	if err != nil {
+		t.Fatalf("EditText returned error: %v", err)
+	}
+	if got != "goodbye world" {
+		t.Fatalf("EditText() = %q, want %q", got, "goodbye world")
+	}
+}
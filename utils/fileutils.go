@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"mime"
-	"net/http"
 	"os"
 	"path/filepath"
 	"picochat/paths"
@@ -209,7 +208,7 @@ func GetMimeType(path string) (string, error) {
 		return "", err
 	}
 
-	mt := http.DetectContentType(buf[:n])
+	mt := DetectImageType(buf[:n])
 	if strings.HasPrefix(mt, "image/") {
 		return mt, nil
 	}
@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type ignoreRule struct {
+	base     string // directory of the .gitignore file
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// GitIgnore collects .gitignore rules of a directory tree. Rules of
+// nested files only apply below their own directory.
+type GitIgnore struct {
+	rules []ignoreRule
+}
+
+// AddDir reads the .gitignore file of a directory, if there is one.
+//
+// Parameters:
+//
+//	dir (string) - the directory containing the .gitignore file
+//
+// Returns:
+//
+//	error - error if the file exists but cannot be read
+func (g *GitIgnore) AddDir(dir string) error {
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("open .gitignore failed: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		g.AddPattern(dir, scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// AddPattern adds a single .gitignore line relative to a base directory.
+// Empty lines and comments are ignored.
+//
+// Parameters:
+//
+//	base (string) - the directory the pattern is relative to
+//	line (string) - the .gitignore line
+//
+// Returns:
+//
+//	none
+func (g *GitIgnore) AddPattern(base, line string) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	rule := ignoreRule{base: filepath.Clean(base)}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	line = strings.TrimPrefix(line, `\`) // escaped leading '#' or '!'
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return
+	}
+	rule.pattern = line
+	g.rules = append(g.rules, rule)
+}
+
+// Match reports whether a path is ignored. The last matching rule wins,
+// so negated patterns can re-include entries.
+//
+// Parameters:
+//
+//	name (string) - the path to check
+//	isDir (bool)  - true if the path is a directory
+//
+// Returns:
+//
+//	bool - true if the path is ignored
+func (g *GitIgnore) Match(name string, isDir bool) bool {
+	ignored := false
+	name = filepath.Clean(name)
+	for _, rule := range g.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		rel, err := filepath.Rel(rule.base, name)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		var matched bool
+		if rule.anchored {
+			matched = matchGlobPath(rule.pattern, rel)
+		} else {
+			matched, _ = path.Match(rule.pattern, path.Base(rel))
+		}
+		if matched {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// matchGlobPath matches a slash separated path against a pattern in which
+// "**" stands for any number of directories.
+//
+// Parameters:
+//
+//	pattern (string) - the glob pattern
+//	name (string)    - the slash separated path
+//
+// Returns:
+//
+//	bool - true if the path matches
+func matchGlobPath(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchSegments is the recursive helper of matchGlobPath.
+//
+// Parameters:
+//
+//	pattern ([]string) - remaining pattern segments
+//	name ([]string)    - remaining path segments
+//
+// Returns:
+//
+//	bool - true if the segments match
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitIgnore_Match(t *testing.T) {
+	var g GitIgnore
+	for _, line := range []string{
+		"# comment",
+		"",
+		"*.log",
+		"!keep.log",
+		"build/",
+		"/root.txt",
+		"docs/**/*.tmp",
+	} {
+		g.AddPattern("repo", line)
+	}
+	g.AddPattern("repo/sub", "local.txt")
+
+	tests := []struct {
+		name  string
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"glob at any level", "repo/a/b/debug.log", false, true},
+		{"negation re-includes", "repo/keep.log", false, false},
+		{"directory rule matches dir", "repo/x/build", true, true},
+		{"directory rule ignores file", "repo/build", false, false},
+		{"anchored at base", "repo/root.txt", false, true},
+		{"anchored not nested", "repo/a/root.txt", false, false},
+		{"double star zero dirs", "repo/docs/a.tmp", false, true},
+		{"double star many dirs", "repo/docs/x/y/a.tmp", false, true},
+		{"nested rule applies below", "repo/sub/local.txt", false, true},
+		{"nested rule not above", "repo/local.txt", false, false},
+		{"outside base", "other/debug.log", false, false},
+		{"dot dot directory name", "repo/..cache/debug.log", false, true},
+		{"dot dot file name", "repo/..debug.log", false, true},
+		{"parent of base", "repo/..", false, false},
+		{"plain file", "repo/main.go", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.Match(tt.path, tt.isDir); got != tt.want {
+				t.Errorf("Match(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitIgnore_AddDir(t *testing.T) {
+	dir := t.TempDir()
+
+	var g GitIgnore
+	if err := g.AddDir(dir); err != nil {
+		t.Fatalf("missing .gitignore should not fail: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("secret.txt\r\n"), 0644); err != nil {
+		t.Fatalf("write .gitignore failed: %v", err)
+	}
+	if err := g.AddDir(dir); err != nil {
+		t.Fatalf("AddDir failed: %v", err)
+	}
+	if !g.Match(filepath.Join(dir, "secret.txt"), false) {
+		t.Error("expected secret.txt to be ignored")
+	}
+}
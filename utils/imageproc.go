@@ -0,0 +1,452 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"github.com/gen2brain/heic"
+	"golang.org/x/image/webp"
+)
+
+// ImageOptions controls the preprocessing of images before upload.
+type ImageOptions struct {
+	MaxDimension int // longest side in pixels, 0 keeps the size
+	Quality      int // JPEG quality 1..100
+	WebPQuality  int // JPEG quality for converted WebP images, 0 uses Quality
+}
+
+// ImageStats describes the result of an image preprocessing step.
+type ImageStats struct {
+	OrigSize   int
+	Size       int
+	OrigWidth  int
+	OrigHeight int
+	Width      int
+	Height     int
+	Changed    bool
+}
+
+// String returns a short summary, e.g. "12.1 MB -> 412.0 KB (4032x3024 -> 1568x1176)".
+func (s ImageStats) String() string {
+	if !s.Changed {
+		if s.Width == 0 {
+			return FormatBytes(s.Size)
+		}
+		return fmt.Sprintf("%s (%dx%d, unchanged)", FormatBytes(s.Size), s.Width, s.Height)
+	}
+	return fmt.Sprintf("%s -> %s (%dx%d -> %dx%d)", FormatBytes(s.OrigSize), FormatBytes(s.Size),
+		s.OrigWidth, s.OrigHeight, s.Width, s.Height)
+}
+
+// FormatBytes formats a byte count with a binary unit.
+//
+// Parameters:
+//
+//	n (int) - number of bytes
+//
+// Returns:
+//
+//	string - formatted size, e.g. "1.5 MB"
+func FormatBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// DetectImageType detects the MIME type of image data. In addition to the
+// types known to http.DetectContentType it recognizes HEIC images.
+//
+// Parameters:
+//
+//	data ([]byte) - image data, at least the first bytes
+//
+// Returns:
+//
+//	string - detected MIME type, e.g. "image/heic"
+func DetectImageType(data []byte) string {
+	if isHEIC(data) {
+		return "image/heic"
+	}
+	return http.DetectContentType(data)
+}
+
+// ProcessImage prepares an image for upload. Images are rotated according
+// to their EXIF orientation and downscaled to fit MaxDimension. JPEGs are
+// re-encoded with the given quality and kept unchanged if that does not
+// make them smaller; PNG and GIF become PNG. WebP and HEIC images are
+// converted to JPEG, or to PNG if they contain transparency. Images that
+// need no change and other formats are returned unchanged.
+//
+// Parameters:
+//
+//	data ([]byte)        - raw image data
+//	opts (ImageOptions)  - preprocessing options
+//
+// Returns:
+//
+//	[]byte     - the image data to upload
+//	string     - MIME type of the returned data
+//	ImageStats - original and resulting size and dimensions
+//	error      - error if the image cannot be decoded or encoded
+func ProcessImage(data []byte, opts ImageOptions) ([]byte, string, ImageStats, error) {
+	stats := ImageStats{OrigSize: len(data), Size: len(data)}
+
+	mimeType := DetectImageType(data)
+	convert := false
+	switch mimeType {
+	case "image/jpeg", "image/png", "image/gif":
+	case "image/webp", "image/heic":
+		convert = true
+	default:
+		return data, mimeType, stats, nil
+	}
+
+	conf, err := decodeImageConfig(data, mimeType)
+	if err != nil {
+		return nil, "", stats, fmt.Errorf("decode image failed: %w", err)
+	}
+	stats.OrigWidth, stats.OrigHeight = conf.Width, conf.Height
+	stats.Width, stats.Height = conf.Width, conf.Height
+
+	orientation := 1
+	if mimeType == "image/jpeg" {
+		orientation = exifOrientation(data)
+	}
+	w, h := conf.Width, conf.Height
+	if orientation >= 5 {
+		w, h = h, w
+	}
+	nw, nh := fitDimensions(w, h, opts.MaxDimension)
+	unchanged := orientation == 1 && nw == w && nh == h
+	if unchanged && !convert && mimeType != "image/jpeg" {
+		return data, mimeType, stats, nil
+	}
+
+	src, err := decodeImage(data, mimeType)
+	if err != nil {
+		return nil, "", stats, fmt.Errorf("decode image failed: %w", err)
+	}
+	img := orientImage(toNRGBA(src), orientation)
+	if nw != w || nh != h {
+		img = downscale(img, nw, nh)
+	}
+
+	quality := opts.Quality
+	if mimeType == "image/webp" && opts.WebPQuality > 0 {
+		quality = opts.WebPQuality
+	}
+	if quality < 1 || quality > 100 {
+		quality = jpeg.DefaultQuality
+	}
+
+	var buf bytes.Buffer
+	switch {
+	case mimeType == "image/jpeg", convert && img.Opaque():
+		mimeType = "image/jpeg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	default:
+		mimeType = "image/png"
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, "", stats, fmt.Errorf("encode image failed: %w", err)
+	}
+
+	// a JPEG that only gets re-encoded is kept if the quality setting does
+	// not make it smaller
+	if unchanged && !convert && buf.Len() >= len(data) {
+		return data, mimeType, stats, nil
+	}
+
+	stats.Size = buf.Len()
+	stats.Width, stats.Height = nw, nh
+	stats.Changed = true
+	return buf.Bytes(), mimeType, stats, nil
+}
+
+// decodeImageConfig reads the dimensions of an image.
+//
+// Parameters:
+//
+//	data ([]byte)     - raw image data
+//	mimeType (string) - MIME type detected by DetectImageType
+//
+// Returns:
+//
+//	image.Config - color model and dimensions
+//	error        - error if the header cannot be decoded
+func decodeImageConfig(data []byte, mimeType string) (image.Config, error) {
+	switch mimeType {
+	case "image/heic":
+		return heic.DecodeConfig(bytes.NewReader(data))
+	case "image/webp":
+		return webp.DecodeConfig(bytes.NewReader(data))
+	}
+	conf, _, err := image.DecodeConfig(bytes.NewReader(data))
+	return conf, err
+}
+
+// decodeImage decodes an image of one of the supported formats.
+//
+// Parameters:
+//
+//	data ([]byte)     - raw image data
+//	mimeType (string) - MIME type detected by DetectImageType
+//
+// Returns:
+//
+//	image.Image - the decoded image
+//	error       - error if the data cannot be decoded
+func decodeImage(data []byte, mimeType string) (image.Image, error) {
+	switch mimeType {
+	case "image/heic":
+		return heic.Decode(bytes.NewReader(data))
+	case "image/webp":
+		return webp.Decode(bytes.NewReader(data))
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// fitDimensions scales width and height so that the longest side is at
+// most limit while keeping the aspect ratio.
+//
+// Parameters:
+//
+//	w, h (int)  - width and height of the image
+//	limit (int) - maximum length of the longest side, 0 keeps the size
+//
+// Returns:
+//
+//	int - the new width
+//	int - the new height
+func fitDimensions(w, h, limit int) (int, int) {
+	if limit <= 0 || (w <= limit && h <= limit) {
+		return w, h
+	}
+	if w >= h {
+		return limit, max(1, (h*limit+w/2)/w)
+	}
+	return max(1, (w*limit+h/2)/h), limit
+}
+
+// isHEIC checks for an ISO base media file with a HEIF brand.
+//
+// Parameters:
+//
+//	data ([]byte) - image data, at least the first 12 bytes
+//
+// Returns:
+//
+//	bool - true for HEIC and HEIF images
+func isHEIC(data []byte) bool {
+	if len(data) < 12 || string(data[4:8]) != "ftyp" {
+		return false
+	}
+	switch string(data[8:12]) {
+	case "heic", "heix", "hevc", "hevx", "heim", "heis", "mif1", "msf1":
+		return true
+	}
+	return false
+}
+
+// exifOrientation reads the EXIF orientation tag (0x0112) of a JPEG.
+//
+// Parameters:
+//
+//	data ([]byte) - JPEG data
+//
+// Returns:
+//
+//	int - orientation 1..8, 1 if missing or invalid
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 1
+		}
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 { // start of scan, end of image
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + size
+		if size < 2 || end > len(data) {
+			return 1
+		}
+		if marker == 0xE1 {
+			if o := parseExifOrientation(data[pos+4 : end]); o != 0 {
+				return o
+			}
+		}
+		pos = end
+	}
+	return 1
+}
+
+// parseExifOrientation reads the orientation from an APP1 Exif segment.
+//
+// Parameters:
+//
+//	seg ([]byte) - content of the APP1 segment without marker and length
+//
+// Returns:
+//
+//	int - orientation 1..8, 0 if missing or invalid
+func parseExifOrientation(seg []byte) int {
+	if len(seg) < 14 || string(seg[:6]) != "Exif\x00\x00" {
+		return 0
+	}
+	tiff := seg[6:]
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			o := int(order.Uint16(tiff[entry+8:]))
+			if o < 1 || o > 8 {
+				return 0
+			}
+			return o
+		}
+	}
+	return 0
+}
+
+// toNRGBA converts an image into an NRGBA image with origin 0,0.
+//
+// Parameters:
+//
+//	src (image.Image) - the decoded image
+//
+// Returns:
+//
+//	*image.NRGBA - a copy with non-premultiplied colors
+func toNRGBA(src image.Image) *image.NRGBA {
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
+
+// orientImage applies an EXIF orientation so the image is upright.
+//
+// Parameters:
+//
+//	src (*image.NRGBA)  - the decoded image
+//	orientation (int)   - EXIF orientation 1..8
+//
+// Returns:
+//
+//	*image.NRGBA - the upright image
+func orientImage(src *image.NRGBA, orientation int) *image.NRGBA {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirror horizontal
+				sx, sy = w-1-x, y
+			case 3: // rotate 180
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirror vertical
+				sx, sy = x, h-1-y
+			case 5: // transpose
+				sx, sy = y, x
+			case 6: // rotate 90 clockwise
+				sx, sy = y, h-1-x
+			case 7: // transverse
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotate 90 counter-clockwise
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
+
+// downscale shrinks an image with an area-averaging box filter. Colors are
+// weighted by alpha so transparent pixels do not darken the edges.
+//
+// Parameters:
+//
+//	src (*image.NRGBA) - the source image
+//	nw, nh (int)       - target width and height (not larger than the source)
+//
+// Returns:
+//
+//	*image.NRGBA - the scaled image
+func downscale(src *image.NRGBA, nw, nh int) *image.NRGBA {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, nw, nh))
+
+	for y := 0; y < nh; y++ {
+		y0, y1 := y*h/nh, max((y+1)*h/nh, y*h/nh+1)
+		for x := 0; x < nw; x++ {
+			x0, x1 := x*w/nw, max((x+1)*w/nw, x*w/nw+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				off := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					pa := uint64(src.Pix[off+3])
+					r += uint64(src.Pix[off]) * pa
+					g += uint64(src.Pix[off+1]) * pa
+					b += uint64(src.Pix[off+2]) * pa
+					a += pa
+					n++
+					off += 4
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			if a > 0 {
+				dst.Pix[i] = uint8(r / a)
+				dst.Pix[i+1] = uint8(g / a)
+				dst.Pix[i+2] = uint8(b / a)
+			}
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
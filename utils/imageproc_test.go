@@ -0,0 +1,275 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withExifOrientation inserts an APP1 Exif segment with the given
+// orientation after the SOI marker of a JPEG.
+func withExifOrientation(data []byte, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	_ = binary.Write(&tiff, binary.BigEndian, uint16(42))
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(8))                // IFD0 offset
+	_ = binary.Write(&tiff, binary.BigEndian, uint16(1))                // entry count
+	_ = binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})      // tag, type SHORT
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(1))                // count
+	_ = binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0}) // value
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(0))                // next IFD
+
+	seg := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	var out bytes.Buffer
+	out.Write(data[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	_ = binary.Write(&out, binary.BigEndian, uint16(len(seg)+2))
+	out.Write(seg)
+	out.Write(data[2:])
+	return out.Bytes()
+}
+
+// testImage returns a w x h image with a red left half and blue right half.
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestProcessImage_Resize(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(400, 200), nil); err != nil {
+		t.Fatalf("encode jpeg: %v", err)
+	}
+
+	out, mimeType, stats, err := ProcessImage(buf.Bytes(), ImageOptions{MaxDimension: 100, Quality: 80})
+	if err != nil {
+		t.Fatalf("ProcessImage failed: %v", err)
+	}
+	if mimeType != "image/jpeg" || !stats.Changed {
+		t.Fatalf("unexpected result: mime=%q stats=%+v", mimeType, stats)
+	}
+	conf, err := jpeg.DecodeConfig(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if conf.Width != 100 || conf.Height != 50 || stats.Width != 100 || stats.OrigWidth != 400 {
+		t.Fatalf("got %dx%d, stats=%+v", conf.Width, conf.Height, stats)
+	}
+	if stats.Size != len(out) || !strings.Contains(stats.String(), "400x200 -> 100x50") {
+		t.Fatalf("unexpected stats %q", stats)
+	}
+}
+
+func TestProcessImage_Unchanged(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(10, 10)); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		opts ImageOptions
+	}{
+		{"small png", buf.Bytes(), ImageOptions{MaxDimension: 100}},
+		{"resize disabled", buf.Bytes(), ImageOptions{}},
+		{"bmp passthrough", []byte("BM\x00\x00\x00\x00\x00\x00\x00\x00"), ImageOptions{MaxDimension: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _, stats, err := ProcessImage(tt.data, tt.opts)
+			if err != nil {
+				t.Fatalf("ProcessImage failed: %v", err)
+			}
+			if stats.Changed || !bytes.Equal(out, tt.data) {
+				t.Fatalf("expected unchanged data, stats=%+v", stats)
+			}
+		})
+	}
+}
+
+func TestProcessImage_Quality(t *testing.T) {
+	src := testImage(200, 100)
+	for i := range src.Pix {
+		if i%4 != 3 {
+			src.Pix[i] ^= uint8(i * 7) // noise keeps the encoder busy
+		}
+	}
+	encode := func(quality int) []byte {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, src, &jpeg.Options{Quality: quality}); err != nil {
+			t.Fatalf("encode jpeg: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		quality int
+		changed bool
+	}{
+		{"high quality shrinks", encode(100), 60, true},
+		{"low quality kept", encode(40), 90, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, mimeType, stats, err := ProcessImage(tt.data, ImageOptions{MaxDimension: 1000, Quality: tt.quality})
+			if err != nil {
+				t.Fatalf("ProcessImage failed: %v", err)
+			}
+			if mimeType != "image/jpeg" || stats.Changed != tt.changed {
+				t.Fatalf("mime=%q stats=%+v, want changed=%v", mimeType, stats, tt.changed)
+			}
+			if !tt.changed && !bytes.Equal(out, tt.data) {
+				t.Fatal("expected the original data")
+			}
+			if tt.changed && (len(out) >= len(tt.data) || stats.Width != 200 || stats.Height != 100) {
+				t.Fatalf("got %d bytes from %d, stats=%+v", len(out), len(tt.data), stats)
+			}
+		})
+	}
+}
+
+func TestProcessImage_Convert(t *testing.T) {
+	tests := []struct {
+		file     string
+		opts     ImageOptions
+		wantMime string
+		wantW    int
+	}{
+		{"photo.webp", ImageOptions{Quality: 85, WebPQuality: 70}, "image/jpeg", 0},
+		{"photo.webp", ImageOptions{MaxDimension: 50}, "image/jpeg", 50},
+		{"alpha.webp", ImageOptions{MaxDimension: 64}, "image/png", 64},
+		{"photo.heic", ImageOptions{MaxDimension: 100, Quality: 80}, "image/jpeg", 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatalf("read %s: %v", tt.file, err)
+			}
+			out, mimeType, stats, err := ProcessImage(data, tt.opts)
+			if err != nil {
+				t.Fatalf("ProcessImage failed: %v", err)
+			}
+			if mimeType != tt.wantMime || !stats.Changed || stats.Size != len(out) {
+				t.Fatalf("mime=%q stats=%+v", mimeType, stats)
+			}
+			conf, format, err := image.DecodeConfig(bytes.NewReader(out))
+			if err != nil {
+				t.Fatalf("decode result: %v", err)
+			}
+			if "image/"+format != tt.wantMime {
+				t.Fatalf("result is %s, want %s", format, tt.wantMime)
+			}
+			wantW := tt.wantW
+			if wantW == 0 {
+				wantW = stats.OrigWidth
+			}
+			if conf.Width != wantW || stats.Width != wantW {
+				t.Fatalf("width %d (stats %d), want %d", conf.Width, stats.Width, wantW)
+			}
+		})
+	}
+}
+
+func TestDetectImageType(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{[]byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"), "image/heic"},
+		{[]byte("\x00\x00\x00\x18ftypmif1\x00\x00\x00\x00"), "image/heic"},
+		{[]byte("RIFF\x00\x00\x00\x00WEBPVP8 "), "image/webp"},
+		{[]byte("\x00\x00\x00\x18ftypisom\x00\x00\x00\x00"), "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		if got := DetectImageType(tt.data); got != tt.want {
+			t.Errorf("DetectImageType(%q) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestProcessImage_Orientation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(40, 20), &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatalf("encode jpeg: %v", err)
+	}
+	data := withExifOrientation(buf.Bytes(), 6)
+	if got := exifOrientation(data); got != 6 {
+		t.Fatalf("exifOrientation = %d, want 6", got)
+	}
+
+	out, _, stats, err := ProcessImage(data, ImageOptions{MaxDimension: 1000, Quality: 95})
+	if err != nil {
+		t.Fatalf("ProcessImage failed: %v", err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 20 || b.Dy() != 40 || !stats.Changed {
+		t.Fatalf("got %dx%d, want 20x40", b.Dx(), b.Dy())
+	}
+	// rotated clockwise: the red left half ends up on top
+	if r, _, bl, _ := img.At(10, 5).RGBA(); r < bl {
+		t.Fatalf("expected red at top, got r=%d b=%d", r, bl)
+	}
+	if r, _, bl, _ := img.At(10, 35).RGBA(); bl < r {
+		t.Fatalf("expected blue at bottom, got r=%d b=%d", r, bl)
+	}
+}
+
+func TestProcessImage_Errors(t *testing.T) {
+	heic := []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00")
+	if _, _, _, err := ProcessImage(heic, ImageOptions{}); err == nil {
+		t.Fatal("expected decode error for a truncated HEIC image")
+	}
+	if _, _, _, err := ProcessImage([]byte("\x89PNG\r\n\x1a\nbroken"), ImageOptions{}); err == nil {
+		t.Fatal("expected decode error")
+	}
+}
+
+func TestDownscale_Alpha(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{R: 200, A: 255})
+	src.SetNRGBA(1, 0, color.NRGBA{}) // transparent black
+
+	got := downscale(src, 1, 1).NRGBAAt(0, 0)
+	if got.R != 200 || got.A != 127 {
+		t.Fatalf("downscale = %+v, want R=200 A=127", got)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{512, "512 B"},
+		{2048, "2.0 KB"},
+		{12 << 20, "12.0 MB"},
+	}
+	for _, tt := range tests {
+		if got := FormatBytes(tt.n); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DevNull marks a missing side of a file patch (new or deleted file).
+const DevNull = "/dev/null"
+
+// Hunk is a single "@@" section of a unified diff.
+type Hunk struct {
+	OldStart int      // 1-based start line in the old file, 0 if unknown
+	Lines    []string // hunk lines with their ' ', '-' or '+' prefix
+	OldNoEOL bool     // the old file ends without a line break ("\ No newline at end of file")
+	NewNoEOL bool     // the new file ends without a line break
+}
+
+// FilePatch holds the hunks of one file of a unified diff.
+type FilePatch struct {
+	OldPath string
+	NewPath string
+	Hunks   []Hunk
+}
+
+// HunkError describes a hunk that cannot be applied.
+type HunkError struct {
+	Hunk   int // 1-based hunk number
+	Line   int // expected line in the old file
+	Reason string
+}
+
+func (e HunkError) Error() string {
+	return fmt.Sprintf("hunk %d (line %d): %s", e.Hunk, e.Line, e.Reason)
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+// Path returns the file the patch applies to.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	string - the new path, or the old path if the file is deleted
+func (p FilePatch) Path() string {
+	if p.NewPath == DevNull {
+		return p.OldPath
+	}
+	return p.NewPath
+}
+
+// IsNew reports whether the patch creates a file.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	bool - true if the old path is /dev/null
+func (p FilePatch) IsNew() bool { return p.OldPath == DevNull }
+
+// IsDelete reports whether the patch deletes a file.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	bool - true if the new path is /dev/null
+func (p FilePatch) IsDelete() bool { return p.NewPath == DevNull }
+
+// Stats returns the number of added and removed lines.
+//
+// Parameters:
+//
+//	none
+//
+// Returns:
+//
+//	int - the number of added lines
+//	int - the number of removed lines
+func (p FilePatch) Stats() (int, int) {
+	var added, removed int
+	for _, h := range p.Hunks {
+		for _, l := range h.Lines {
+			switch {
+			case strings.HasPrefix(l, "+"):
+				added++
+			case strings.HasPrefix(l, "-"):
+				removed++
+			}
+		}
+	}
+	return added, removed
+}
+
+// ParsePatch parses a unified diff with one or more files. Hunk headers
+// without line numbers ("@@") are accepted; such hunks are located by
+// their context only.
+//
+// Parameters:
+//
+//	text (string) - the unified diff
+//
+// Returns:
+//
+//	[]FilePatch - the parsed file patches
+//	error       - error if no file header or hunk is found
+func ParsePatch(text string) ([]FilePatch, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var patches []FilePatch
+	var cur *FilePatch
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			patches = append(patches, FilePatch{
+				OldPath: patchPath(line[4:], "a/"),
+				NewPath: patchPath(lines[i+1][4:], "b/"),
+			})
+			cur = &patches[len(patches)-1]
+			i++
+		case strings.HasPrefix(line, "@@"):
+			if cur == nil {
+				return nil, fmt.Errorf("hunk without file header at line %d", i+1)
+			}
+			h := Hunk{}
+			if m := hunkHeaderRe.FindStringSubmatch(line); m != nil {
+				h.OldStart, _ = strconv.Atoi(m[1])
+			}
+			for i+1 < len(lines) && isHunkLine(lines, i+1) {
+				i++
+				if strings.HasPrefix(lines[i], `\`) { // "\ No newline at end of file"
+					markNoEOL(&h)
+					continue
+				}
+				l := lines[i]
+				if l == "" {
+					l = " " // context line with stripped blank
+				}
+				h.Lines = append(h.Lines, l)
+			}
+			// trailing blank lines usually come from the end of the block
+			for len(h.Lines) > 0 && h.Lines[len(h.Lines)-1] == " " {
+				h.Lines = h.Lines[:len(h.Lines)-1]
+			}
+			cur.Hunks = append(cur.Hunks, h)
+		}
+	}
+
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("no file header (---/+++) found")
+	}
+	for _, p := range patches {
+		if len(p.Hunks) == 0 {
+			return nil, fmt.Errorf("no hunks found for %s", p.Path())
+		}
+	}
+	return patches, nil
+}
+
+// markNoEOL records a "\ No newline at end of file" marker for the side of
+// the preceding hunk line.
+//
+// Parameters:
+//
+//	h (*Hunk) - the hunk being parsed
+//
+// Returns:
+//
+//	none
+func markNoEOL(h *Hunk) {
+	if len(h.Lines) == 0 {
+		return
+	}
+	switch h.Lines[len(h.Lines)-1][0] {
+	case ' ':
+		h.OldNoEOL, h.NewNoEOL = true, true
+	case '-':
+		h.OldNoEOL = true
+	case '+':
+		h.NewNoEOL = true
+	}
+}
+
+// isHunkLine checks if line i still belongs to the current hunk.
+//
+// Parameters:
+//
+//	lines ([]string) - the lines of the diff
+//	i (int)          - index of the line to check
+//
+// Returns:
+//
+//	bool - false at the next hunk or file header or at other text
+func isHunkLine(lines []string, i int) bool {
+	l := lines[i]
+	if strings.HasPrefix(l, "@@") || strings.HasPrefix(l, "diff ") {
+		return false
+	}
+	if strings.HasPrefix(l, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ") {
+		return false
+	}
+	return l == "" || strings.ContainsAny(l[:1], " +-\\")
+}
+
+// patchPath cleans a path of a ---/+++ header line.
+//
+// Parameters:
+//
+//	s (string)      - the path after "--- " or "+++ "
+//	prefix (string) - the prefix to remove, "a/" or "b/"
+//
+// Returns:
+//
+//	string - the path without timestamp and prefix
+func patchPath(s, prefix string) string {
+	s, _, _ = strings.Cut(s, "\t") // drop timestamps
+	s = strings.TrimSpace(s)
+	if s == DevNull {
+		return s
+	}
+	return strings.TrimPrefix(s, prefix)
+}
+
+// ApplyHunks applies the hunks of a file patch to a text. Each hunk is
+// located by its context and removed lines, starting at the expected line
+// and searching outward; lines are compared exactly first and then
+// ignoring trailing white space. The text is only returned if all hunks
+// apply. The line break at the end of the text is kept unless a hunk at the
+// end of the file has a "\ No newline at end of file" marker.
+//
+// Parameters:
+//
+//	text (string)  - the current file content
+//	hunks ([]Hunk) - the hunks to apply
+//
+// Returns:
+//
+//	string      - the patched text
+//	[]HunkError - the hunks that failed (nil on success)
+func ApplyHunks(text string, hunks []Hunk) (string, []HunkError) {
+	lines := splitLines(text)
+	var errs []HunkError
+	offset := 0 // line shift caused by previous hunks
+	minPos := 0 // hunks must not overlap
+	finalEOL := text == "" || strings.HasSuffix(text, "\n")
+
+	for n, h := range hunks {
+		var oldLines, newLines []string
+		for _, l := range h.Lines {
+			switch l[0] {
+			case ' ':
+				oldLines = append(oldLines, l[1:])
+				newLines = append(newLines, l[1:])
+			case '-':
+				oldLines = append(oldLines, l[1:])
+			case '+':
+				newLines = append(newLines, l[1:])
+			}
+		}
+
+		expected := max(h.OldStart-1, 0) + offset
+		pos := findLines(lines, oldLines, expected, minPos)
+		if pos < 0 {
+			errs = append(errs, HunkError{Hunk: n + 1, Line: h.OldStart, Reason: "context not found"})
+			continue
+		}
+
+		// context lines keep the text of the file (e.g. its trailing white space)
+		replaced := make([]string, 0, len(newLines))
+		k := pos
+		for _, l := range h.Lines {
+			switch l[0] {
+			case ' ':
+				replaced = append(replaced, lines[k])
+				k++
+			case '-':
+				k++
+			case '+':
+				replaced = append(replaced, l[1:])
+			}
+		}
+		if h.OldNoEOL || h.NewNoEOL {
+			if k != len(lines) {
+				errs = append(errs, HunkError{Hunk: n + 1, Line: h.OldStart, Reason: `"\ No newline at end of file" before the end of the file`})
+				continue
+			}
+			finalEOL = !h.NewNoEOL
+		}
+		lines = append(lines[:pos], append(replaced, lines[k:]...)...)
+		offset += len(newLines) - len(oldLines)
+		minPos = pos + len(newLines)
+	}
+
+	if errs != nil {
+		return "", errs
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	out := strings.Join(lines, "\n")
+	if finalEOL {
+		out += "\n"
+	}
+	return out, nil
+}
+
+// findLines searches want in lines, starting at the expected position and
+// moving outward. It returns -1 if there is no match at or after minPos.
+//
+// Parameters:
+//
+//	lines ([]string) - the file lines
+//	want ([]string)  - the lines to find
+//	expected (int)   - the expected 0-based position
+//	minPos (int)     - the first allowed position
+//
+// Returns:
+//
+//	int - the 0-based position or -1
+func findLines(lines, want []string, expected, minPos int) int {
+	if len(want) == 0 {
+		return min(max(expected, minPos), len(lines))
+	}
+	last := len(lines) - len(want)
+	for _, eq := range []func(a, b string) bool{
+		func(a, b string) bool { return a == b },
+		func(a, b string) bool { return strings.TrimRight(a, " \t") == strings.TrimRight(b, " \t") },
+	} {
+		for d := 0; expected-d >= minPos || expected+d <= last; d++ {
+			candidates := []int{expected - d, expected + d}
+			if d == 0 {
+				candidates = candidates[:1]
+			}
+			for _, pos := range candidates {
+				if pos >= minPos && pos <= last && matchLines(lines[pos:pos+len(want)], want, eq) {
+					return pos
+				}
+			}
+		}
+	}
+	return -1
+}
+
+// matchLines compares two line slices of equal length.
+//
+// Parameters:
+//
+//	a ([]string)                - the lines of the file at the tested position
+//	b ([]string)                - the expected lines
+//	eq (func(a, b string) bool) - the comparison of two lines
+//
+// Returns:
+//
+//	bool - true if all lines are equal
+func matchLines(a, b []string, eq func(a, b string) bool) bool {
+	for i := range b {
+		if !eq(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
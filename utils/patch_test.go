@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePatch(t *testing.T) {
+	text := "diff --git a/main.go b/main.go\n" +
+		"index 123..456 100644\n" +
+		"--- a/main.go\t2024-01-01\n" +
+		"+++ b/main.go\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		" package main\n" +
+		"\n" +
+		"-var x = 1\n" +
+		"+var x = 2\n" +
+		"\\ No newline at end of file\n" +
+		"--- /dev/null\n" +
+		"+++ b/new.txt\n" +
+		"@@\n" +
+		"+hello\n"
+
+	patches, err := ParsePatch(text)
+	if err != nil {
+		t.Fatalf("ParsePatch failed: %v", err)
+	}
+	if len(patches) != 2 {
+		t.Fatalf("got %d patches, want 2", len(patches))
+	}
+	p := patches[0]
+	if p.Path() != "main.go" || p.IsNew() || len(p.Hunks) != 1 || p.Hunks[0].OldStart != 1 || p.Hunks[0].OldNoEOL || !p.Hunks[0].NewNoEOL {
+		t.Fatalf("unexpected first patch: %+v", p)
+	}
+	if got := strings.Join(p.Hunks[0].Lines, "|"); got != " package main| |-var x = 1|+var x = 2" {
+		t.Fatalf("unexpected hunk lines %q", got)
+	}
+	if added, removed := p.Stats(); added != 1 || removed != 1 {
+		t.Fatalf("Stats() = %d, %d", added, removed)
+	}
+	if !patches[1].IsNew() || patches[1].Path() != "new.txt" || patches[1].Hunks[0].OldStart != 0 {
+		t.Fatalf("unexpected second patch: %+v", patches[1])
+	}
+
+	if _, err := ParsePatch("@@ -1 +1 @@\n-a\n+b\n"); err == nil {
+		t.Fatal("expected error for missing file header")
+	}
+}
+
+func TestApplyHunks_NoNewlineAtEnd(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		diff    string
+		want    string
+		wantErr bool
+	}{
+		{
+			"remove final newline",
+			"a\nb\n",
+			"@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n",
+			"a\nb", false,
+		},
+		{
+			"add final newline",
+			"a\nb",
+			"@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+			"a\nb\n", false,
+		},
+		{
+			"change last line without newline",
+			"a\nb",
+			"@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+B\n\\ No newline at end of file\n",
+			"a\nB", false,
+		},
+		{
+			"new file without newline",
+			"",
+			"@@ -0,0 +1 @@\n+x\n\\ No newline at end of file\n",
+			"x", false,
+		},
+		{
+			"marker before the end",
+			"a\nb\nc\n",
+			"@@ -1,2 +1,2 @@\n a\n-b\n+B\n\\ No newline at end of file\n",
+			"", true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patches, err := ParsePatch("--- a/f.txt\n+++ b/f.txt\n" + tt.diff)
+			if err != nil {
+				t.Fatalf("ParsePatch failed: %v", err)
+			}
+			got, errs := ApplyHunks(tt.text, patches[0].Hunks)
+			if (errs != nil) != tt.wantErr {
+				t.Fatalf("errs = %v, wantErr %v", errs, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(errs[0].Error(), "No newline at end of file") {
+				t.Fatalf("unexpected error %v", errs[0])
+			}
+			if got != tt.want {
+				t.Fatalf("ApplyHunks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyHunks(t *testing.T) {
+	text := "a\nb\nc\nd\ne\nf\ng\n"
+
+	tests := []struct {
+		name    string
+		hunks   []Hunk
+		want    string
+		wantErr bool
+	}{
+		{
+			"exact position",
+			[]Hunk{{OldStart: 2, Lines: []string{" b", "-c", "+C", " d"}}},
+			"a\nb\nC\nd\ne\nf\ng\n", false,
+		},
+		{
+			"shifted line numbers and two hunks",
+			[]Hunk{
+				{OldStart: 5, Lines: []string{" a", "+0"}},
+				{OldStart: 1, Lines: []string{" f", "-g"}},
+			},
+			"a\n0\nb\nc\nd\ne\nf\n", false,
+		},
+		{
+			"trailing white space tolerated",
+			[]Hunk{{Lines: []string{" e  ", "-f"}}},
+			"a\nb\nc\nd\ne\ng\n", false,
+		},
+		{
+			"missing context rejects all",
+			[]Hunk{
+				{OldStart: 1, Lines: []string{"-a", "+A"}},
+				{OldStart: 3, Lines: []string{" x", "-c"}},
+			},
+			"", true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := ApplyHunks(text, tt.hunks)
+			if (errs != nil) != tt.wantErr {
+				t.Fatalf("errs = %v, wantErr %v", errs, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("ApplyHunks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
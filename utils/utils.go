@@ -2,9 +2,12 @@ package utils
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
 	"unicode"
+
+	"github.com/mattn/go-runewidth"
 )
 
 // ListAvailableModels lists all models via /tags API call
@@ -91,17 +94,15 @@ func MarkdownTable(tableData [][]string) string {
 			if colIdx < len(row) {
 				col = row[colIdx]
 			}
-			if len(col) > maxWidths[colIdx] {
-				maxWidths[colIdx] = len(col)
-			}
+			maxWidths[colIdx] = max(maxWidths[colIdx], DisplayWidth(col))
 		}
 	}
 
 	pad := func(s string, width int, fill byte) string {
-		if len(s) >= width {
-			return s
+		if w := DisplayWidth(s); w < width {
+			return s + strings.Repeat(string(fill), width-w)
 		}
-		return s + strings.Repeat(string(fill), width-len(s))
+		return s
 	}
 
 	var builder strings.Builder
@@ -129,6 +130,23 @@ func MarkdownTable(tableData [][]string) string {
 	return builder.String()
 }
 
+// ansiSequence matches terminal escape sequences such as colors.
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// DisplayWidth returns the number of terminal columns of a text. Escape
+// sequences take no space and wide characters (e.g. CJK) take two columns.
+//
+// Parameters:
+//
+//	s (string) - the text
+//
+// Returns:
+//
+//	int - the width in terminal columns
+func DisplayWidth(s string) int {
+	return runewidth.StringWidth(ansiSequence.ReplaceAllString(s, ""))
+}
+
 // capitalize capitalizes the first letter of a string
 //
 // Parameters:
@@ -165,3 +165,23 @@ func TestMarkdownTable_Basic(t *testing.T) {
 		t.Fatalf("MarkdownTable result mismatch\nwant:\n%s\n\ngot:\n%s", want, got)
 	}
 }
+
+func TestMarkdownTable_DisplayWidth(t *testing.T) {
+	table := [][]string{
+		{"Name", "Value"},
+		{"中文", "\033[1mbold\033[22m"},
+		{"x", "22"},
+	}
+
+	got := MarkdownTable(table)
+	want := strings.Join([]string{
+		"| Name | Value |",
+		"| ---- | ----- |",
+		"| 中文 | \033[1mbold\033[22m  |",
+		"| x    | 22    |",
+	}, "\n")
+
+	if got != want {
+		t.Fatalf("MarkdownTable result mismatch\nwant:\n%s\n\ngot:\n%s", want, got)
+	}
+}